@@ -2,25 +2,26 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"time"
 
+	"github.com/cerfical/muzik/internal/audit"
+	"github.com/cerfical/muzik/internal/blob"
 	"github.com/cerfical/muzik/internal/config"
 	"github.com/cerfical/muzik/internal/httpserv/api"
 	"github.com/cerfical/muzik/internal/log"
+	"github.com/cerfical/muzik/internal/memstore"
+	"github.com/cerfical/muzik/internal/model"
 	"github.com/cerfical/muzik/internal/postgres"
+	"github.com/cerfical/muzik/internal/sqlite"
 )
 
 func main() {
 	config := config.MustLoad(os.Args)
 
 	log := log.New(&config.Log)
-	log.WithFields(
-		"addr", config.DB.Addr,
-		"user", config.DB.User,
-		"name", config.DB.Name,
-	).Info("Opening the database")
-
-	store, err := postgres.OpenTrackStore(&config.DB)
+	store, err := openStore(&config.DB, log)
 	if err != nil {
 		log.Fatal("Failed to open the database", err)
 	}
@@ -32,8 +33,126 @@ func main() {
 		}
 	}()
 
-	server := api.NewServer(&config.Server, store, log)
-	if err := server.Run(context.Background()); err != nil {
+	blobs, err := config.Blobs.OpenStore(log)
+	if err != nil {
+		log.Fatal("Failed to open the blob storage", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if pgStore, ok := store.(*postgres.Store); ok && config.DB.StatsInterval > 0 {
+		go logPoolStats(ctx, pgStore, config.DB.StatsInterval, log)
+	}
+
+	// Record all changes made through the server and background jobs in the audit log
+	audited := audit.New(store)
+	if config.Trash.Retention > 0 {
+		go purgeTrash(audit.NewContext(ctx, audit.Origin{Actor: "trash-purge"}), audited, blobs, &config.Trash, log)
+	}
+
+	server := api.NewServer(&config.Server, &config.API, audited, blobs, log)
+	if err := server.Run(ctx); err != nil {
 		log.Error("The server has terminated abnormally", err)
 	}
 }
+
+func openStore(cfg *config.DBConfig, log *log.Logger) (model.Store, error) {
+	switch cfg.Driver {
+	case config.DriverMemory:
+		log.Info("Opening an in-memory database")
+		return memstore.New(), nil
+	case config.DriverSQLite:
+		log.WithFields("path", cfg.SQLite.Path).Info("Opening the database")
+		return sqlite.Open(&cfg.SQLite)
+	}
+
+	params, err := cfg.Params()
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	log.WithFields(
+		"host", params.Host,
+		"port", params.Port,
+		"name", params.Database,
+		"user", params.User,
+		"sslmode", params.SSLMode,
+	).Info("Opening the database")
+	return postgres.Open(&cfg.Config)
+}
+
+// purgeTrash periodically deletes the tracks that have been in the trash for longer than the retention period until the context is done.
+func purgeTrash(ctx context.Context, store model.Store, blobs blob.Store, cfg *config.TrashConfig, log *log.Logger) {
+	ticker := time.NewTicker(cfg.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		n, err := purgeExpired(ctx, store, blobs, time.Now().Add(-cfg.Retention), log)
+		if err != nil && ctx.Err() == nil {
+			log.Error("Failed to purge the trash", err)
+		}
+		if n > 0 {
+			log.WithFields("tracks", n).Info("Purged the trash")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeBatchSize is the maximum number of tracks purged from the trash in one transaction.
+const purgeBatchSize = 100
+
+// purgeExpired purges the tracks moved to the trash before the specified time in batches, returning their number.
+// The audio files of the purged tracks are released as well, with failures to do so only logged.
+func purgeExpired(ctx context.Context, store model.Store, blobs blob.Store, before time.Time, log *log.Logger) (int, error) {
+	var n int
+	for {
+		purged, err := store.PurgeTracks(ctx, before, purgeBatchSize)
+		n += len(purged)
+
+		for _, track := range purged {
+			if track.Meta == nil || track.Meta.Audio == nil {
+				continue
+			}
+			if err := api.ReleaseAudio(ctx, store, blobs, track.Meta.Audio); err != nil {
+				log.WithFields("sha256", track.Meta.Audio.SHA256).Error("Failed to release an audio file", err)
+			}
+		}
+
+		if err != nil || len(purged) < purgeBatchSize {
+			return n, err
+		}
+	}
+}
+
+// logPoolStats periodically logs the state of the database connection pool until the context is done.
+func logPoolStats(ctx context.Context, store *postgres.Store, interval time.Duration, log *log.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			stats := store.Stats()
+			log.WithFields(
+				"total", stats.TotalConns(),
+				"idle", stats.IdleConns(),
+				"acquired", stats.AcquiredConns(),
+				"max", stats.MaxConns(),
+				"acquires", stats.AcquireCount(),
+				"empty_acquires", stats.EmptyAcquireCount(),
+				"acquire_time", stats.AcquireDuration().String(),
+				"new_conns", stats.NewConnsCount(),
+				"lifetime_closes", stats.MaxLifetimeDestroyCount(),
+				"idle_closes", stats.MaxIdleDestroyCount(),
+			).Info("Database pool stats")
+		}
+	}
+}
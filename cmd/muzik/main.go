@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cerfical/muzik/internal/audit"
+	"github.com/cerfical/muzik/internal/config"
+	"github.com/cerfical/muzik/internal/dedupe"
+	"github.com/cerfical/muzik/internal/log"
+	"github.com/cerfical/muzik/internal/memstore"
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/cerfical/muzik/internal/postgres"
+	"github.com/cerfical/muzik/internal/sqlite"
+)
+
+const usage = `usage: muzik migrate up|down|status [config]
+       muzik duplicates [-similarity N] [config]
+       muzik merge ID DUPLICATE_ID[,DUPLICATE_ID...] [config]`
+
+func main() {
+	if len(os.Args) < 2 {
+		exitUsage()
+	}
+
+	switch os.Args[1] {
+	case "migrate":
+		if len(os.Args) < 3 {
+			exitUsage()
+		}
+
+		config, log := load(os.Args[3:])
+		if err := migrate(context.Background(), os.Args[2], &config.DB, log); err != nil {
+			log.Fatal("Failed to migrate the database", err)
+		}
+	case "duplicates":
+		flags := flag.NewFlagSet("duplicates", flag.ExitOnError)
+		similarity := flags.Float64("similarity", dedupe.DefaultSimilarity, "the lowest `similarity` of titles, from 0 to 1, for tracks to be grouped")
+		flags.Parse(os.Args[2:])
+
+		config, log := load(flags.Args())
+		if err := listDuplicates(context.Background(), &config.DB, *similarity, log); err != nil {
+			log.Fatal("Failed to find duplicate tracks", err)
+		}
+	case "merge":
+		if len(os.Args) < 4 {
+			exitUsage()
+		}
+
+		keepID, err := strconv.Atoi(os.Args[2])
+		if err != nil {
+			exitUsage()
+		}
+
+		var dropIDs []int
+		for _, arg := range strings.Split(os.Args[3], ",") {
+			id, err := strconv.Atoi(arg)
+			if err != nil {
+				exitUsage()
+			}
+			dropIDs = append(dropIDs, id)
+		}
+
+		config, log := load(os.Args[4:])
+		if err := merge(context.Background(), &config.DB, keepID, dropIDs, log); err != nil {
+			log.Fatal("Failed to merge tracks", err)
+		}
+	default:
+		exitUsage()
+	}
+}
+
+func exitUsage() {
+	fmt.Fprintln(os.Stderr, usage)
+	os.Exit(2)
+}
+
+// load loads the config from the path in args, if any, which must come after all arguments of the command.
+func load(args []string) (*config.Config, *log.Logger) {
+	config := config.MustLoad(append([]string{os.Args[0]}, args...))
+	return config, log.New(&config.Log)
+}
+
+func migrate(ctx context.Context, cmd string, cfg *config.DBConfig, log *log.Logger) error {
+	if cfg.Driver != config.DriverPostgres {
+		return fmt.Errorf("the %q database driver does not support migrations", cfg.Driver)
+	}
+
+	migrator, err := postgres.OpenMigrator(&cfg.Config)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	switch cmd {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, m := range applied {
+			log.WithFields("migration", m.String()).Info("Applied a migration")
+		}
+		if err == nil && len(applied) == 0 {
+			log.Info("The database is up to date")
+		}
+		return err
+	case "down":
+		reverted, err := migrator.Down(ctx)
+		if reverted != nil {
+			log.WithFields("migration", reverted.String()).Info("Reverted a migration")
+		} else if err == nil {
+			log.Info("There are no migrations to revert")
+		}
+		return err
+	case "status":
+		migrations, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			if m.AppliedAt.IsZero() {
+				fmt.Printf("%v\tpending\n", m)
+			} else {
+				fmt.Printf("%v\tapplied at %v\n", m, m.AppliedAt.Local())
+			}
+		}
+		return nil
+	default:
+		return errors.New(usage)
+	}
+}
+
+// openStore opens the database selected by the config.
+func openStore(cfg *config.DBConfig) (model.Store, error) {
+	switch cfg.Driver {
+	case config.DriverMemory:
+		return memstore.New(), nil
+	case config.DriverSQLite:
+		return sqlite.Open(&cfg.SQLite)
+	default:
+		return postgres.Open(&cfg.Config)
+	}
+}
+
+// listDuplicates prints groups of likely duplicate tracks, each followed by the IDs and titles of its tracks.
+func listDuplicates(ctx context.Context, cfg *config.DBConfig, similarity float64, log *log.Logger) error {
+	store, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	tracks, err := dedupe.ReadTracks(ctx, store)
+	if err != nil {
+		return err
+	}
+
+	titles := make(map[int]string, len(tracks))
+	for _, t := range tracks {
+		titles[t.ID] = t.Attrs.Title
+	}
+
+	groups := dedupe.Find(tracks, similarity)
+	for _, g := range groups {
+		fmt.Printf("%q\tsimilarity %.2f\n", g.Attrs.Title, g.Attrs.Similarity)
+		for _, id := range g.Rels.TrackIDs {
+			fmt.Printf("\t%d\t%s\n", id, titles[id])
+		}
+	}
+
+	if len(groups) == 0 {
+		log.Info("No duplicate tracks found")
+	}
+	return nil
+}
+
+// merge merges duplicates into the track with the keepID, recording the changes in the audit log.
+func merge(ctx context.Context, cfg *config.DBConfig, keepID int, dropIDs []int, log *log.Logger) error {
+	store, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ctx = audit.NewContext(ctx, audit.Origin{Actor: "muzik-merge"})
+
+	var track *model.Track
+	err = audit.New(store).RunInTx(ctx, func(tx model.TxStores) (err error) {
+		track, err = dedupe.Merge(ctx, tx, keepID, dropIDs)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	log.WithFields("id", track.ID, "title", track.Attrs.Title, "merged", len(dropIDs)).Info("Merged tracks")
+	return nil
+}
@@ -0,0 +1,140 @@
+// Package audiofmt recognizes the audio file formats supported for track uploads.
+package audiofmt
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrUnknownFormat is returned by [Sniff] when the data is not in any of the supported formats.
+var ErrUnknownFormat = errors.New("unknown audio format")
+
+// Format describes an audio file format.
+type Format struct {
+	// Name is a short human-readable name of the format.
+	Name string
+
+	// MediaType is the preferred media type of the format.
+	MediaType string
+
+	// Aliases are other media types the format is known under.
+	Aliases []string
+}
+
+var (
+	MP3 = &Format{
+		Name:      "mp3",
+		MediaType: "audio/mpeg",
+		Aliases:   []string{"audio/mp3", "audio/mpeg3", "audio/x-mpeg-3"},
+	}
+
+	FLAC = &Format{
+		Name:      "flac",
+		MediaType: "audio/flac",
+		Aliases:   []string{"audio/x-flac"},
+	}
+
+	Ogg = &Format{
+		Name:      "ogg",
+		MediaType: "audio/ogg",
+		Aliases:   []string{"application/ogg", "audio/vorbis"},
+	}
+
+	WAV = &Format{
+		Name:      "wav",
+		MediaType: "audio/wav",
+		Aliases:   []string{"audio/wave", "audio/x-wav", "audio/vnd.wave"},
+	}
+)
+
+// Formats lists all supported audio formats.
+var Formats = []*Format{MP3, FLAC, Ogg, WAV}
+
+// ByMediaType looks up a format by one of its media types.
+func ByMediaType(mediaType string) (*Format, bool) {
+	for _, f := range Formats {
+		if strings.EqualFold(f.MediaType, mediaType) {
+			return f, true
+		}
+
+		for _, alias := range f.Aliases {
+			if strings.EqualFold(alias, mediaType) {
+				return f, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Sniff determines the format of audio data by the signature at its start.
+//
+// An ID3v2 tag in front of the data is skipped, since some tools prepend it to files other than MP3.
+func Sniff(r io.ReaderAt) (*Format, error) {
+	var header [12]byte
+	n, err := r.ReadAt(header[:], 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	if start, ok := id3v2Size(header[:n]); ok {
+		n, err = r.ReadAt(header[:], start)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+
+		// Anything after an ID3 tag other than FLAC is assumed to be MP3, which may well be padded with garbage
+		if bytes.HasPrefix(header[:n], []byte("fLaC")) {
+			return FLAC, nil
+		}
+		return MP3, nil
+	}
+
+	h := header[:n]
+	switch {
+	case bytes.HasPrefix(h, []byte("fLaC")):
+		return FLAC, nil
+	case bytes.HasPrefix(h, []byte("OggS")):
+		return Ogg, nil
+	case len(h) >= 12 && bytes.Equal(h[0:4], []byte("RIFF")) && bytes.Equal(h[8:12], []byte("WAVE")):
+		return WAV, nil
+	case isMPEGFrameSync(h):
+		return MP3, nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+// id3v2Size reports the total size of an ID3v2 tag at the start of the header, if there is one.
+func id3v2Size(h []byte) (int64, bool) {
+	if len(h) < 10 || !bytes.HasPrefix(h, []byte("ID3")) {
+		return 0, false
+	}
+
+	// The size is stored as a syncsafe integer with the high bit of each byte unset
+	var size int64
+	for _, b := range h[6:10] {
+		if b&0x80 != 0 {
+			return 0, false
+		}
+		size = size<<7 | int64(b)
+	}
+
+	size += 10
+	if h[5]&0x10 != 0 {
+		// A footer follows the tag
+		size += 10
+	}
+	return size, true
+}
+
+// isMPEGFrameSync reports whether the header starts with an MPEG audio frame header.
+func isMPEGFrameSync(h []byte) bool {
+	if len(h) < 4 || h[0] != 0xFF || h[1]&0xE0 != 0xE0 {
+		return false
+	}
+
+	version, layer := h[1]>>3&0x03, h[1]>>1&0x03
+	bitrate, sampleRate := h[2]>>4, h[2]>>2&0x03
+	return version != 0x01 && layer != 0x00 && bitrate != 0x0F && sampleRate != 0x03
+}
@@ -0,0 +1,70 @@
+package audiofmt_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cerfical/muzik/internal/audiofmt"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestAudioFormats(t *testing.T) {
+	suite.Run(t, new(AudioFormatsTest))
+}
+
+type AudioFormatsTest struct {
+	suite.Suite
+}
+
+func (t *AudioFormatsTest) TestSniff_Ok() {
+	id3 := []byte("ID3\x04\x00\x00\x00\x00\x00\x05xxxxx")
+
+	tests := []struct {
+		name  string
+		input []byte
+		want  *audiofmt.Format
+	}{
+		{"mp3_frame", []byte{0xFF, 0xFB, 0x90, 0x64, 0x00}, audiofmt.MP3},
+		{"mp3_id3", append(id3, 0xFF, 0xFB, 0x90, 0x64), audiofmt.MP3},
+		{"flac", []byte("fLaC\x00\x00\x00\x22"), audiofmt.FLAC},
+		{"flac_id3", append(id3, "fLaC"...), audiofmt.FLAC},
+		{"ogg", []byte("OggS\x00\x02"), audiofmt.Ogg},
+		{"wav", []byte("RIFF\x24\x00\x00\x00WAVEfmt "), audiofmt.WAV},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func() {
+			f, err := audiofmt.Sniff(bytes.NewReader(test.input))
+			t.Require().NoError(err)
+			t.Equal(test.want, f)
+		})
+	}
+}
+
+func (t *AudioFormatsTest) TestSniff_Unknown() {
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{"empty", nil},
+		{"text", []byte("hello, world")},
+		{"riff_not_wave", []byte("RIFF\x24\x00\x00\x00AVI LIST")},
+		{"bad_frame", []byte{0xFF, 0xF9, 0x90, 0x64}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func() {
+			_, err := audiofmt.Sniff(bytes.NewReader(test.input))
+			t.ErrorIs(err, audiofmt.ErrUnknownFormat)
+		})
+	}
+}
+
+func (t *AudioFormatsTest) TestByMediaType() {
+	f, ok := audiofmt.ByMediaType("Audio/X-Wav")
+	t.Require().True(ok)
+	t.Equal(audiofmt.WAV, f)
+
+	_, ok = audiofmt.ByMediaType("video/mp4")
+	t.False(ok)
+}
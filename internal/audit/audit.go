@@ -0,0 +1,237 @@
+// Package audit records changes made to a music library in its audit log.
+package audit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/cerfical/muzik/internal/model"
+)
+
+// Origin describes where changes come from.
+type Origin struct {
+	Actor     string
+	RequestID string
+}
+
+type originKey struct{}
+
+// NewContext attaches the origin of changes made with the context.
+func NewContext(ctx context.Context, origin Origin) context.Context {
+	return context.WithValue(ctx, originKey{}, origin)
+}
+
+func fromContext(ctx context.Context) Origin {
+	origin, _ := ctx.Value(originKey{}).(Origin)
+	return origin
+}
+
+// Store records all changes made to tracks through it in the audit log of the underlying store, in the same transactions as the changes themselves.
+type Store struct {
+	model.Store
+}
+
+func New(store model.Store) *Store {
+	return &Store{store}
+}
+
+func (s *Store) RunInTx(ctx context.Context, f func(model.TxStores) error) error {
+	return s.Store.RunInTx(ctx, func(tx model.TxStores) error {
+		return f(&txStores{tx})
+	})
+}
+
+func (s *Store) CreateTrack(ctx context.Context, track *model.Track) (created *model.Track, err error) {
+	err = s.RunInTx(ctx, func(tx model.TxStores) error {
+		created, err = tx.CreateTrack(ctx, track)
+		return err
+	})
+	return created, err
+}
+
+func (s *Store) CreateTracks(ctx context.Context, tracks []model.Track) (created []model.Track, err error) {
+	err = s.RunInTx(ctx, func(tx model.TxStores) error {
+		created, err = tx.CreateTracks(ctx, tracks)
+		return err
+	})
+	return created, err
+}
+
+func (s *Store) UpdateTrack(ctx context.Context, track *model.Track) (updated *model.Track, err error) {
+	err = s.RunInTx(ctx, func(tx model.TxStores) error {
+		updated, err = tx.UpdateTrack(ctx, track)
+		return err
+	})
+	return updated, err
+}
+
+func (s *Store) SetTrackAudio(ctx context.Context, id int, audio *model.Audio) (updated *model.Track, err error) {
+	err = s.RunInTx(ctx, func(tx model.TxStores) error {
+		updated, err = tx.SetTrackAudio(ctx, id, audio)
+		return err
+	})
+	return updated, err
+}
+
+func (s *Store) DeleteTrack(ctx context.Context, id int) error {
+	return s.RunInTx(ctx, func(tx model.TxStores) error {
+		return tx.DeleteTrack(ctx, id)
+	})
+}
+
+func (s *Store) DeleteTracks(ctx context.Context, ids []int) error {
+	return s.RunInTx(ctx, func(tx model.TxStores) error {
+		return tx.DeleteTracks(ctx, ids)
+	})
+}
+
+func (s *Store) RestoreTrack(ctx context.Context, id int) (restored *model.Track, err error) {
+	err = s.RunInTx(ctx, func(tx model.TxStores) error {
+		restored, err = tx.RestoreTrack(ctx, id)
+		return err
+	})
+	return restored, err
+}
+
+func (s *Store) PurgeTracks(ctx context.Context, before time.Time, limit int) (purged []model.Track, err error) {
+	err = s.RunInTx(ctx, func(tx model.TxStores) error {
+		purged, err = tx.PurgeTracks(ctx, before, limit)
+		return err
+	})
+	return purged, err
+}
+
+// txStores records changes to tracks made within a transaction.
+type txStores struct {
+	model.TxStores
+}
+
+func (tx *txStores) CreateTrack(ctx context.Context, track *model.Track) (*model.Track, error) {
+	created, err := tx.TxStores.CreateTrack(ctx, track)
+	if err != nil {
+		return nil, err
+	}
+	return created, tx.record(ctx, model.AuditCreate, created.ID, nil, created)
+}
+
+func (tx *txStores) CreateTracks(ctx context.Context, tracks []model.Track) ([]model.Track, error) {
+	created, err := tx.TxStores.CreateTracks(ctx, tracks)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range created {
+		if err := tx.record(ctx, model.AuditCreate, created[i].ID, nil, &created[i]); err != nil {
+			return nil, err
+		}
+	}
+	return created, nil
+}
+
+func (tx *txStores) UpdateTrack(ctx context.Context, track *model.Track) (*model.Track, error) {
+	old, err := tx.TxStores.GetTrack(ctx, track.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := tx.TxStores.UpdateTrack(ctx, track)
+	if err != nil {
+		return nil, err
+	}
+	return updated, tx.record(ctx, model.AuditUpdate, updated.ID, old, updated)
+}
+
+func (tx *txStores) SetTrackAudio(ctx context.Context, id int, audio *model.Audio) (*model.Track, error) {
+	old, err := tx.TxStores.GetTrack(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := tx.TxStores.SetTrackAudio(ctx, id, audio)
+	if err != nil {
+		return nil, err
+	}
+	return updated, tx.record(ctx, model.AuditUpdate, id, old, updated)
+}
+
+func (tx *txStores) DeleteTrack(ctx context.Context, id int) error {
+	old, err := tx.TxStores.GetTrack(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.TxStores.DeleteTrack(ctx, id); err != nil {
+		return err
+	}
+	return tx.record(ctx, model.AuditDelete, id, old, nil)
+}
+
+func (tx *txStores) DeleteTracks(ctx context.Context, ids []int) error {
+	old := make(map[int]*model.Track, len(ids))
+	for _, id := range ids {
+		track, err := tx.TxStores.GetTrack(ctx, id)
+		if err != nil && !errors.Is(err, model.ErrNotFound) {
+			return err
+		}
+		// Missing tracks are left to the store to report all at once
+		old[id] = track
+	}
+
+	if err := tx.TxStores.DeleteTracks(ctx, ids); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if track, ok := old[id]; ok {
+			if err := tx.record(ctx, model.AuditDelete, id, track, nil); err != nil {
+				return err
+			}
+			delete(old, id)
+		}
+	}
+	return nil
+}
+
+func (tx *txStores) RestoreTrack(ctx context.Context, id int) (*model.Track, error) {
+	restored, err := tx.TxStores.RestoreTrack(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return restored, tx.record(ctx, model.AuditRestore, id, nil, restored)
+}
+
+func (tx *txStores) PurgeTracks(ctx context.Context, before time.Time, limit int) ([]model.Track, error) {
+	// The purged tracks are recorded as they were before being purged, so there is no need to read the trash separately
+	purged, err := tx.TxStores.PurgeTracks(ctx, before, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range purged {
+		if err := tx.record(ctx, model.AuditPurge, purged[i].ID, &purged[i], nil); err != nil {
+			return nil, err
+		}
+	}
+	return purged, nil
+}
+
+// record appends an entry for the change of a track from one state to another, with nil meaning that the track is not visible.
+func (tx *txStores) record(ctx context.Context, action model.AuditAction, id int, before, after *model.Track) error {
+	changes, err := diff(before, after)
+	if err != nil {
+		return err
+	}
+
+	origin := fromContext(ctx)
+	_, err = tx.AppendAudit(ctx, &model.AuditEntry{
+		Attrs: model.AuditAttrs{
+			Actor:     origin.Actor,
+			RequestID: origin.RequestID,
+			Action:    action,
+			Resource:  model.ResourceID{Type: model.TrackType, ID: id},
+			Changes:   changes,
+		},
+	})
+	return err
+}
@@ -0,0 +1,188 @@
+package audit_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cerfical/muzik/internal/audit"
+	"github.com/cerfical/muzik/internal/memstore"
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestAudit(t *testing.T) {
+	suite.Run(t, new(AuditTest))
+}
+
+type AuditTest struct {
+	suite.Suite
+
+	ctx   context.Context
+	store *audit.Store
+}
+
+func (t *AuditTest) SetupTest() {
+	t.ctx = audit.NewContext(context.Background(), audit.Origin{Actor: "alice", RequestID: "req-1"})
+	t.store = audit.New(memstore.New())
+}
+
+func (t *AuditTest) TestCreateTrack() {
+	track := t.createTrack("Song")
+
+	entries := t.entries()
+	t.Require().Len(entries, 1)
+
+	e := entries[0].Attrs
+	t.Equal("alice", e.Actor)
+	t.Equal("req-1", e.RequestID)
+	t.Equal(model.AuditCreate, e.Action)
+	t.Equal(model.ResourceID{Type: model.TrackType, ID: track.ID}, e.Resource)
+	t.False(e.Time.IsZero())
+
+	t.Nil(e.Changes["/attributes/title"].Before)
+	t.JSONEq(`"Song"`, string(e.Changes["/attributes/title"].After))
+}
+
+func (t *AuditTest) TestUpdateTrack() {
+	track := t.createTrack("Song")
+
+	track.Attrs.Title = "New Song"
+	_, err := t.store.UpdateTrack(t.ctx, track)
+	t.Require().NoError(err)
+
+	entries := t.entries()
+	t.Require().Len(entries, 2)
+
+	e := entries[1].Attrs
+	t.Equal(model.AuditUpdate, e.Action)
+	t.Equal(map[string]model.AuditChange{
+		"/attributes/title": {
+			Before: json.RawMessage(`"Song"`),
+			After:  json.RawMessage(`"New Song"`),
+		},
+	}, e.Changes)
+}
+
+func (t *AuditTest) TestSetTrackAudio() {
+	track := t.createTrack("Song")
+
+	_, err := t.store.SetTrackAudio(t.ctx, track.ID, &model.Audio{ContentType: "audio/flac", Size: 4, SHA256: "abcd"})
+	t.Require().NoError(err)
+
+	entries := t.entries()
+	t.Require().Len(entries, 2)
+
+	e := entries[1].Attrs
+	t.Equal(model.AuditUpdate, e.Action)
+	t.Equal(map[string]model.AuditChange{
+		"/meta/audio": {
+			After: json.RawMessage(`{"contentType":"audio/flac","size":4,"sha256":"abcd"}`),
+		},
+	}, e.Changes)
+}
+
+func (t *AuditTest) TestDeleteAndRestoreTrack() {
+	track := t.createTrack("Song")
+
+	t.Require().NoError(t.store.DeleteTrack(t.ctx, track.ID))
+	_, err := t.store.RestoreTrack(t.ctx, track.ID)
+	t.Require().NoError(err)
+
+	entries := t.entries()
+	t.Require().Len(entries, 3)
+
+	deleted, restored := entries[1].Attrs, entries[2].Attrs
+	t.Equal(model.AuditDelete, deleted.Action)
+	t.JSONEq(`"Song"`, string(deleted.Changes["/attributes/title"].Before))
+	t.Nil(deleted.Changes["/attributes/title"].After)
+
+	t.Equal(model.AuditRestore, restored.Action)
+	t.Nil(restored.Changes["/attributes/title"].Before)
+	t.JSONEq(`"Song"`, string(restored.Changes["/attributes/title"].After))
+}
+
+func (t *AuditTest) TestPurgeTracks() {
+	trashed := t.createTrack("Trashed")
+	kept := t.createTrack("Kept")
+	t.Require().NoError(t.store.DeleteTrack(t.ctx, trashed.ID))
+
+	purged, err := t.store.PurgeTracks(t.ctx, time.Now().Add(time.Hour), 0)
+	t.Require().NoError(err)
+	t.Len(purged, 1)
+
+	entries := t.entries()
+	t.Require().Len(entries, 4)
+
+	e := entries[3].Attrs
+	t.Equal(model.AuditPurge, e.Action)
+	t.Equal(trashed.ID, e.Resource.ID)
+	t.NotEqual(kept.ID, e.Resource.ID)
+}
+
+func (t *AuditTest) TestBulkChanges() {
+	created, err := t.store.CreateTracks(t.ctx, []model.Track{
+		{Attrs: model.TrackAttrs{Title: "Song"}},
+		{Attrs: model.TrackAttrs{Title: "Other Song"}},
+	})
+	t.Require().NoError(err)
+	t.Require().NoError(t.store.DeleteTracks(t.ctx, []int{created[1].ID, created[0].ID}))
+
+	entries := t.entries()
+	t.Require().Len(entries, 4)
+
+	var actions []model.AuditAction
+	var ids []int
+	for _, e := range entries {
+		actions = append(actions, e.Attrs.Action)
+		ids = append(ids, e.Attrs.Resource.ID)
+	}
+	t.Equal([]model.AuditAction{model.AuditCreate, model.AuditCreate, model.AuditDelete, model.AuditDelete}, actions)
+	t.Equal([]int{created[0].ID, created[1].ID, created[1].ID, created[0].ID}, ids)
+	t.JSONEq(`"Other Song"`, string(entries[2].Attrs.Changes["/attributes/title"].Before))
+}
+
+func (t *AuditTest) TestFailedChange_NotRecorded() {
+	_, err := t.store.UpdateTrack(t.ctx, &model.Track{ID: 1})
+	t.ErrorIs(err, model.ErrNotFound)
+
+	t.ErrorIs(t.store.DeleteTrack(t.ctx, 1), model.ErrNotFound)
+	t.ErrorIs(t.store.DeleteTracks(t.ctx, []int{1}), model.ErrNotFound)
+	t.Empty(t.entries())
+}
+
+func (t *AuditTest) TestRunInTx_Rollback() {
+	errRollback := errors.New("rollback")
+	err := t.store.RunInTx(t.ctx, func(tx model.TxStores) error {
+		if _, err := tx.CreateTrack(t.ctx, &model.Track{Attrs: model.TrackAttrs{Title: "Song"}}); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	t.ErrorIs(err, errRollback)
+	t.Empty(t.entries())
+}
+
+func (t *AuditTest) TestNoOrigin() {
+	_, err := t.store.CreateTrack(context.Background(), &model.Track{Attrs: model.TrackAttrs{Title: "Song"}})
+	t.Require().NoError(err)
+
+	entries := t.entries()
+	t.Require().Len(entries, 1)
+	t.Empty(entries[0].Attrs.Actor)
+	t.Empty(entries[0].Attrs.RequestID)
+}
+
+func (t *AuditTest) createTrack(title string) *model.Track {
+	track, err := t.store.CreateTrack(t.ctx, &model.Track{Attrs: model.TrackAttrs{Title: title}})
+	t.Require().NoError(err)
+	return track
+}
+
+func (t *AuditTest) entries() []model.AuditEntry {
+	page, err := t.store.GetAudit(t.ctx, &model.AuditQuery{})
+	t.Require().NoError(err)
+	return page.Entries
+}
@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/cerfical/muzik/internal/model"
+)
+
+// diff compares the members of two resource objects, either of which may be nil, down to their attributes, relationships and meta.
+func diff(before, after any) (map[string]model.AuditChange, error) {
+	old, err := members(before)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := members(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]model.AuditChange)
+	for ptr, v := range old {
+		if !bytes.Equal(v, current[ptr]) {
+			changes[ptr] = model.AuditChange{Before: v, After: current[ptr]}
+		}
+	}
+	for ptr, v := range current {
+		if _, ok := old[ptr]; !ok {
+			changes[ptr] = model.AuditChange{After: v}
+		}
+	}
+	return changes, nil
+}
+
+// members flattens the attributes, relationships and meta of a resource object into a map from JSON pointers to their values.
+func members(res any) (map[string]json.RawMessage, error) {
+	flat := make(map[string]json.RawMessage)
+	if res == nil {
+		return flat, nil
+	}
+
+	b, err := json.Marshal(res)
+	if err != nil {
+		return nil, err
+	}
+
+	var obj struct {
+		Attrs map[string]json.RawMessage `json:"attributes"`
+		Rels  map[string]json.RawMessage `json:"relationships"`
+		Meta  map[string]json.RawMessage `json:"meta"`
+	}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return nil, err
+	}
+
+	for name, v := range obj.Attrs {
+		flat["/attributes/"+name] = v
+	}
+	for name, v := range obj.Rels {
+		flat["/relationships/"+name] = v
+	}
+	for name, v := range obj.Meta {
+		flat["/meta/"+name] = v
+	}
+	return flat, nil
+}
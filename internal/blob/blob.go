@@ -0,0 +1,51 @@
+// Package blob stores large binary objects, such as audio files, outside of the database.
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+// ErrNotFound is returned when there is no blob with the requested key.
+var ErrNotFound = errors.New("blob not found")
+
+// ErrInvalidKey is returned for keys that cannot be safely mapped onto the storage.
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// ErrSizeMismatch is returned by [Store.Put] when the content does not match its declared size.
+var ErrSizeMismatch = errors.New("blob size mismatch")
+
+// Store is a flat key-value storage of blobs.
+//
+// Keys are slash-separated relative paths, such as "audio/0a1b".
+type Store interface {
+	// Put stores the content of r under the key, replacing any blob that is already there.
+	//
+	// The size must be the exact length of the content.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+
+	// Open opens the blob with the key for reading.
+	Open(ctx context.Context, key string) (Reader, error)
+
+	// Delete removes the blob with the key, succeeding if there is no such blob.
+	Delete(ctx context.Context, key string) error
+}
+
+// Reader provides random access to the content of a blob.
+type Reader interface {
+	io.ReadSeekCloser
+
+	// Size returns the length of the content in bytes.
+	Size() int64
+}
+
+// checkKey validates that the key is a clean relative path that does not escape the storage root.
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || key == ".." || strings.HasPrefix(key, "../") {
+		return ErrInvalidKey
+	}
+	return nil
+}
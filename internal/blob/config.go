@@ -0,0 +1,19 @@
+package blob
+
+type LocalConfig struct {
+	// Dir is the directory the blobs are stored in, created on demand.
+	Dir string
+}
+
+type S3Config struct {
+	// Endpoint is the base URL of an S3-compatible service, such as https://s3.us-east-1.amazonaws.com.
+	Endpoint string
+	Region   string
+	Bucket   string
+
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// PathStyle puts the bucket into the URL path rather than the host name, as required by most self-hosted services.
+	PathStyle bool
+}
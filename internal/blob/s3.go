@@ -0,0 +1,275 @@
+package blob
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// NewS3 creates a store keeping blobs as objects in a bucket of an S3-compatible service.
+func NewS3(config *S3Config) (*S3Store, error) {
+	if config.Bucket == "" {
+		return nil, errors.New("the S3 bucket is not set")
+	}
+
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		if config.Region == "" {
+			return nil, errors.New("either the S3 endpoint or the region must be set")
+		}
+		endpoint = "https://s3." + config.Region + ".amazonaws.com"
+	}
+
+	base, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint: %w", err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" || base.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", endpoint)
+	}
+
+	if config.PathStyle {
+		base = base.JoinPath(config.Bucket)
+	} else {
+		base.Host = config.Bucket + "." + base.Host
+	}
+
+	region := config.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	return &S3Store{
+		base:   base,
+		client: http.DefaultClient,
+		signer: signer{
+			accessKeyID:     config.AccessKeyID,
+			secretAccessKey: config.SecretAccessKey,
+			region:          region,
+		},
+	}, nil
+}
+
+type S3Store struct {
+	base   *url.URL
+	client *http.Client
+	signer signer
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	body := &exactReader{r: r, remaining: size}
+	req, err := s.newRequest(ctx, http.MethodPut, key, body)
+	if err != nil {
+		return err
+	}
+
+	req.ContentLength = size
+	if size == 0 {
+		// Make the client send the zero Content-Length rather than treat the body as of unknown length
+		req.Body = http.NoBody
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	res, err := s.do(req, unsignedPayload)
+	if body.mismatch {
+		return ErrSizeMismatch
+	}
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+func (s *S3Store) Open(ctx context.Context, key string) (Reader, error) {
+	req, err := s.newRequest(ctx, http.MethodHead, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.do(req, emptyPayload)
+	if err != nil {
+		return nil, err
+	}
+	res.Body.Close()
+
+	return &s3Reader{
+		ctx:   ctx,
+		store: s,
+		key:   key,
+		size:  res.ContentLength,
+	}, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := s.do(req, emptyPayload)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	return res.Body.Close()
+}
+
+func (s *S3Store) newRequest(ctx context.Context, method, key string, body io.Reader) (*http.Request, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	return http.NewRequestWithContext(ctx, method, s.base.JoinPath(key).String(), body)
+}
+
+// do signs and sends the request, turning unsuccessful responses into errors.
+func (s *S3Store) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	s.signer.sign(req, payloadHash, time.Now())
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return res, nil
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+
+	// Error responses carry an XML document describing the error, except for HEAD requests
+	var body struct {
+		Code    string
+		Message string
+	}
+	if err := xml.NewDecoder(io.LimitReader(res.Body, 64<<10)).Decode(&body); err != nil || body.Code == "" {
+		return nil, fmt.Errorf("S3 %s request failed with status %s", req.Method, res.Status)
+	}
+	return nil, fmt.Errorf("S3 %s request failed with status %s: %s: %s", req.Method, res.Status, body.Code, body.Message)
+}
+
+// s3Reader reads an object lazily with ranged requests, starting a new one after each seek.
+type s3Reader struct {
+	ctx   context.Context
+	store *S3Store
+	key   string
+	size  int64
+
+	offset int64
+	body   io.ReadCloser
+}
+
+func (r *s3Reader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+
+	if r.body == nil {
+		req, err := r.store.newRequest(r.ctx, http.MethodGet, r.key, nil)
+		if err != nil {
+			return 0, err
+		}
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(r.offset, 10)+"-")
+
+		res, err := r.store.do(req, emptyPayload)
+		if err != nil {
+			return 0, err
+		}
+		if res.StatusCode != http.StatusPartialContent && r.offset != 0 {
+			res.Body.Close()
+			return 0, fmt.Errorf("S3 ignored the range of a GET request, responding with status %s", res.Status)
+		}
+		r.body = res.Body
+	}
+
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	if errors.Is(err, io.EOF) && r.offset < r.size {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (r *s3Reader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.offset
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, errors.New("invalid whence")
+	}
+
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+
+	if offset != r.offset {
+		r.closeBody()
+		r.offset = offset
+	}
+	return offset, nil
+}
+
+func (r *s3Reader) Size() int64 {
+	return r.size
+}
+
+func (r *s3Reader) Close() error {
+	r.closeBody()
+	return nil
+}
+
+func (r *s3Reader) closeBody() {
+	if r.body != nil {
+		r.body.Close()
+		r.body = nil
+	}
+}
+
+// exactReader fails uploads of content that is shorter or longer than declared.
+type exactReader struct {
+	r         io.Reader
+	remaining int64
+	mismatch  bool
+}
+
+func (r *exactReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		// Make sure there is nothing left beyond the declared size
+		var extra [1]byte
+		if n, _ := r.r.Read(extra[:]); n > 0 {
+			r.mismatch = true
+			return 0, ErrSizeMismatch
+		}
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+
+	n, err := r.r.Read(p)
+	r.remaining -= int64(n)
+	if errors.Is(err, io.EOF) && r.remaining > 0 {
+		r.mismatch = true
+		return n, ErrSizeMismatch
+	}
+	if errors.Is(err, io.EOF) {
+		err = nil
+	}
+	return n, err
+}
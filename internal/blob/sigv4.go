@@ -0,0 +1,145 @@
+package blob
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// unsignedPayload is used in place of the payload hash for uploads, which are streamed without buffering.
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+
+	// emptyPayload is the SHA-256 hash of an empty body.
+	emptyPayload = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// signer signs requests to S3 with AWS Signature Version 4.
+type signer struct {
+	accessKeyID     string
+	secretAccessKey string
+	region          string
+}
+
+// sign adds the authentication headers to the request for a payload with the given SHA-256 hash.
+func (s *signer) sign(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers, signedHeaders := canonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.EscapedPath(), false),
+		canonicalQuery(req),
+		headers,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hexSHA256(canonicalRequest),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretAccessKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.accessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// canonicalHeaders lists the signed headers with their values, along with their names separated by semicolons.
+func canonicalHeaders(req *http.Request) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	values := map[string]string{"host": host}
+	for name, vals := range req.Header {
+		name = strings.ToLower(name)
+		if name == "content-type" || name == "content-md5" || name == "range" || strings.HasPrefix(name, "x-amz-") {
+			for i, v := range vals {
+				vals[i] = strings.Join(strings.Fields(v), " ")
+			}
+			values[name] = strings.Join(vals, ",")
+		}
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + ":" + values[name] + "\n")
+	}
+	return b.String(), strings.Join(names, ";")
+}
+
+// canonicalQuery encodes the query parameters sorted by name.
+func canonicalQuery(req *http.Request) string {
+	query := req.URL.Query()
+
+	var params []string
+	for name, vals := range query {
+		for _, v := range vals {
+			params = append(params, uriEncode(name, true)+"="+uriEncode(v, true))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// uriEncode percent-encodes everything but the unreserved characters, and slashes unless encodeSlash is set.
+//
+// Already escaped sequences in the input are kept intact.
+func uriEncode(s string, encodeSlash bool) string {
+	const hexDigits = "0123456789ABCDEF"
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '.', c == '_', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		case c == '%' && !encodeSlash && i+2 < len(s):
+			b.WriteString(strings.ToUpper(s[i : i+3]))
+			i += 2
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&0x0F])
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hexSHA256(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,11 +2,16 @@ package config
 
 import (
 	"errors"
+	"fmt"
 	"strings"
+	"time"
 
+	"github.com/cerfical/muzik/internal/blob"
 	"github.com/cerfical/muzik/internal/httpserv"
+	"github.com/cerfical/muzik/internal/httpserv/api"
 	"github.com/cerfical/muzik/internal/log"
 	"github.com/cerfical/muzik/internal/postgres"
+	"github.com/cerfical/muzik/internal/sqlite"
 	"github.com/mitchellh/mapstructure"
 	"github.com/spf13/viper"
 )
@@ -48,20 +53,102 @@ func load(v *viper.Viper) (*Config, error) {
 
 	v.SetDefault("log.level", log.LevelInfo)
 	v.SetDefault("server.addr", "localhost:8080")
+	v.SetDefault("api.actorheader", "X-Forwarded-User")
+	v.SetDefault("api.maxaudiosize", 200<<20)
+	v.SetDefault("api.maxbodysize", 10<<20)
 
+	v.SetDefault("db.driver", DriverPostgres)
 	v.SetDefault("db.addr", "localhost:5432")
 	v.SetDefault("db.name", "postgres")
 	v.SetDefault("db.user", "postgres")
+	v.SetDefault("db.sqlite.path", "muzik.db")
+
+	v.SetDefault("blobs.driver", BlobsDriverLocal)
+	v.SetDefault("blobs.local.dir", "blobs")
+
+	v.SetDefault("trash.retention", 30*24*time.Hour)
+	v.SetDefault("trash.purgeinterval", time.Hour)
 
 	var cfg Config
 	if err := v.Unmarshal(&cfg, viper.DecodeHook(mapstructure.TextUnmarshallerHookFunc())); err != nil {
 		return nil, err
 	}
+
+	switch cfg.DB.Driver {
+	case DriverPostgres, DriverSQLite, DriverMemory:
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", cfg.DB.Driver)
+	}
+
+	switch cfg.Blobs.Driver {
+	case BlobsDriverLocal, BlobsDriverS3:
+	default:
+		return nil, fmt.Errorf("unknown blob storage driver %q", cfg.Blobs.Driver)
+	}
+
+	if cfg.Trash.Retention > 0 && cfg.Trash.PurgeInterval <= 0 {
+		return nil, errors.New("the trash purge interval must be positive")
+	}
 	return &cfg, nil
 }
 
+// Supported database drivers.
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+
+	// DriverMemory keeps all data in memory, losing it when the server stops.
+	DriverMemory = "memory"
+)
+
+// Supported blob storage drivers.
+const (
+	BlobsDriverLocal = "local"
+	BlobsDriverS3    = "s3"
+)
+
 type Config struct {
 	Server httpserv.Config
-	DB     postgres.Config
+	API    api.Config
+	DB     DBConfig
+	Blobs  BlobsConfig
+	Trash  TrashConfig
 	Log    log.Config
 }
+
+// DBConfig selects the database driver along with its settings.
+type DBConfig struct {
+	Driver string
+
+	postgres.Config `mapstructure:",squash"`
+
+	SQLite sqlite.Config
+}
+
+// BlobsConfig selects where uploaded audio files are stored.
+type BlobsConfig struct {
+	Driver string
+
+	Local blob.LocalConfig
+	S3    blob.S3Config
+}
+
+// OpenStore opens the blob storage selected by the config.
+func (c *BlobsConfig) OpenStore(log *log.Logger) (blob.Store, error) {
+	if c.Driver == BlobsDriverS3 {
+		log.WithFields("endpoint", c.S3.Endpoint, "bucket", c.S3.Bucket).Info("Opening the blob storage")
+		return blob.NewS3(&c.S3)
+	}
+
+	log.WithFields("dir", c.Local.Dir).Info("Opening the blob storage")
+	return blob.NewLocal(&c.Local)
+}
+
+// TrashConfig controls how long deleted tracks are kept in the trash.
+type TrashConfig struct {
+	// Retention is how long tracks stay in the trash before they are purged, with zero keeping them forever.
+	Retention time.Duration
+
+	// PurgeInterval is how often the trash is checked for tracks to purge.
+	PurgeInterval time.Duration
+}
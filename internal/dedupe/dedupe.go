@@ -0,0 +1,273 @@
+// Package dedupe finds tracks that are likely to be copies of the same recording, and merges them into one.
+package dedupe
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/cerfical/muzik/internal/search"
+)
+
+// DefaultSimilarity is the similarity of titles above which tracks are considered duplicates by default.
+const DefaultSimilarity = 0.85
+
+// qualifierWords are the words making up qualifiers that tell releases of the same recording apart, as in "Song (2011 Remaster)".
+var qualifierWords = map[string]bool{
+	"remaster": true, "remastered": true,
+	"version": true, "edit": true, "radio": true, "single": true, "album": true, "original": true,
+	"mono": true, "stereo": true, "explicit": true, "clean": true,
+	"bonus": true, "track": true, "deluxe": true, "edition": true, "expanded": true, "anniversary": true,
+	"digital": true, "digitally": true,
+}
+
+// featuringWords introduce qualifiers naming guest artists, as in "Song (feat. Someone)".
+var featuringWords = []string{"feat", "ft", "featuring", "with"}
+
+// Normalize reduces a title to its folded words without punctuation and qualifiers such as "(Remastered)" or " - Radio Edit",
+// so that titles of different releases of the same recording normalize the same.
+func Normalize(title string) string {
+	for {
+		head, qualifier, ok := cutQualifier(title)
+		if !ok || !isQualifier(qualifier) {
+			break
+		}
+		title = head
+	}
+	return strings.Join(search.Terms(title), " ")
+}
+
+// cutQualifier splits off the trailing part of a title that is in parentheses or brackets, or follows a dash.
+func cutQualifier(title string) (head, qualifier string, ok bool) {
+	title = strings.TrimRightFunc(title, unicode.IsSpace)
+
+	for _, p := range []struct{ open, close string }{{"(", ")"}, {"[", "]"}} {
+		if strings.HasSuffix(title, p.close) {
+			if i := strings.LastIndex(title, p.open); i > 0 {
+				return title[:i], title[i+1 : len(title)-1], true
+			}
+		}
+	}
+
+	if i := strings.LastIndex(title, " - "); i > 0 {
+		return title[:i], title[i+3:], true
+	}
+	return title, "", false
+}
+
+func isQualifier(text string) bool {
+	words := search.Terms(text)
+	if len(words) == 0 {
+		return false
+	}
+
+	if slices.Contains(featuringWords, words[0]) && len(words) > 1 {
+		return true
+	}
+
+	for _, w := range words {
+		if !qualifierWords[w] && !isNumber(w) {
+			return false
+		}
+	}
+	return true
+}
+
+func isNumber(word string) bool {
+	return strings.IndexFunc(word, func(r rune) bool { return !unicode.IsDigit(r) }) < 0
+}
+
+// Similarity compares two normalized titles by their edit distance, from 0 for completely different titles to 1 for equal ones.
+func Similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	n := max(len(ra), len(rb))
+	if n == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(n)
+}
+
+// levenshtein counts the single-rune insertions, deletions and substitutions needed to turn a into b.
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := range a {
+		curr[0] = i + 1
+		for j := range b {
+			cost := 1
+			if a[i] == b[j] {
+				cost = 0
+			}
+			curr[j+1] = min(prev[j+1]+1, curr[j]+1, prev[j]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+// readPageSize is the number of tracks read from a store at once by [ReadTracks].
+const readPageSize = 1000
+
+// ReadTracks reads all tracks from the store a page at a time, keeping only the titles and artists that [Find] compares them by.
+func ReadTracks(ctx context.Context, store model.TrackStore) ([]model.Track, error) {
+	var tracks []model.Track
+	query := model.TrackQuery{Limit: readPageSize}
+	for {
+		page, err := store.GetTracks(ctx, &query)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range page.Tracks {
+			tracks = append(tracks, model.Track{
+				ID:    t.ID,
+				Attrs: model.TrackAttrs{Title: t.Attrs.Title},
+				Rels:  model.TrackRels{ArtistIDs: t.Rels.ArtistIDs},
+			})
+		}
+
+		if len(page.Tracks) < query.Limit {
+			return tracks, nil
+		}
+		query.After = page.Tracks[len(page.Tracks)-1].ID
+	}
+}
+
+// Find groups tracks whose normalized titles have at least the specified similarity, ordered by the IDs of their first tracks.
+//
+// Tracks with no artists in common are never grouped, unless either of them has no artists at all.
+// Only tracks whose titles share a blocking key are compared, so titles differing both in their first and last few letters are never grouped.
+func Find(tracks []model.Track, minSimilarity float64) []model.DuplicateGroup {
+	tracks = slices.Clone(tracks)
+	slices.SortFunc(tracks, func(a, b model.Track) int { return a.ID - b.ID })
+
+	titles := make([]string, len(tracks))
+	for i := range tracks {
+		titles[i] = Normalize(tracks[i].Attrs.Title)
+	}
+
+	// Link similar tracks into groups by union-find, remembering the weakest link of each group
+	parent := make([]int, len(tracks))
+	weakest := make([]float64, len(tracks))
+	for i := range parent {
+		parent[i] = i
+		weakest[i] = 1
+	}
+
+	var root func(int) int
+	root = func(i int) int {
+		if parent[i] != i {
+			parent[i] = root(parent[i])
+		}
+		return parent[i]
+	}
+
+	// Split tracks into blocks by the keys of their titles, so that only tracks sharing a block are compared
+	blocks := make(map[string][]int)
+	for i, t := range titles {
+		if t == "" {
+			continue
+		}
+		for _, k := range blockKeys(t) {
+			blocks[k] = append(blocks[k], i)
+		}
+	}
+
+	seen := make([]int, len(tracks))
+	for i := range seen {
+		seen[i] = -1
+	}
+
+	for i := range tracks {
+		if titles[i] == "" {
+			continue
+		}
+
+		var candidates []int
+		for _, k := range blockKeys(titles[i]) {
+			for _, j := range blocks[k] {
+				if j > i && seen[j] != i {
+					seen[j] = i
+					candidates = append(candidates, j)
+				}
+			}
+		}
+
+		for _, j := range candidates {
+			if !shareArtists(&tracks[i], &tracks[j]) || !closeLengths(titles[i], titles[j], minSimilarity) {
+				continue
+			}
+
+			sim := Similarity(titles[i], titles[j])
+			if sim < minSimilarity {
+				continue
+			}
+
+			// Groups are rooted at their lowest index, which is the track with the lowest ID
+			ri, rj := root(i), root(j)
+			if ri != rj {
+				ri, rj = min(ri, rj), max(ri, rj)
+				parent[rj] = ri
+				weakest[ri] = min(weakest[ri], weakest[rj], sim)
+			}
+		}
+	}
+
+	groups := []model.DuplicateGroup{}
+	index := make(map[int]int)
+	for i := range tracks {
+		r := root(i)
+		if r == i {
+			continue
+		}
+
+		g, ok := index[r]
+		if !ok {
+			g = len(groups)
+			index[r] = g
+			groups = append(groups, model.DuplicateGroup{
+				ID:    tracks[r].ID,
+				Attrs: model.DuplicateAttrs{Title: titles[r], Similarity: weakest[r]},
+				Rels:  model.DuplicateRels{TrackIDs: []int{tracks[r].ID}},
+			})
+		}
+		groups[g].Rels.TrackIDs = append(groups[g].Rels.TrackIDs, tracks[i].ID)
+	}
+	return groups
+}
+
+// blockKeyLen is the number of letters at either end of a title that make up its blocking keys.
+const blockKeyLen = 4
+
+// blockKeys returns the keys of the blocks a normalized title belongs to: its first and last few letters.
+// Similar titles almost always agree at one of their ends, and a typo rarely touches both.
+func blockKeys(title string) []string {
+	r := []rune(title)
+	n := min(len(r), blockKeyLen)
+	return []string{"^" + string(r[:n]), string(r[len(r)-n:]) + "$"}
+}
+
+func shareArtists(a, b *model.Track) bool {
+	if len(a.Rels.ArtistIDs) == 0 || len(b.Rels.ArtistIDs) == 0 {
+		return true
+	}
+
+	for _, id := range a.Rels.ArtistIDs {
+		if slices.Contains(b.Rels.ArtistIDs, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// closeLengths checks that the lengths of titles differ little enough for them to possibly have the specified similarity.
+func closeLengths(a, b string, minSimilarity float64) bool {
+	la, lb := len([]rune(a)), len([]rune(b))
+	return float64(min(la, lb)) >= minSimilarity*float64(max(la, lb))
+}
@@ -0,0 +1,230 @@
+package dedupe_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cerfical/muzik/internal/dedupe"
+	"github.com/cerfical/muzik/internal/memstore"
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestDedupe(t *testing.T) {
+	suite.Run(t, new(DedupeTest))
+}
+
+type DedupeTest struct {
+	suite.Suite
+}
+
+func (t *DedupeTest) TestNormalize() {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Song (Remastered)", "song"},
+		{"song - remaster", "song"},
+		{"Song - 2011 Remaster [Radio Edit]", "song"},
+		{"Café del Mar (feat. Someone)", "cafe del mar"},
+		{"Don't Stop (Club Mix)", "don t stop club mix"},
+		{"Intro - Live at Wembley", "intro live at wembley"},
+		{"(Remastered)", "remastered"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.title, func() {
+			t.Equal(test.want, dedupe.Normalize(test.title))
+		})
+	}
+}
+
+func (t *DedupeTest) TestSimilarity() {
+	t.Equal(1.0, dedupe.Similarity("song", "song"))
+	t.Equal(0.75, dedupe.Similarity("song", "sang"))
+	t.Equal(0.0, dedupe.Similarity("abc", "xyz"))
+	t.Equal(1.0, dedupe.Similarity("", ""))
+}
+
+func (t *DedupeTest) TestFind() {
+	tracks := []model.Track{
+		{ID: 5, Attrs: model.TrackAttrs{Title: "song - remaster"}},
+		{ID: 1, Attrs: model.TrackAttrs{Title: "Song (Remastered)"}},
+		{ID: 2, Attrs: model.TrackAttrs{Title: "Another Song"}},
+		{ID: 3, Attrs: model.TrackAttrs{Title: "Intro"}, Rels: model.TrackRels{ArtistIDs: []int{1}}},
+		{ID: 4, Attrs: model.TrackAttrs{Title: "Intro"}, Rels: model.TrackRels{ArtistIDs: []int{2}}},
+		{ID: 6, Attrs: model.TrackAttrs{Title: "Anothr Song"}},
+		{ID: 7, Attrs: model.TrackAttrs{Title: "Intro (Mono)"}, Rels: model.TrackRels{ArtistIDs: []int{2, 3}}},
+	}
+
+	groups := dedupe.Find(tracks, dedupe.DefaultSimilarity)
+	t.Require().Len(groups, 3)
+
+	t.Equal(1, groups[0].ID)
+	t.Equal("song", groups[0].Attrs.Title)
+	t.Equal(1.0, groups[0].Attrs.Similarity)
+	t.Equal([]int{1, 5}, groups[0].Rels.TrackIDs)
+
+	t.Equal([]int{2, 6}, groups[1].Rels.TrackIDs)
+	t.InDelta(11.0/12, groups[1].Attrs.Similarity, 1e-9)
+
+	// Tracks of different artists are kept apart
+	t.Equal([]int{4, 7}, groups[2].Rels.TrackIDs)
+
+	t.Empty(dedupe.Find(tracks, 1.01))
+}
+
+func (t *DedupeTest) TestFind_Blocking() {
+	tracks := []model.Track{
+		{ID: 1, Attrs: model.TrackAttrs{Title: "Paranoid Android"}},
+		{ID: 2, Attrs: model.TrackAttrs{Title: "Paranoid Androids"}},
+		{ID: 3, Attrs: model.TrackAttrs{Title: "Xaranoid Android"}},
+		{ID: 4, Attrs: model.TrackAttrs{Title: "Karma Police"}},
+		{ID: 5, Attrs: model.TrackAttrs{Title: "Barma Policf"}},
+	}
+
+	// Titles agreeing at either end are compared, titles differing at both ends are not
+	groups := dedupe.Find(tracks, 0.8)
+	t.Require().Len(groups, 1)
+	t.Equal([]int{1, 2, 3}, groups[0].Rels.TrackIDs)
+}
+
+func (t *DedupeTest) TestReadTracks() {
+	ctx := context.Background()
+	store := memstore.New()
+
+	// More tracks than fit in a page
+	tracks := make([]model.Track, 1500)
+	for i := range tracks {
+		tracks[i] = model.Track{Attrs: model.TrackAttrs{Title: "Song", Comment: "C"}}
+	}
+	tracks[1200].Attrs.Title = "Song (Remastered)"
+	_, err := store.CreateTracks(ctx, tracks)
+	t.Require().NoError(err)
+
+	read, err := dedupe.ReadTracks(ctx, store)
+	t.Require().NoError(err)
+	t.Require().Len(read, len(tracks))
+	t.Equal(1, read[0].ID)
+	t.Equal(len(tracks), read[len(read)-1].ID)
+
+	// Only what tracks are compared by is kept
+	t.Equal("Song (Remastered)", read[1200].Attrs.Title)
+	t.Empty(read[1200].Attrs.Comment)
+}
+
+func (t *DedupeTest) TestMerge() {
+	ctx := context.Background()
+	store := memstore.New()
+
+	artist, err := store.CreateArtist(ctx, &model.ArtistAttrs{Name: "A"})
+	t.Require().NoError(err)
+	album, err := store.CreateAlbum(ctx, &model.AlbumAttrs{Title: "B"})
+	t.Require().NoError(err)
+
+	year := 2011
+	tracks, err := store.CreateTracks(ctx, []model.Track{
+		{Attrs: model.TrackAttrs{Title: "Song", Genres: []string{"rock"}}},
+		{Attrs: model.TrackAttrs{Title: "Song (Remastered)", Year: &year, Genres: []string{"rock", "pop"}}, Rels: model.TrackRels{AlbumID: album.ID}},
+		{Attrs: model.TrackAttrs{Title: "song - remaster"}, Rels: model.TrackRels{ArtistIDs: []int{artist.ID}}},
+		{Attrs: model.TrackAttrs{Title: "Other"}},
+	})
+	t.Require().NoError(err)
+
+	audio := model.Audio{ContentType: "audio/flac", Size: 10, SHA256: "abc"}
+	_, err = store.SetTrackAudio(ctx, tracks[2].ID, &audio)
+	t.Require().NoError(err)
+
+	playlist, err := store.CreatePlaylist(ctx, &model.Playlist{
+		Rels: model.PlaylistRels{TrackIDs: []int{tracks[3].ID, tracks[1].ID, tracks[0].ID, tracks[2].ID}},
+	})
+	t.Require().NoError(err)
+
+	var merged *model.Track
+	err = store.RunInTx(ctx, func(tx model.TxStores) (err error) {
+		merged, err = dedupe.Merge(ctx, tx, tracks[0].ID, []int{tracks[1].ID, tracks[2].ID})
+		return err
+	})
+	t.Require().NoError(err)
+
+	t.Equal("Song", merged.Attrs.Title)
+	t.Equal(&year, merged.Attrs.Year)
+	t.Equal([]string{"rock", "pop"}, merged.Attrs.Genres)
+	t.Equal(album.ID, merged.Rels.AlbumID)
+	t.Equal([]int{artist.ID}, merged.Rels.ArtistIDs)
+	t.Equal(&model.TrackMeta{Audio: &audio}, merged.Meta)
+	t.Equal(3, merged.Revision)
+
+	playlist, err = store.GetPlaylist(ctx, playlist.ID)
+	t.Require().NoError(err)
+	t.Equal([]int{tracks[3].ID, tracks[0].ID, tracks[0].ID, tracks[0].ID}, playlist.Rels.TrackIDs)
+
+	trash, err := store.GetTracks(ctx, &model.TrackQuery{Trashed: true})
+	t.Require().NoError(err)
+	t.Equal(2, trash.Total)
+}
+
+func (t *DedupeTest) TestMerge_TrashedInPlaylist() {
+	ctx := context.Background()
+	store := memstore.New()
+
+	tracks, err := store.CreateTracks(ctx, []model.Track{
+		{Attrs: model.TrackAttrs{Title: "Song"}},
+		{Attrs: model.TrackAttrs{Title: "Song (Remastered)"}},
+		{Attrs: model.TrackAttrs{Title: "Other"}},
+	})
+	t.Require().NoError(err)
+
+	playlist, err := store.CreatePlaylist(ctx, &model.Playlist{
+		Rels: model.PlaylistRels{TrackIDs: []int{tracks[2].ID, tracks[1].ID, tracks[2].ID}},
+	})
+	t.Require().NoError(err)
+	t.Require().NoError(store.DeleteTrack(ctx, tracks[2].ID))
+
+	err = store.RunInTx(ctx, func(tx model.TxStores) error {
+		_, err := dedupe.Merge(ctx, tx, tracks[0].ID, []int{tracks[1].ID})
+		return err
+	})
+	t.Require().NoError(err)
+
+	// The trashed track is back in its places once restored
+	_, err = store.RestoreTrack(ctx, tracks[2].ID)
+	t.Require().NoError(err)
+
+	playlist, err = store.GetPlaylist(ctx, playlist.ID)
+	t.Require().NoError(err)
+	t.Equal([]int{tracks[2].ID, tracks[0].ID, tracks[2].ID}, playlist.Rels.TrackIDs)
+}
+
+func (t *DedupeTest) TestMerge_Errors() {
+	ctx := context.Background()
+	store := memstore.New()
+
+	tracks, err := store.CreateTracks(ctx, []model.Track{
+		{Attrs: model.TrackAttrs{Title: "Song"}},
+		{Attrs: model.TrackAttrs{Title: "Song (Remastered)"}},
+	})
+	t.Require().NoError(err)
+
+	merge := func(keepID int, dropIDs ...int) error {
+		return store.RunInTx(ctx, func(tx model.TxStores) error {
+			_, err := dedupe.Merge(ctx, tx, keepID, dropIDs)
+			return err
+		})
+	}
+
+	t.ErrorIs(merge(tracks[0].ID), dedupe.ErrInvalidMerge)
+	t.ErrorIs(merge(tracks[0].ID, tracks[0].ID), dedupe.ErrInvalidMerge)
+	t.ErrorIs(merge(tracks[0].ID, tracks[1].ID, tracks[1].ID), dedupe.ErrInvalidMerge)
+	t.ErrorIs(merge(100, tracks[1].ID), model.ErrNotFound)
+
+	err = merge(tracks[0].ID, 100, tracks[1].ID, 101)
+	var itemErrs model.ItemErrors
+	t.Require().ErrorAs(err, &itemErrs)
+	t.Equal(0, itemErrs[0].Index)
+	t.Equal(2, itemErrs[1].Index)
+
+	// Nothing is changed by failed merges
+	_, err = store.GetTrack(ctx, tracks[1].ID)
+	t.NoError(err)
+}
@@ -0,0 +1,155 @@
+package dedupe
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/cerfical/muzik/internal/model"
+)
+
+// ErrInvalidMerge is returned when the tracks to merge are not distinct.
+var ErrInvalidMerge = errors.New("tracks to merge must be distinct from each other and from the track they are merged into")
+
+// Merge merges duplicates into the track with the keepID and moves them to the trash, so it should be run in a transaction.
+//
+// The kept track gains the album, artists, genres, audio and any other attributes it lacks from the duplicates,
+// and playlists refer to it instead of the duplicates in the same positions.
+// If the kept track does not exist, [model.ErrNotFound] is returned, and if some of the duplicates do not exist, [model.ItemErrors] wrapping it.
+func Merge(ctx context.Context, tx model.TxStores, keepID int, dropIDs []int) (*model.Track, error) {
+	if len(dropIDs) == 0 || slices.Contains(dropIDs, keepID) || len(dropIDs) != len(distinct(dropIDs)) {
+		return nil, ErrInvalidMerge
+	}
+
+	keep, err := tx.GetTrack(ctx, keepID)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := false
+	var audio *model.Audio
+	var itemErrs model.ItemErrors
+	for i, id := range dropIDs {
+		drop, err := tx.GetTrack(ctx, id)
+		if err != nil {
+			if !errors.Is(err, model.ErrNotFound) {
+				return nil, err
+			}
+			itemErrs = append(itemErrs, model.ItemError{Index: i, Err: err})
+			continue
+		}
+
+		if mergeInto(keep, drop) {
+			changed = true
+		}
+
+		if audio == nil && drop.Meta != nil {
+			audio = drop.Meta.Audio
+		}
+	}
+
+	if itemErrs != nil {
+		return nil, itemErrs
+	}
+
+	if err := repointPlaylists(ctx, tx, keepID, dropIDs); err != nil {
+		return nil, err
+	}
+
+	if changed {
+		if keep, err = tx.UpdateTrack(ctx, keep); err != nil {
+			return nil, err
+		}
+	}
+
+	if audio != nil && (keep.Meta == nil || keep.Meta.Audio == nil) {
+		if keep, err = tx.SetTrackAudio(ctx, keepID, audio); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.DeleteTracks(ctx, dropIDs); err != nil {
+		return nil, err
+	}
+	return keep, nil
+}
+
+func distinct(ids []int) []int {
+	ids = slices.Clone(ids)
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
+
+// mergeInto fills in the missing attributes and relationships of the kept track from a duplicate, reporting whether there were any.
+func mergeInto(keep, drop *model.Track) bool {
+	changed := false
+	fill := func(dst **int, src *int) {
+		if *dst == nil && src != nil {
+			*dst, changed = src, true
+		}
+	}
+
+	a, b := &keep.Attrs, &drop.Attrs
+	fill(&a.Duration, b.Duration)
+	fill(&a.TrackNumber, b.TrackNumber)
+	fill(&a.DiscNumber, b.DiscNumber)
+	fill(&a.Year, b.Year)
+
+	if a.BPM == nil && b.BPM != nil {
+		a.BPM, changed = b.BPM, true
+	}
+
+	if a.ISRC == "" && b.ISRC != "" {
+		a.ISRC, changed = b.ISRC, true
+	}
+
+	if a.Comment == "" && b.Comment != "" {
+		a.Comment, changed = b.Comment, true
+	}
+
+	if !a.Explicit && b.Explicit {
+		a.Explicit, changed = true, true
+	}
+
+	for _, g := range b.Genres {
+		if !slices.Contains(a.Genres, g) {
+			a.Genres, changed = append(a.Genres, g), true
+		}
+	}
+
+	if keep.Rels.AlbumID == 0 && drop.Rels.AlbumID != 0 {
+		keep.Rels.AlbumID, changed = drop.Rels.AlbumID, true
+	}
+
+	for _, id := range drop.Rels.ArtistIDs {
+		if !slices.Contains(keep.Rels.ArtistIDs, id) {
+			keep.Rels.ArtistIDs, changed = append(keep.Rels.ArtistIDs, id), true
+		}
+	}
+	return changed
+}
+
+// repointPlaylists replaces the duplicates in all playlists with the kept track.
+func repointPlaylists(ctx context.Context, tx model.PlaylistStore, keepID int, dropIDs []int) error {
+	playlists, err := tx.GetPlaylists(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range playlists {
+		ids := slices.Clone(p.Rels.TrackIDs)
+		changed := false
+		for i, id := range ids {
+			if slices.Contains(dropIDs, id) {
+				ids[i], changed = keepID, true
+			}
+		}
+
+		if changed {
+			if _, err := tx.SetPlaylistTracks(ctx, p.ID, ids); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
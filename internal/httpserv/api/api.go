@@ -3,16 +3,32 @@ package api
 import (
 	"net/http"
 
+	"github.com/cerfical/muzik/internal/blob"
 	"github.com/cerfical/muzik/internal/httpserv"
 	"github.com/cerfical/muzik/internal/log"
 	"github.com/cerfical/muzik/internal/model"
 )
 
-func NewServer(config *httpserv.Config, store model.TrackStore, log *log.Logger) *httpserv.Server {
-	h := NewHandler(store, log)
-	return httpserv.New(config, h, log)
+type Config struct {
+	// RequireIfMatch rejects writes to tracks that are not conditional on an If-Match header, to prevent lost updates.
+	RequireIfMatch bool
+
+	// ActorHeader names the request header identifying who makes changes, as set by an authenticating proxy in front of the server.
+	ActorHeader string
+
+	// MaxAudioSize limits the size of uploaded audio files in bytes, with zero meaning no limit.
+	MaxAudioSize int64
+
+	// MaxBodySize limits the size of track documents buffered in memory in bytes, with zero meaning no limit.
+	MaxBodySize int64
+}
+
+func NewServer(serverConfig *httpserv.Config, config *Config, store model.Store, blobs blob.Store, log *log.Logger) *httpserv.Server {
+	h := NewHandler(config, store, blobs, log)
+	return httpserv.New(serverConfig, h, log)
 }
 
-func NewHandler(store model.TrackStore, log *log.Logger) http.Handler {
-	return setupRoutes(store, log)
+// NewHandler creates the API handler, with blobs holding the uploaded audio files of tracks.
+func NewHandler(config *Config, store model.Store, blobs blob.Store, log *log.Logger) http.Handler {
+	return setupRoutes(config, store, blobs, log)
 }
@@ -0,0 +1,327 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/cerfical/muzik/internal/audiofmt"
+	"github.com/cerfical/muzik/internal/blob"
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/cerfical/muzik/internal/tags"
+)
+
+// genericMediaType is accepted for uploads of audio files whose format is only known from their content.
+const genericMediaType = "application/octet-stream"
+
+// audioMediaTypes lists media types accepted in audio upload requests.
+var audioMediaTypes = func() []string {
+	var mediaTypes []string
+	for _, f := range audiofmt.Formats {
+		mediaTypes = append(mediaTypes, f.MediaType)
+		mediaTypes = append(mediaTypes, f.Aliases...)
+	}
+	return append(mediaTypes, genericMediaType)
+}()
+
+// uploadAudio stores the request body as the audio file of a track,
+// filling in the attributes the track lacks from the tags embedded in the file.
+//
+// Blobs are keyed by the checksum of their content, so identical files are stored once.
+// The stored file is released again if the track cannot be saved, and so is the file it replaces, see [ReleaseAudio].
+func (h *tracksHandler) uploadAudio(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		notFound(w, r)
+		return
+	}
+
+	mode, err := parseTagsMode(r.URL.Query())
+	if err != nil {
+		reportQueryError(w, r, err, h.log)
+		return
+	}
+
+	// Check the track before reading a possibly large body, the revision is checked again once the body is stored
+	track, err := h.currentTrack(r, h.store, id)
+	if err != nil {
+		h.respondSaved(w, r, nil, err)
+		return
+	}
+
+	upload, ok := h.spoolAudio(w, r)
+	if !ok {
+		return
+	}
+	defer upload.close()
+
+	var tagged *model.TrackAttrs
+	if mode != tagsIgnore {
+		t, err := tags.Read(upload.file, upload.audio.Size, upload.format)
+		if err != nil {
+			internalError("Failed to read the tags of an uploaded audio file", err, h.log)(w, r)
+			return
+		}
+
+		attrs := t.Attrs()
+		tagged = &attrs
+
+		// Reject conflicting files before storing them, the attributes are compared again in the transaction
+		current := track.Attrs
+		if _, conflicts := tags.Apply(&current, tagged); mode == tagsReject && conflicts != nil {
+			reportErrors(tagConflicts(conflicts))(w, r)
+			return
+		}
+	}
+
+	if err := h.blobs.Put(r.Context(), audioKey(&upload.audio), upload.file, upload.audio.Size, upload.audio.ContentType); err != nil {
+		internalError("Failed to store an audio file", err, h.log)(w, r)
+		return
+	}
+
+	var saved *model.Track
+	var replaced *model.Audio
+	var conflicts []tags.Conflict
+	err = h.store.RunInTx(r.Context(), func(tx model.TxStores) error {
+		if err := h.checkRevision(r, tx, id); err != nil {
+			return err
+		}
+
+		current, err := tx.GetTrack(r.Context(), id)
+		if err != nil {
+			return err
+		}
+
+		replaced = nil
+		if current.Meta != nil {
+			replaced = current.Meta.Audio
+		}
+
+		if tagged != nil {
+			var changed bool
+			changed, conflicts = tags.Apply(&current.Attrs, tagged)
+			if mode == tagsReject && conflicts != nil {
+				return tagConflicts(conflicts)
+			}
+
+			if changed {
+				if _, err := tx.UpdateTrack(r.Context(), current); err != nil {
+					return err
+				}
+			}
+		}
+
+		saved, err = tx.SetTrackAudio(r.Context(), id, &upload.audio)
+		return err
+	})
+
+	// Release the stored file if no track has it, and the replaced file once the track no longer has it
+	ctx := context.WithoutCancel(r.Context())
+	if err != nil {
+		h.releaseAudio(ctx, &upload.audio)
+	} else if replaced != nil && replaced.SHA256 != upload.audio.SHA256 {
+		h.releaseAudio(ctx, replaced)
+	}
+
+	if err != nil || conflicts == nil {
+		h.respondSaved(w, r, saved, err)
+		return
+	}
+
+	w.Header().Set("ETag", trackETag(r, saved))
+	encode(w, r, http.StatusOK, &document{
+		Meta: &uploadMeta{TagConflicts: conflicts},
+		Data: saved,
+	})
+}
+
+// uploadMeta lists the attributes of a track that differ from the tags of the uploaded audio file.
+type uploadMeta struct {
+	TagConflicts []tags.Conflict `json:"tagConflicts"`
+}
+
+// stream serves the audio file of a track, with support for range requests for players to be able to seek.
+func (h *tracksHandler) stream(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		notFound(w, r)
+		return
+	}
+
+	track, err := h.store.GetTrack(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			notFound(w, r)
+		} else {
+			internalError("Failed to read track data from persistent storage", err, h.log)(w, r)
+		}
+		return
+	}
+
+	if track.Meta == nil || track.Meta.Audio == nil {
+		noAudio(w, r)
+		return
+	}
+
+	audio := track.Meta.Audio
+	content, err := h.blobs.Open(r.Context(), audioKey(audio))
+	if err != nil {
+		internalError("Failed to open an audio file", err, h.log)(w, r)
+		return
+	}
+	defer content.Close()
+
+	// The checksum identifies the content, so the entity tag is valid for the range requests made with If-Range
+	w.Header().Set("Content-Type", audio.ContentType)
+	w.Header().Set("ETag", `"`+audio.SHA256+`"`)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, "", time.Time{}, content)
+}
+
+// ReleaseAudio deletes the blob holding the audio file unless some track, including ones in the trash, still has it.
+//
+// An upload of the same file made at the same time may still need the blob, in which case the upload has to be repeated.
+func ReleaseAudio(ctx context.Context, store model.TrackStore, blobs blob.Store, audio *model.Audio) error {
+	inUse, err := store.AudioInUse(ctx, audio.SHA256)
+	if err != nil || inUse {
+		return err
+	}
+	return blobs.Delete(ctx, audioKey(audio))
+}
+
+// releaseAudio is like [ReleaseAudio], but only logs failures, as the file is left in the blob store then.
+func (h *tracksHandler) releaseAudio(ctx context.Context, audio *model.Audio) {
+	if err := ReleaseAudio(ctx, h.store, h.blobs, audio); err != nil {
+		h.log.WithFields("sha256", audio.SHA256).Error("Failed to release an audio file", err)
+	}
+}
+
+// audioKey names the blob holding the audio file.
+func audioKey(audio *model.Audio) string {
+	return "audio/" + audio.SHA256
+}
+
+// audioUpload is an uploaded audio file spooled to a temporary file.
+type audioUpload struct {
+	file   *os.File
+	format *audiofmt.Format
+	audio  model.Audio
+}
+
+func (u *audioUpload) close() {
+	u.file.Close()
+	os.Remove(u.file.Name())
+}
+
+// spoolAudio copies the request body to a temporary file, checking its size and format, and reporting any problems to the client.
+func (h *tracksHandler) spoolAudio(w http.ResponseWriter, r *http.Request) (*audioUpload, bool) {
+	var declared *audiofmt.Format
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err == nil && mediaType != genericMediaType {
+		declared, _ = audiofmt.ByMediaType(mediaType)
+	}
+
+	body := r.Body
+	if h.maxAudioSize > 0 {
+		body = http.MaxBytesReader(w, body, h.maxAudioSize)
+	}
+
+	file, err := os.CreateTemp("", "muzik-upload-*")
+	if err != nil {
+		internalError("Failed to create a temporary file for an upload", err, h.log)(w, r)
+		return nil, false
+	}
+	upload := &audioUpload{file: file}
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(file, hash), body)
+	if err != nil {
+		upload.close()
+		if maxBytesErr := (*http.MaxBytesError)(nil); errors.As(err, &maxBytesErr) {
+			contentTooLarge(maxBytesErr.Limit)(w, r)
+		} else {
+			badRequest(fmt.Sprintf("The request body could not be read: %v", err), "")(w, r)
+		}
+		return nil, false
+	}
+
+	if size == 0 {
+		upload.close()
+		badRequest("The request body must contain an audio file", "")(w, r)
+		return nil, false
+	}
+
+	format, err := audiofmt.Sniff(file)
+	if err != nil {
+		upload.close()
+		if errors.Is(err, audiofmt.ErrUnknownFormat) {
+			unsupportedAudio("The content is not in any of the supported audio formats")(w, r)
+		} else {
+			internalError("Failed to read an uploaded audio file", err, h.log)(w, r)
+		}
+		return nil, false
+	}
+
+	if declared != nil && declared != format {
+		upload.close()
+		unsupportedAudio(fmt.Sprintf("The content is in the %s format, but was declared as %s", format.Name, declared.Name))(w, r)
+		return nil, false
+	}
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		upload.close()
+		internalError("Failed to read an uploaded audio file", err, h.log)(w, r)
+		return nil, false
+	}
+
+	upload.format = format
+	upload.audio = model.Audio{
+		ContentType: format.MediaType,
+		Size:        size,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+	}
+	return upload, true
+}
+
+// tagConflicts describes the attributes of a track that differ from the tags of the uploaded audio file.
+func tagConflicts(conflicts []tags.Conflict) requestErrors {
+	errs := make([]errorInfo, len(conflicts))
+	for i, c := range conflicts {
+		value, _ := json.Marshal(c.Value)
+		errs[i] = errorInfo{
+			Title:  "Resource conflict",
+			Detail: fmt.Sprintf("The tags of the audio file have the %s %s, which differs from the track", c.Field, value),
+			Status: http.StatusConflict,
+		}
+	}
+	return errs
+}
+
+// noAudio reports that the requested track has no audio file uploaded.
+func noAudio(w http.ResponseWriter, r *http.Request) {
+	encode(w, r, http.StatusNotFound, errorResponse{
+		Errors: []errorInfo{{
+			Title:  "Audio not found",
+			Detail: "The track has no audio file uploaded",
+			Status: http.StatusNotFound,
+		}},
+	})
+}
+
+// unsupportedAudio reports that the uploaded content is not audio of a supported format.
+func unsupportedAudio(detail string) http.HandlerFunc {
+	return reportErrors([]errorInfo{{
+		Title:  "Media type is unsupported",
+		Detail: detail,
+		Status: http.StatusUnsupportedMediaType,
+	}})
+}
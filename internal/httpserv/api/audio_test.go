@@ -0,0 +1,494 @@
+package api_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/cerfical/muzik/internal/blob"
+	"github.com/cerfical/muzik/internal/httpserv/api"
+	"github.com/cerfical/muzik/internal/memstore"
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/gavv/httpexpect/v2"
+	"github.com/stretchr/testify/suite"
+)
+
+// flacContent is the start of a FLAC file, enough to be recognized by its signature.
+const flacContent = "fLaC\x00\x00\x00\x22\x10\x00\x10\x00"
+
+func TestAudio(t *testing.T) {
+	suite.Run(t, new(AudioTest))
+}
+
+type AudioTest struct {
+	suite.Suite
+
+	store  model.Store
+	blobs  blob.Store
+	expect *httpexpect.Expect
+}
+
+func (t *AudioTest) SetupTest() {
+	t.store = memstore.New()
+
+	_, err := t.store.CreateTrack(context.Background(), &model.Track{Attrs: model.TrackAttrs{Title: "Song"}})
+	t.Require().NoError(err)
+
+	t.blobs, err = blob.NewLocal(&blob.LocalConfig{Dir: t.T().TempDir()})
+	t.Require().NoError(err)
+
+	t.serve(&api.Config{})
+}
+
+func (t *AudioTest) serve(config *api.Config) {
+	t.expect = httpexpect.WithConfig(httpexpect.Config{
+		TestName: t.T().Name(),
+		BaseURL:  "/api/tracks",
+		Reporter: httpexpect.NewAssertReporter(t.T()),
+		Client: &http.Client{
+			Transport: httpexpect.NewBinder(api.NewHandler(config, t.store, t.blobs, nil)),
+		},
+	})
+}
+
+func (t *AudioTest) TestUpload_Ok() {
+	sum := sha256.Sum256([]byte(flacContent))
+	checksum := hex.EncodeToString(sum[:])
+
+	e := t.expect.PUT("/1/audio").
+		WithHeader("Content-Type", "audio/x-flac").
+		WithText(flacContent).
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.Header("ETag").IsEqual(`"2"`)
+
+	data := e.JSON(jsonAPIContent).Schema(schema("AudioUploadDataResponse")).Object().Value("data").Object()
+	data.Value("meta").Object().Value("audio").IsEqual(map[string]any{
+		"contentType": "audio/flac",
+		"size":        len(flacContent),
+		"sha256":      checksum,
+	})
+
+	r, err := t.blobs.Open(context.Background(), "audio/"+checksum)
+	t.Require().NoError(err)
+	defer r.Close()
+
+	content, err := io.ReadAll(r)
+	t.Require().NoError(err)
+	t.Equal(flacContent, string(content))
+
+	t.expect.GET("/1").
+		Expect().
+		Status(http.StatusOK).
+		JSON(jsonAPIContent).Object().Value("data").Object().
+		Value("meta").Object().Value("audio").Object().Value("sha256").IsEqual(checksum)
+}
+
+func (t *AudioTest) TestUpload_Sniffed() {
+	e := t.expect.PUT("/1/audio").
+		WithHeader("Content-Type", "application/octet-stream").
+		WithBytes([]byte{0xFF, 0xFB, 0x90, 0x64, 0x00, 0x00}).
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.JSON(jsonAPIContent).Schema(schema("AudioUploadDataResponse")).Object().
+		Value("data").Object().Value("meta").Object().Value("audio").Object().
+		Value("contentType").IsEqual("audio/mpeg")
+}
+
+func (t *AudioTest) TestUpload_UnsupportedMediaType() {
+	tests := []struct {
+		name        string
+		contentType string
+		content     string
+	}{
+		{"unsupported_type", "text/plain", flacContent},
+		{"unknown_content", "application/octet-stream", "not audio at all"},
+		{"type_mismatch", "audio/mpeg", flacContent},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func() {
+			e := t.expect.PUT("/1/audio").
+				WithHeader("Content-Type", test.contentType).
+				WithText(test.content).
+				Expect()
+
+			e.Status(http.StatusUnsupportedMediaType)
+			e.JSON(jsonAPIContent).Schema(errorResponse())
+		})
+	}
+
+	t.expectNoAudio()
+}
+
+func (t *AudioTest) TestUpload_TooLarge() {
+	t.serve(&api.Config{MaxAudioSize: 8})
+
+	e := t.expect.PUT("/1/audio").
+		WithHeader("Content-Type", "audio/flac").
+		WithText(flacContent).
+		Expect()
+
+	e.Status(http.StatusRequestEntityTooLarge)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+
+	t.expectNoAudio()
+}
+
+func (t *AudioTest) TestUpload_Empty() {
+	e := t.expect.PUT("/1/audio").
+		WithHeader("Content-Type", "audio/flac").
+		Expect()
+
+	e.Status(http.StatusBadRequest)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
+
+func (t *AudioTest) TestUpload_NotFound() {
+	e := t.expect.PUT("/2/audio").
+		WithHeader("Content-Type", "audio/flac").
+		WithText(flacContent).
+		Expect()
+
+	e.Status(http.StatusNotFound)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
+
+func (t *AudioTest) TestUpload_Preconditions() {
+	t.Run("failed", func() {
+		e := t.expect.PUT("/1/audio").
+			WithHeader("Content-Type", "audio/flac").
+			WithHeader("If-Match", `"5"`).
+			WithText(flacContent).
+			Expect()
+
+		e.Status(http.StatusPreconditionFailed)
+		e.JSON(jsonAPIContent).Schema(errorResponse())
+	})
+
+	t.Run("required", func() {
+		t.serve(&api.Config{RequireIfMatch: true})
+
+		e := t.expect.PUT("/1/audio").
+			WithHeader("Content-Type", "audio/flac").
+			WithText(flacContent).
+			Expect()
+
+		e.Status(http.StatusPreconditionRequired)
+		e.JSON(jsonAPIContent).Schema(errorResponse())
+	})
+
+	t.Run("matched", func() {
+		t.serve(&api.Config{RequireIfMatch: true})
+
+		t.expect.PUT("/1/audio").
+			WithHeader("Content-Type", "audio/flac").
+			WithHeader("If-Match", `"1"`).
+			WithText(flacContent).
+			Expect().
+			Status(http.StatusOK)
+	})
+}
+
+func (t *AudioTest) TestUpload_ReleasesReplaced() {
+	flacKey := "audio/" + strings.Trim(t.streamETag(), `"`)
+
+	// The file is kept while another track has it
+	_, err := t.store.CreateTrack(context.Background(), &model.Track{Attrs: model.TrackAttrs{Title: "Other"}})
+	t.Require().NoError(err)
+	for _, id := range []string{"/1/audio", "/2/audio"} {
+		t.expect.PUT(id).
+			WithHeader("Content-Type", "audio/flac").
+			WithText(flacContent).
+			Expect().
+			Status(http.StatusOK)
+	}
+
+	mp3 := []byte{0xFF, 0xFB, 0x90, 0x64, 0x00, 0x00}
+	t.expect.PUT("/1/audio").
+		WithHeader("Content-Type", "audio/mpeg").
+		WithBytes(mp3).
+		Expect().
+		Status(http.StatusOK)
+	t.blobExists(flacKey, true)
+
+	t.expect.PUT("/2/audio").
+		WithHeader("Content-Type", "audio/mpeg").
+		WithBytes(mp3).
+		Expect().
+		Status(http.StatusOK)
+	t.blobExists(flacKey, false)
+}
+
+func (t *AudioTest) TestUpload_ReleasesUnsaved() {
+	t.store = failingTxStore{t.store}
+	t.serve(&api.Config{})
+
+	e := t.expect.PUT("/1/audio").
+		WithHeader("Content-Type", "audio/flac").
+		WithText(flacContent).
+		Expect()
+
+	e.Status(http.StatusInternalServerError)
+	t.blobExists("audio/"+strings.Trim(t.streamETag(), `"`), false)
+}
+
+// failingTxStore fails all transactions.
+type failingTxStore struct {
+	model.Store
+}
+
+func (failingTxStore) RunInTx(context.Context, func(model.TxStores) error) error {
+	return errors.New("transaction failed")
+}
+
+func (t *AudioTest) blobExists(key string, want bool) {
+	r, err := t.blobs.Open(context.Background(), key)
+	if err == nil {
+		r.Close()
+	}
+	t.Equal(want, err == nil, key)
+}
+
+func (t *AudioTest) expectNoAudio() {
+	track, err := t.store.GetTrack(context.Background(), 1)
+	t.Require().NoError(err)
+	t.Nil(track.Meta)
+}
+
+func (t *AudioTest) TestStream_Ok() {
+	t.upload()
+
+	e := t.expect.GET("/1/stream").
+		WithHeader("Accept", "audio/*").
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.Header("Content-Type").IsEqual("audio/flac")
+	e.Header("Accept-Ranges").IsEqual("bytes")
+	e.Header("ETag").IsEqual(t.streamETag())
+	e.Header("Cache-Control").IsEqual("no-cache")
+	e.Body().IsEqual(flacContent)
+}
+
+func (t *AudioTest) TestStream_Head() {
+	t.upload()
+
+	e := t.expect.HEAD("/1/stream").
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.Header("Content-Length").IsEqual(strconv.Itoa(len(flacContent)))
+	e.Body().IsEmpty()
+}
+
+func (t *AudioTest) TestStream_Range() {
+	t.upload()
+
+	e := t.expect.GET("/1/stream").
+		WithHeader("Range", "bytes=4-7").
+		Expect()
+
+	e.Status(http.StatusPartialContent)
+	e.Header("Content-Type").IsEqual("audio/flac")
+	e.Header("Content-Range").IsEqual(fmt.Sprintf("bytes 4-7/%d", len(flacContent)))
+	e.Body().IsEqual(flacContent[4:8])
+}
+
+func (t *AudioTest) TestStream_MultipleRanges() {
+	t.upload()
+
+	e := t.expect.GET("/1/stream").
+		WithHeader("Range", "bytes=0-3,-2").
+		Expect()
+
+	e.Status(http.StatusPartialContent)
+	e.Header("Content-Type").HasPrefix("multipart/byteranges; boundary=")
+
+	body := e.Body().Raw()
+	t.Contains(body, "Content-Type: audio/flac")
+	t.Contains(body, fmt.Sprintf("Content-Range: bytes 0-3/%d", len(flacContent)))
+	t.Contains(body, fmt.Sprintf("Content-Range: bytes %d-%d/%d", len(flacContent)-2, len(flacContent)-1, len(flacContent)))
+}
+
+func (t *AudioTest) TestStream_UnsatisfiableRange() {
+	t.upload()
+
+	e := t.expect.GET("/1/stream").
+		WithHeader("Range", fmt.Sprintf("bytes=%d-", len(flacContent))).
+		Expect()
+
+	e.Status(http.StatusRequestedRangeNotSatisfiable)
+	e.Header("Content-Range").IsEqual(fmt.Sprintf("bytes */%d", len(flacContent)))
+}
+
+func (t *AudioTest) TestStream_IfRange() {
+	t.upload()
+
+	t.Run("matched", func() {
+		t.expect.GET("/1/stream").
+			WithHeader("Range", "bytes=0-3").
+			WithHeader("If-Range", t.streamETag()).
+			Expect().
+			Status(http.StatusPartialContent)
+	})
+
+	t.Run("changed", func() {
+		e := t.expect.GET("/1/stream").
+			WithHeader("Range", "bytes=0-3").
+			WithHeader("If-Range", `"outdated"`).
+			Expect()
+
+		e.Status(http.StatusOK)
+		e.Body().IsEqual(flacContent)
+	})
+}
+
+func (t *AudioTest) TestStream_NotModified() {
+	t.upload()
+
+	t.expect.GET("/1/stream").
+		WithHeader("If-None-Match", t.streamETag()).
+		Expect().
+		Status(http.StatusNotModified)
+}
+
+func (t *AudioTest) TestStream_NotFound() {
+	t.Run("no_track", func() {
+		e := t.expect.GET("/2/stream").
+			Expect()
+
+		e.Status(http.StatusNotFound)
+		e.JSON(jsonAPIContent).Schema(errorResponse())
+	})
+
+	t.Run("no_audio", func() {
+		e := t.expect.GET("/1/stream").
+			Expect()
+
+		e.Status(http.StatusNotFound)
+		e.JSON(jsonAPIContent).Schema(errorResponse())
+	})
+}
+
+func (t *AudioTest) upload() {
+	t.expect.PUT("/1/audio").
+		WithHeader("Content-Type", "audio/flac").
+		WithText(flacContent).
+		Expect().
+		Status(http.StatusOK)
+}
+
+func (t *AudioTest) streamETag() string {
+	sum := sha256.Sum256([]byte(flacContent))
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func (t *AudioTest) TestUpload_TagsFill() {
+	e := t.expect.PUT("/1/audio").
+		WithHeader("Content-Type", "audio/flac").
+		WithBytes(flacFile("TITLE=song", "DATE=2011-05-03", "GENRE=Rock")).
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.Header("ETag").IsEqual(`"3"`)
+
+	response := e.JSON(jsonAPIContent).Schema(schema("AudioUploadDataResponse")).Object()
+	response.NotContainsKey("meta")
+	response.Value("data").Object().Value("attributes").IsEqual(map[string]any{
+		"title":    "Song",
+		"duration": 10_000,
+		"year":     2011,
+		"genres":   []string{"Rock"},
+		"explicit": false,
+	})
+}
+
+func (t *AudioTest) TestUpload_TagConflicts() {
+	e := t.expect.PUT("/1/audio").
+		WithHeader("Content-Type", "audio/flac").
+		WithBytes(flacFile("TITLE=Other", "DATE=2011")).
+		Expect()
+
+	e.Status(http.StatusOK)
+
+	response := e.JSON(jsonAPIContent).Schema(schema("AudioUploadDataResponse")).Object()
+	response.Value("meta").Object().Value("tagConflicts").IsEqual([]any{
+		map[string]any{"attribute": "title", "value": "Other"},
+	})
+
+	attrs := response.Value("data").Object().Value("attributes").Object()
+	attrs.Value("title").IsEqual("Song")
+	attrs.Value("year").IsEqual(2011)
+}
+
+func (t *AudioTest) TestUpload_TagsRejected() {
+	e := t.expect.PUT("/1/audio").
+		WithQuery("tags", "reject").
+		WithHeader("Content-Type", "audio/flac").
+		WithBytes(flacFile("TITLE=Other", "DATE=2011")).
+		Expect()
+
+	e.Status(http.StatusConflict)
+	e.JSON(jsonAPIContent).Schema(errorResponse()).Object().
+		Value("errors").Array().Value(0).Object().
+		Value("detail").String().Contains(`title "Other"`)
+
+	track, err := t.store.GetTrack(context.Background(), 1)
+	t.Require().NoError(err)
+	t.Nil(track.Attrs.Year)
+	t.Nil(track.Meta)
+}
+
+func (t *AudioTest) TestUpload_TagsIgnored() {
+	e := t.expect.PUT("/1/audio").
+		WithQuery("tags", "ignore").
+		WithHeader("Content-Type", "audio/flac").
+		WithBytes(flacFile("TITLE=Other", "DATE=2011")).
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.Header("ETag").IsEqual(`"2"`)
+	e.JSON(jsonAPIContent).Schema(schema("AudioUploadDataResponse")).Object().
+		Value("data").Object().Value("attributes").Object().NotContainsKey("year")
+}
+
+func (t *AudioTest) TestUpload_InvalidTagsMode() {
+	e := t.expect.PUT("/1/audio").
+		WithQuery("tags", "merge").
+		WithHeader("Content-Type", "audio/flac").
+		WithBytes(flacFile()).
+		Expect()
+
+	e.Status(http.StatusBadRequest)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
+
+// flacFile makes a FLAC file lasting 10 seconds with the Vorbis comment fields, but no audio frames.
+func flacFile(fields ...string) []byte {
+	info := make([]byte, 34)
+	binary.BigEndian.PutUint64(info[10:], 44100<<44|1<<41|15<<36|441000)
+
+	comment := binary.LittleEndian.AppendUint32(nil, 0)
+	comment = binary.LittleEndian.AppendUint32(comment, uint32(len(fields)))
+	for _, f := range fields {
+		comment = binary.LittleEndian.AppendUint32(comment, uint32(len(f)))
+		comment = append(comment, f...)
+	}
+
+	file := []byte("fLaC")
+	file = append(file, 0, 0, 0, byte(len(info)))
+	file = append(file, info...)
+	file = append(file, 0x84, byte(len(comment)>>16), byte(len(comment)>>8), byte(len(comment)))
+	return append(file, comment...)
+}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/cerfical/muzik/internal/log"
+	"github.com/cerfical/muzik/internal/model"
+)
+
+type auditHandler struct {
+	store model.AuditStore
+	log   *log.Logger
+}
+
+func (h *auditHandler) getAll(w http.ResponseWriter, r *http.Request) {
+	query, params, err := parseAuditQuery(r.URL.Query())
+	if err != nil {
+		reportQueryError(w, r, err, h.log)
+		return
+	}
+
+	page, err := h.store.GetAudit(r.Context(), query)
+	if err != nil {
+		internalError("Failed to read the audit log from persistent storage", err, h.log)(w, r)
+		return
+	}
+
+	lastID := 0
+	if n := len(page.Entries); n > 0 {
+		lastID = page.Entries[n-1].ID
+	}
+
+	encode(w, r, http.StatusOK, &document{
+		Links: params.collectionLinks(r.URL, len(page.Entries), lastID, page.Total),
+		Meta:  &collectionMeta{Total: page.Total},
+		Data:  page.Entries,
+	})
+}
@@ -0,0 +1,179 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cerfical/muzik/internal/audit"
+	"github.com/cerfical/muzik/internal/httpserv/api"
+	"github.com/cerfical/muzik/internal/memstore"
+	"github.com/cerfical/muzik/internal/mocks"
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/gavv/httpexpect/v2"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestAudit(t *testing.T) {
+	suite.Run(t, new(AuditTest))
+}
+
+type AuditTest struct {
+	suite.Suite
+
+	store  *mocks.Store
+	expect *httpexpect.Expect
+}
+
+func (t *AuditTest) SetupTest() {
+	t.store = mocks.NewStore(t.T())
+	t.serve(&api.Config{}, t.store)
+}
+
+func (t *AuditTest) serve(config *api.Config, store model.Store) {
+	t.expect = httpexpect.WithConfig(httpexpect.Config{
+		TestName: t.T().Name(),
+		BaseURL:  "/api",
+		Reporter: httpexpect.NewAssertReporter(t.T()),
+		Client: &http.Client{
+			Transport: httpexpect.NewBinder(api.NewHandler(config, store, nil, nil)),
+		},
+	})
+}
+
+var sampleAuditEntries = []model.AuditEntry{
+	{
+		ID: 1,
+		Attrs: model.AuditAttrs{
+			Time:      time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+			Actor:     "alice",
+			RequestID: "f00d",
+			Action:    model.AuditCreate,
+			Resource:  model.ResourceID{Type: model.TrackType, ID: 1},
+			Changes: map[string]model.AuditChange{
+				"/attributes/title": {After: []byte(`"Song"`)},
+			},
+		},
+	},
+	{
+		ID: 2,
+		Attrs: model.AuditAttrs{
+			Time:     time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC),
+			Action:   model.AuditDelete,
+			Resource: model.ResourceID{Type: model.TrackType, ID: 1},
+			Changes: map[string]model.AuditChange{
+				"/attributes/title": {Before: []byte(`"Song"`)},
+			},
+		},
+	},
+}
+
+func (t *AuditTest) TestAudit_GetAll_Ok() {
+	t.store.EXPECT().
+		GetAudit(mock.Anything, &model.AuditQuery{Limit: 20}).
+		Return(&model.AuditPage{Entries: sampleAuditEntries, Total: 2}, nil)
+
+	e := t.expect.GET("/audit").
+		Expect()
+
+	e.Status(http.StatusOK)
+
+	response := e.JSON(jsonAPIContent).Schema(schema("AuditEntriesDataResponse")).Object()
+	response.Value("meta").Object().Value("total").IsEqual(2)
+
+	data := response.Value("data").Array()
+	data.Length().IsEqual(2)
+
+	entry := data.Value(0).Object()
+	entry.Value("type").IsEqual("auditEntries")
+	entry.Value("id").IsEqual("1")
+
+	attrs := entry.Value("attributes").Object()
+	attrs.Value("actor").IsEqual("alice")
+	attrs.Value("requestId").IsEqual("f00d")
+	attrs.Value("resource").IsEqual(map[string]any{"type": "tracks", "id": "1"})
+	attrs.Value("changes").IsEqual(map[string]any{
+		"/attributes/title": map[string]any{"before": nil, "after": "Song"},
+	})
+}
+
+func (t *AuditTest) TestAudit_GetAll_Filtered() {
+	t.store.EXPECT().
+		GetAudit(mock.Anything, &model.AuditQuery{
+			Resource: model.ResourceID{Type: model.TrackType, ID: 1},
+			Since:    time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+			Until:    time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC),
+			Limit:    1,
+		}).
+		Return(&model.AuditPage{Entries: sampleAuditEntries[:1], Total: 2}, nil)
+
+	e := t.expect.GET("/audit").
+		WithQuery("filter[resourceType]", "tracks").
+		WithQuery("filter[resourceId]", 1).
+		WithQuery("filter[since]", "2024-05-01T00:00:00Z").
+		WithQuery("filter[until]", "2024-05-02T00:00:00Z").
+		WithQuery("page[size]", 1).
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.JSON(jsonAPIContent).Schema(schema("AuditEntriesDataResponse")).Object().
+		Value("links").Object().ContainsKey("next")
+}
+
+func (t *AuditTest) TestAudit_GetAll_BadQuery() {
+	tests := []struct {
+		name  string
+		param string
+		value string
+	}{
+		{"unknown_resource_type", "filter[resourceType]", "genres"},
+		{"unaudited_resource_type", "filter[resourceType]", "duplicateGroups"},
+		{"resource_id_without_type", "filter[resourceId]", "1"},
+		{"non_numeric_resource_id", "filter[resourceId]", "abc"},
+		{"invalid_since", "filter[since]", "yesterday"},
+		{"invalid_until", "filter[until]", "2024-05-01"},
+		{"unknown_filter", "filter[action]", "create"},
+		{"sort", "sort", "-id"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func() {
+			e := t.expect.GET("/audit").
+				WithQuery(test.param, test.value).
+				Expect()
+
+			e.Status(http.StatusBadRequest)
+			e.JSON(jsonAPIContent).Schema(errorResponse()).Object().
+				Value("errors").Array().Value(0).Object().
+				Value("source").Object().Value("parameter").IsEqual(test.param)
+		})
+	}
+}
+
+func (t *AuditTest) TestAudit_RecordsActor() {
+	t.serve(&api.Config{ActorHeader: "X-Forwarded-User"}, audit.New(memstore.New()))
+
+	t.expect.POST("/tracks/").
+		WithHeader("X-Forwarded-User", "alice").
+		WithJSON(map[string]any{
+			"data": map[string]any{
+				"type":       "tracks",
+				"attributes": map[string]any{"title": "Song"},
+			},
+		}).
+		Expect().
+		Status(http.StatusCreated)
+
+	data := t.expect.GET("/audit").
+		Expect().
+		Status(http.StatusOK).
+		JSON(jsonAPIContent).Schema(schema("AuditEntriesDataResponse")).Object().
+		Value("data").Array()
+
+	data.Length().IsEqual(1)
+	attrs := data.Value(0).Object().Value("attributes").Object()
+	attrs.Value("actor").IsEqual("alice")
+	attrs.Value("action").IsEqual("create")
+	attrs.Value("resource").IsEqual(map[string]any{"type": "tracks", "id": "1"})
+}
@@ -0,0 +1,207 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	jsonMediaType    = "application/json"
+	jsonAPIMediaType = "application/vnd.api+json"
+	csvMediaType     = "text/csv"
+	msgpackMediaType = "application/msgpack"
+	yamlMediaType    = "application/yaml"
+)
+
+// codec converts API documents to and from one of their representations.
+type codec struct {
+	mediaType string
+
+	encode func(w io.Writer, v any) error
+
+	// decode is nil for representations that are only produced by the server.
+	decode func(r io.Reader, v any) error
+}
+
+// codecs lists the supported representations of API documents, in order of preference.
+//
+// Plain JSON is kept as an alias of the JSON:API media type for clients unaware of the latter.
+var codecs = []*codec{
+	{jsonAPIMediaType, encodeJSON, decodeJSON},
+	{jsonMediaType, encodeJSON, decodeJSON},
+	{csvMediaType, encodeCSV, nil},
+	{msgpackMediaType, encodeMsgpack, decodeMsgpack},
+	{yamlMediaType, encodeYAML, decodeYAML},
+}
+
+var defaultCodec = codecs[0]
+
+// encodeMediaTypes lists media types that API documents can be represented as in responses.
+var encodeMediaTypes = func() []string {
+	var mediaTypes []string
+	for _, c := range codecs {
+		mediaTypes = append(mediaTypes, c.mediaType)
+	}
+	return mediaTypes
+}()
+
+// decodeMediaTypes lists media types that API documents can be represented as in requests.
+var decodeMediaTypes = func() []string {
+	var mediaTypes []string
+	for _, c := range codecs {
+		if c.decode != nil {
+			mediaTypes = append(mediaTypes, c.mediaType)
+		}
+	}
+	return mediaTypes
+}()
+
+func codecByMediaType(mediaType string) (*codec, bool) {
+	for _, c := range codecs {
+		if c.mediaType == mediaType {
+			return c, true
+		}
+	}
+	return nil, false
+}
+
+// responseCodec finds the codec for the media type negotiated by [accepts].
+func responseCodec(r *http.Request) *codec {
+	if c, ok := codecByMediaType(responseType(r)); ok {
+		return c
+	}
+	return defaultCodec
+}
+
+// requestCodec finds the codec for the request body, assuming [hasContentType] has accepted its media type.
+func requestCodec(r *http.Request) *codec {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if c, ok := codecByMediaType(mediaType); ok && c.decode != nil {
+		return c
+	}
+	return defaultCodec
+}
+
+// encodeYAML converts v to JSON first, so that the YAML representation follows the JSON one, including the order of fields.
+func encodeYAML(w io.Writer, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	// JSON is a subset of YAML, but the result should not look like JSON
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return err
+	}
+	resetStyle(&doc)
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func resetStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		resetStyle(c)
+	}
+}
+
+// decodeYAML decodes a single YAML document.
+func decodeYAML(r io.Reader, v any) error {
+	dec := yaml.NewDecoder(r)
+
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return &parseError{msg: "The request body must not be empty"}
+		}
+		return &parseError{msg: fmt.Sprintf("The request body contains invalid YAML content: %v", err)}
+	}
+
+	if err := dec.Decode(new(any)); !errors.Is(err, io.EOF) {
+		return &parseError{msg: "The request body must contain a single YAML document"}
+	}
+
+	return decodeGeneric(doc, v)
+}
+
+func encodeMsgpack(w io.Writer, v any) error {
+	doc, err := toGeneric(v)
+	if err != nil {
+		return err
+	}
+	return msgpack.NewEncoder(w).Encode(doc)
+}
+
+// decodeMsgpack decodes a MessagePack document.
+func decodeMsgpack(r io.Reader, v any) error {
+	var doc any
+	if err := msgpack.NewDecoder(r).Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return &parseError{msg: "The request body must not be empty"}
+		}
+		return &parseError{msg: fmt.Sprintf("The request body contains invalid MessagePack content: %v", err)}
+	}
+	return decodeGeneric(doc, v)
+}
+
+// decodeGeneric decodes a document made of maps, slices and scalars into v by converting it to JSON,
+// so that documents in all media types are validated the same way as JSON ones.
+func decodeGeneric(doc any, v any) error {
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return &parseError{msg: "The request body contains values that have no JSON equivalent"}
+	}
+	return decodeJSON(bytes.NewReader(b), v)
+}
+
+// toGeneric converts v to maps, slices and scalars through its JSON representation.
+func toGeneric(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return normalizeNumbers(doc), nil
+}
+
+// normalizeNumbers replaces [json.Number] with integers where possible, and floats otherwise.
+func normalizeNumbers(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			v[k] = normalizeNumbers(e)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = normalizeNumbers(e)
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		f, _ := v.Float64()
+		return f
+	}
+	return v
+}
@@ -0,0 +1,228 @@
+package api_test
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cerfical/muzik/internal/httpserv/api"
+	"github.com/cerfical/muzik/internal/mocks"
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/gavv/httpexpect/v2"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/vmihailenco/msgpack/v5"
+	"gopkg.in/yaml.v3"
+)
+
+func TestCodecs(t *testing.T) {
+	suite.Run(t, new(CodecsTest))
+}
+
+type CodecsTest struct {
+	suite.Suite
+
+	store  *mocks.Store
+	expect *httpexpect.Expect
+}
+
+func (t *CodecsTest) SetupTest() {
+	t.store = mocks.NewStore(t.T())
+	t.expect = httpexpect.WithConfig(httpexpect.Config{
+		TestName: t.T().Name(),
+		BaseURL:  "/api/tracks",
+		Reporter: httpexpect.NewAssertReporter(t.T()),
+		Client: &http.Client{
+			Transport: httpexpect.NewBinder(api.NewHandler(&api.Config{}, t.store, nil, nil)),
+		},
+	})
+}
+
+func (t *CodecsTest) TestEncode_Negotiated() {
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+	}{
+		{"json_api_by_default", "", "application/vnd.api+json"},
+		{"json_api_for_any_type", "*/*", "application/vnd.api+json"},
+		{"json", "application/json", "application/json"},
+		{"csv", "text/csv", "text/csv"},
+		{"msgpack", "application/msgpack", "application/msgpack"},
+		{"yaml", "application/yaml", "application/yaml"},
+		{"highest_qvalue", "application/json;q=0.2, application/yaml;q=0.8, text/*;q=0.5", "application/yaml"},
+		{"most_specific_range", "application/*;q=0.1, application/msgpack", "application/msgpack"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func() {
+			t.store.EXPECT().
+				GetTrack(mock.Anything, 1).
+				Return(&sampleTracks[0], nil).
+				Once()
+
+			e := t.expect.GET("/1").
+				WithHeader("Accept", test.accept).
+				Expect()
+
+			e.Status(http.StatusOK).
+				Header("Content-Type").IsEqual(test.contentType)
+			e.Header("Vary").IsEqual("Accept")
+		})
+	}
+}
+
+func (t *CodecsTest) TestEncode_YAML() {
+	t.store.EXPECT().
+		GetTrack(mock.Anything, 2).
+		Return(&sampleTracks[1], nil)
+
+	e := t.expect.GET("/2").
+		WithHeader("Accept", "application/yaml").
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.Body().IsEqual(strings.Join([]string{
+		"jsonapi:",
+		`  version: "1.1"`,
+		"links:",
+		"  self: /api/tracks/2",
+		"data:",
+		"  type: tracks",
+		`  id: "2"`,
+		"  attributes:",
+		"    title: 'Example Track #2'",
+		"    explicit: false",
+		"  relationships:",
+		"    album:",
+		"      data: null",
+		"    artists:",
+		"      data: []",
+		"",
+	}, "\n"))
+}
+
+func (t *CodecsTest) TestEncode_CSV() {
+	tracks := []model.Track{sampleTracks[0], sampleTracks[1]}
+	tracks[0].Rels = model.TrackRels{AlbumID: 1, ArtistIDs: []int{1, 2}}
+
+	t.store.EXPECT().
+		GetTracks(mock.Anything, mock.Anything).
+		Return(&model.TrackPage{Tracks: tracks, Total: 2}, nil)
+
+	e := t.expect.GET("/").
+		WithHeader("Accept", "text/csv").
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.Body().IsEqual(strings.Join([]string{
+		"id,title,duration,trackNumber,year,genres,isrc,bpm,explicit,album,artists",
+		"1,Example Track #1,215000,1,1999,Rock;Pop,USRC17607839,120.5,true,1,1;2",
+		"2,Example Track #2,,,,,,,false,,",
+		"",
+	}, "\n"))
+}
+
+func (t *CodecsTest) TestEncode_Errors() {
+	t.store.EXPECT().
+		GetTrack(mock.Anything, 3).
+		Return(nil, model.ErrNotFound)
+
+	e := t.expect.GET("/3").
+		WithHeader("Accept", "text/csv").
+		Expect()
+
+	e.Status(http.StatusNotFound).
+		Header("Content-Type").IsEqual("text/csv")
+	e.Body().HasPrefix("status,title,detail\n404,Resource not found,")
+}
+
+func (t *CodecsTest) TestDecode() {
+	newTrack := map[string]any{
+		"data": map[string]any{
+			"attributes": map[string]any{"title": "New Track", "year": 2001},
+		},
+	}
+
+	yamlBody, err := yaml.Marshal(newTrack)
+	t.Require().NoError(err)
+
+	msgpackBody, err := msgpack.Marshal(newTrack)
+	t.Require().NoError(err)
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+	}{
+		{"json_api", "application/vnd.api+json", []byte(`{"data":{"attributes":{"title":"New Track","year":2001}}}`)},
+		{"yaml", "application/yaml", yamlBody},
+		{"msgpack", "application/msgpack", msgpackBody},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func() {
+			t.store.EXPECT().
+				CreateTrack(mock.Anything, &model.Track{Attrs: model.TrackAttrs{Title: "New Track", Year: ptr(2001)}}).
+				Return(&model.Track{ID: 3, Attrs: model.TrackAttrs{Title: "New Track", Year: ptr(2001)}}, nil).
+				Once()
+
+			e := t.expect.POST("/").
+				WithHeader("Content-Type", test.contentType).
+				WithBytes(test.body).
+				Expect()
+
+			e.Status(http.StatusCreated)
+			e.JSON(jsonAPIContent).Schema(trackDataResponse())
+		})
+	}
+}
+
+func (t *CodecsTest) TestDecode_BadRequest() {
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+	}{
+		{"yaml_syntax", "application/yaml", []byte("data: [")},
+		{"yaml_multiple_documents", "application/yaml", []byte("data: {}\n---\ndata: {}\n")},
+		{"yaml_invalid_attribute_type", "application/yaml", []byte("data:\n  attributes:\n    title: [1]\n")},
+		{"msgpack_truncated", "application/msgpack", []byte{0x81, 0xa4}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func() {
+			e := t.expect.POST("/").
+				WithHeader("Content-Type", test.contentType).
+				WithBytes(test.body).
+				Expect()
+
+			e.Status(http.StatusBadRequest)
+			e.JSON(jsonAPIContent).Schema(errorResponse())
+		})
+	}
+}
+
+func (t *CodecsTest) TestNotAcceptable() {
+	e := t.expect.GET("/1").
+		WithHeader("Accept", "application/xml").
+		Expect()
+
+	e.Status(http.StatusNotAcceptable).
+		Header("Vary").IsEqual("Accept")
+	e.JSON(jsonAPIContent).Schema(errorResponse()).Object().
+		Value("errors").Array().Value(0).Object().
+		Value("detail").String().
+		Contains("'application/vnd.api+json', 'application/json', 'text/csv', 'application/msgpack', 'application/yaml'")
+}
+
+func (t *CodecsTest) TestUnsupportedMediaType_CSV() {
+	e := t.expect.POST("/").
+		WithHeader("Content-Type", "text/csv").
+		WithBytes(bytes.Repeat([]byte("a"), 4)).
+		Expect()
+
+	e.Status(http.StatusUnsupportedMediaType)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
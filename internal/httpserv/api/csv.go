@@ -0,0 +1,160 @@
+package api
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// csvListSeparator separates elements of arrays within a single CSV field.
+const csvListSeparator = ";"
+
+// encodeCSV renders the primary data of a document, or its errors, as a table with a row per resource.
+//
+// Attributes and relationships of resources are placed in columns of their own, with relationships represented by IDs of the related resources.
+func encodeCSV(w io.Writer, v any) error {
+	doc, err := toOrdered(v)
+	if err != nil {
+		return err
+	}
+
+	obj, ok := doc.(orderedObject)
+	if !ok {
+		return errors.New("the document is not an object")
+	}
+
+	var rows []any
+	if data, ok := obj.get("data"); ok {
+		rows = listOf(data)
+	} else if errs, ok := obj.get("errors"); ok {
+		rows = listOf(errs)
+	}
+
+	var columns []string
+	records := make([]map[string]string, len(rows))
+	for i, row := range rows {
+		rowObj, ok := row.(orderedObject)
+		if !ok {
+			return errors.New("the document rows are not objects")
+		}
+
+		records[i] = make(map[string]string)
+		for _, f := range flattenRow(rowObj) {
+			if !slices.Contains(columns, f.key) {
+				columns = append(columns, f.key)
+			}
+			records[i][f.key] = f.val
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	if len(columns) > 0 {
+		cw.Write(columns)
+	}
+
+	for _, rec := range records {
+		vals := make([]string, len(columns))
+		for i, col := range columns {
+			vals[i] = rec[col]
+		}
+		cw.Write(vals)
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+type csvField struct {
+	key, val string
+}
+
+func flattenRow(row orderedObject) []csvField {
+	var fields []csvField
+	for _, m := range row {
+		switch m.key {
+		case "type":
+			// All rows are resources of the same type
+			continue
+		case "attributes":
+			if attrs, ok := m.val.(orderedObject); ok {
+				for _, attr := range attrs {
+					fields = append(fields, flatten(attr.key, attr.val)...)
+				}
+				continue
+			}
+		case "relationships":
+			if rels, ok := m.val.(orderedObject); ok {
+				for _, rel := range rels {
+					fields = append(fields, csvField{rel.key, relatedIDs(rel.val)})
+				}
+				continue
+			}
+		}
+		fields = append(fields, flatten(m.key, m.val)...)
+	}
+	return fields
+}
+
+// flatten turns nested objects into fields with dotted names.
+func flatten(key string, v any) []csvField {
+	obj, ok := v.(orderedObject)
+	if !ok {
+		return []csvField{{key, csvValue(v)}}
+	}
+
+	var fields []csvField
+	for _, m := range obj {
+		fields = append(fields, flatten(key+"."+m.key, m.val)...)
+	}
+	return fields
+}
+
+// relatedIDs extracts IDs from the resource linkage of a relationship.
+func relatedIDs(rel any) string {
+	obj, ok := rel.(orderedObject)
+	if !ok {
+		return csvValue(rel)
+	}
+
+	data, _ := obj.get("data")
+	var ids []string
+	for _, ref := range listOf(data) {
+		if refObj, ok := ref.(orderedObject); ok {
+			id, _ := refObj.get("id")
+			ids = append(ids, csvValue(id))
+		}
+	}
+	return strings.Join(ids, csvListSeparator)
+}
+
+func csvValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []any:
+		vals := make([]string, len(v))
+		for i, e := range v {
+			vals[i] = csvValue(e)
+		}
+		return strings.Join(vals, csvListSeparator)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// listOf treats single values as lists of one element, and null as an empty list.
+func listOf(v any) []any {
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case []any:
+		return v
+	default:
+		return []any{v}
+	}
+}
@@ -0,0 +1,358 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/cerfical/muzik/internal/log"
+	"github.com/cerfical/muzik/internal/model"
+)
+
+const (
+	includeParam = "include"
+	fieldsParam  = "fields"
+)
+
+var jsonAPI = jsonAPIObject{Version: jsonAPIVersion}
+
+// relationshipTypes maps the relationships of each resource type to the types of related resources.
+var relationshipTypes = map[string]map[string]string{
+	model.TrackType: {
+		"album":   model.AlbumType,
+		"artists": model.ArtistType,
+	},
+	model.ArtistType:     {},
+	model.AlbumType:      {},
+	model.PlaylistType:   {"tracks": model.TrackType},
+	model.AuditEntryType: {},
+
+	model.DuplicateGroupType: {"tracks": model.TrackType},
+}
+
+// queryParamFamilies lists the JSON:API query parameter families understood by the server.
+var queryParamFamilies = []string{includeParam, fieldsParam, "page", "sort", "filter"}
+
+// reservedParamName matches the names of query parameter families reserved by JSON:API.
+var reservedParamName = regexp.MustCompile(`^[a-z]+$`)
+
+// documentOptions controls the contents of documents produced in response to a request.
+type documentOptions struct {
+	// include lists relationship paths along which related resources are included in documents.
+	include [][]string
+
+	// fields maps resource types to the only fields to be present in resource objects of that type.
+	fields map[string][]string
+
+	store model.Store
+	log   *log.Logger
+}
+
+type documentOptionsKey struct{}
+
+// documentParams parses the query parameters controlling the contents of documents with primary data of the specified type.
+//
+// An empty type means that the primary data consists of resource linkage or resources of mixed types, which cannot be complemented with related resources.
+// Additional params are accepted as specific to the endpoint, even if their names are reserved by JSON:API.
+func documentParams(primaryType string, store model.Store, log *log.Logger, params ...string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			opts, err := parseDocumentParams(r.URL.Query(), primaryType, params)
+			if err != nil {
+				reportQueryError(w, r, err, log)
+				return
+			}
+
+			opts.store, opts.log = store, log
+			ctx := context.WithValue(r.Context(), documentOptionsKey{}, opts)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		}
+	}
+}
+
+func parseDocumentParams(query url.Values, primaryType string, params []string) (*documentOptions, error) {
+	opts := documentOptions{fields: make(map[string][]string)}
+	for param := range query {
+		family, member, hasMember := strings.Cut(param, "[")
+		if !slices.Contains(queryParamFamilies, family) {
+			if reservedParamName.MatchString(family) && !slices.Contains(params, param) {
+				return nil, &queryError{param, fmt.Sprintf("The query parameter '%s' is not supported", param)}
+			}
+			// Implementation-specific parameters are left to handlers
+			continue
+		}
+
+		switch family {
+		case includeParam:
+			if primaryType == "" {
+				return nil, &queryError{param, "The endpoint does not support inclusion of related resources"}
+			}
+
+			for _, path := range strings.Split(query.Get(param), ",") {
+				rels := strings.Split(path, ".")
+				if err := checkRelationshipPath(param, primaryType, rels); err != nil {
+					return nil, err
+				}
+				opts.include = append(opts.include, rels)
+			}
+		case fieldsParam:
+			typ, ok := strings.CutSuffix(member, "]")
+			if _, known := relationshipTypes[typ]; !hasMember || !ok || !known {
+				return nil, &queryError{param, "Sparse fieldsets must be specified for a known resource type, as in 'fields[TYPE]'"}
+			}
+
+			names := []string{}
+			if val := query.Get(param); val != "" {
+				names = strings.Split(val, ",")
+			}
+			opts.fields[typ] = names
+		}
+	}
+	return &opts, nil
+}
+
+func checkRelationshipPath(param string, typ string, rels []string) error {
+	for _, rel := range rels {
+		relType, ok := relationshipTypes[typ][rel]
+		if !ok {
+			return &queryError{param, fmt.Sprintf("The resource type '%s' has no relationship '%s' to include", typ, rel)}
+		}
+		typ = relType
+	}
+	return nil
+}
+
+func documentLog(r *http.Request) *log.Logger {
+	if opts, ok := r.Context().Value(documentOptionsKey{}).(*documentOptions); ok {
+		return opts.log
+	}
+	return nil
+}
+
+// complete fills in the members common to all documents and applies the [documentOptions] of the request.
+func (d *document) complete(r *http.Request) (any, error) {
+	d.JSONAPI = &jsonAPI
+	if d.Links == nil {
+		d.Links = &documentLinks{Self: r.URL.String()}
+	}
+
+	opts, ok := r.Context().Value(documentOptionsKey{}).(*documentOptions)
+	if !ok || (len(opts.include) == 0 && len(opts.fields) == 0) {
+		return d, nil
+	}
+
+	if len(opts.include) > 0 {
+		included, err := opts.includeRelated(r.Context(), d.Data)
+		if err != nil {
+			return nil, err
+		}
+		d.Included = included
+	}
+
+	doc, err := toOrdered(d)
+	if err != nil {
+		return nil, err
+	}
+	return opts.applyFields(doc.(orderedObject)), nil
+}
+
+// includeRelated fetches the resources reachable from primary data along the included relationship paths.
+//
+// Each resource is included only once, and never if it is already part of the primary data.
+func (o *documentOptions) includeRelated(ctx context.Context, data any) ([]any, error) {
+	primary, err := toOrdered(data)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make(map[model.ResourceID]orderedObject)
+	for _, res := range listOf(primary) {
+		if obj, ok := res.(orderedObject); ok {
+			resources[identify(obj)] = obj
+		}
+	}
+
+	var included []any
+	for _, path := range o.include {
+		current := listOf(primary)
+		for _, rel := range path {
+			var ids, missing []model.ResourceID
+			seen := make(map[model.ResourceID]bool)
+			for _, res := range current {
+				for _, id := range linkage(res, rel) {
+					if seen[id] {
+						continue
+					}
+					seen[id] = true
+
+					ids = append(ids, id)
+					if _, ok := resources[id]; !ok {
+						missing = append(missing, id)
+					}
+				}
+			}
+
+			fetched, err := fetchResources(ctx, o.store, missing)
+			if err != nil {
+				return nil, err
+			}
+
+			var next []any
+			for _, id := range ids {
+				obj, ok := resources[id]
+				if !ok {
+					related, ok := fetched[id]
+					if !ok {
+						// The resource might have been deleted in the meantime
+						continue
+					}
+
+					v, err := toOrdered(related)
+					if err != nil {
+						return nil, err
+					}
+
+					obj = v.(orderedObject)
+					resources[id] = obj
+					included = append(included, obj)
+				}
+				next = append(next, obj)
+			}
+			current = next
+		}
+	}
+	return included, nil
+}
+
+// applyFields removes from the resource objects of the document all fields not present in the sparse fieldsets.
+func (o *documentOptions) applyFields(doc orderedObject) orderedObject {
+	filter := func(res any) any {
+		obj, ok := res.(orderedObject)
+		if !ok {
+			return res
+		}
+
+		typ, _ := obj.get("type")
+		names, ok := o.fields[fmt.Sprint(typ)]
+		if !ok {
+			return obj
+		}
+
+		filtered := slices.Clone(obj)
+		for _, member := range []string{"attributes", "relationships"} {
+			fields, ok := obj.get(member)
+			if !ok {
+				continue
+			}
+
+			var kept orderedObject
+			for _, f := range fields.(orderedObject) {
+				if slices.Contains(names, f.key) {
+					kept = append(kept, f)
+				}
+			}
+
+			if len(kept) > 0 {
+				filtered = filtered.set(member, kept)
+			} else {
+				filtered = filtered.set(member, nil)
+			}
+		}
+		return filtered
+	}
+
+	if data, ok := doc.get("data"); ok {
+		if list, ok := data.([]any); ok {
+			for i := range list {
+				list[i] = filter(list[i])
+			}
+		} else if data != nil {
+			doc = doc.set("data", filter(data))
+		}
+	}
+
+	if included, ok := doc.get("included"); ok {
+		list := included.([]any)
+		for i := range list {
+			list[i] = filter(list[i])
+		}
+	}
+	return doc
+}
+
+// identify extracts the identity of a resource object.
+func identify(obj orderedObject) model.ResourceID {
+	typ, _ := obj.get("type")
+	id, _ := obj.get("id")
+
+	n, _ := strconv.Atoi(fmt.Sprint(id))
+	return model.ResourceID{Type: fmt.Sprint(typ), ID: n}
+}
+
+// linkage extracts the identities of resources related to a resource object through the relationship.
+func linkage(res any, rel string) []model.ResourceID {
+	obj, ok := res.(orderedObject)
+	if !ok {
+		return nil
+	}
+
+	rels, _ := obj.get("relationships")
+	relsObj, _ := rels.(orderedObject)
+	relObj, _ := relsObj.get(rel)
+	relData, _ := relObj.(orderedObject)
+	data, _ := relData.get("data")
+
+	var ids []model.ResourceID
+	for _, v := range listOf(data) {
+		if ref, ok := v.(orderedObject); ok {
+			ids = append(ids, identify(ref))
+		}
+	}
+	return ids
+}
+
+// fetchResources fetches the existing resources with the specified IDs, making one request to the store for each resource type.
+func fetchResources(ctx context.Context, store model.Store, ids []model.ResourceID) (map[model.ResourceID]any, error) {
+	byType := make(map[string][]int)
+	for _, id := range ids {
+		byType[id.Type] = append(byType[id.Type], id.ID)
+	}
+
+	fetched := make(map[model.ResourceID]any, len(ids))
+	for typ, ids := range byType {
+		switch typ {
+		case model.TrackType:
+			tracks, err := store.GetTracksByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			for i := range tracks {
+				fetched[model.ResourceID{Type: typ, ID: tracks[i].ID}] = &tracks[i]
+			}
+		case model.ArtistType:
+			artists, err := store.GetArtistsByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			for i := range artists {
+				fetched[model.ResourceID{Type: typ, ID: artists[i].ID}] = &artists[i]
+			}
+		case model.AlbumType:
+			albums, err := store.GetAlbumsByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			for i := range albums {
+				fetched[model.ResourceID{Type: typ, ID: albums[i].ID}] = &albums[i]
+			}
+		default:
+			return nil, fmt.Errorf("unknown resource type %q", typ)
+		}
+	}
+	return fetched, nil
+}
@@ -0,0 +1,102 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/cerfical/muzik/internal/dedupe"
+	"github.com/cerfical/muzik/internal/model"
+)
+
+func (h *tracksHandler) getDuplicates(w http.ResponseWriter, r *http.Request) {
+	similarity, params, err := parseDuplicatesQuery(r.URL.Query())
+	if err != nil {
+		reportQueryError(w, r, err, h.log)
+		return
+	}
+
+	tracks, err := dedupe.ReadTracks(r.Context(), h.store)
+	if err != nil {
+		internalError("Failed to read tracks data from persistent storage", err, h.log)(w, r)
+		return
+	}
+
+	groups := dedupe.Find(tracks, similarity)
+	total := len(groups)
+
+	// Groups are ordered by their IDs, so they can be paginated either way
+	if params.after > 0 {
+		i, _ := slices.BinarySearchFunc(groups, params.after+1, func(g model.DuplicateGroup, id int) int { return g.ID - id })
+		groups = groups[i:]
+	} else {
+		groups = groups[min((params.number-1)*params.size, len(groups)):]
+	}
+	groups = groups[:min(params.size, len(groups))]
+
+	lastID := 0
+	if n := len(groups); n > 0 {
+		lastID = groups[n-1].ID
+	}
+
+	encode(w, r, http.StatusOK, &document{
+		Links: params.collectionLinks(r.URL, len(groups), lastID, total),
+		Meta:  &collectionMeta{Total: total},
+		Data:  groups,
+	})
+}
+
+func (h *tracksHandler) merge(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		notFound(w, r)
+		return
+	}
+
+	var req relationshipRequest
+	if !parseRequest(w, r, &req, h.log) {
+		return
+	}
+
+	dropIDs, ok := parseTrackIDs(w, r, req.Data)
+	if !ok || !checkBulkSize(w, r, len(dropIDs)) {
+		return
+	}
+
+	var errs []errorInfo
+	seen := map[int]bool{id: true}
+	for i, dropID := range dropIDs {
+		if seen[dropID] {
+			errs = append(errs, malformedBody(fmt.Sprintf("The track '%d' is listed more than once or is the track merged into", dropID), fmt.Sprintf("/data/%d", i)))
+		}
+		seen[dropID] = true
+	}
+
+	if errs != nil {
+		reportErrors(errs)(w, r)
+		return
+	}
+
+	// Only the revision of the kept track is checked, since the others are only moved to the trash
+	var track *model.Track
+	err = h.store.RunInTx(r.Context(), func(tx model.TxStores) error {
+		if err := h.checkRevision(r, tx, id); err != nil {
+			return err
+		}
+
+		merged, err := dedupe.Merge(r.Context(), tx, id, dropIDs)
+		if err != nil {
+			return err
+		}
+		track = merged
+		return nil
+	})
+
+	if itemErrs := (model.ItemErrors)(nil); errors.As(err, &itemErrs) {
+		reportErrors(bulkErrors(itemErrs, ""))(w, r)
+		return
+	}
+	h.respondSaved(w, r, track, err)
+}
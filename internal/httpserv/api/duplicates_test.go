@@ -0,0 +1,232 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/cerfical/muzik/internal/httpserv/api"
+	"github.com/cerfical/muzik/internal/memstore"
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/gavv/httpexpect/v2"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestDuplicates(t *testing.T) {
+	suite.Run(t, new(DuplicatesTest))
+}
+
+type DuplicatesTest struct {
+	suite.Suite
+
+	store  model.Store
+	expect *httpexpect.Expect
+}
+
+func (t *DuplicatesTest) SetupTest() {
+	t.store = memstore.New()
+
+	_, err := t.store.CreateTracks(context.Background(), []model.Track{
+		{Attrs: model.TrackAttrs{Title: "Song", Genres: []string{"rock"}}},
+		{Attrs: model.TrackAttrs{Title: "Other"}},
+		{Attrs: model.TrackAttrs{Title: "song - remaster", Year: ptr(2011)}},
+		{Attrs: model.TrackAttrs{Title: "Another (Mono)"}},
+		{Attrs: model.TrackAttrs{Title: "Anothr"}},
+	})
+	t.Require().NoError(err)
+
+	t.serve(&api.Config{})
+}
+
+func (t *DuplicatesTest) serve(config *api.Config) {
+	t.expect = httpexpect.WithConfig(httpexpect.Config{
+		TestName: t.T().Name(),
+		BaseURL:  "/api/tracks",
+		Reporter: httpexpect.NewAssertReporter(t.T()),
+		Client: &http.Client{
+			Transport: httpexpect.NewBinder(api.NewHandler(config, t.store, nil, nil)),
+		},
+	})
+}
+
+func (t *DuplicatesTest) TestDuplicates_GetAll_Ok() {
+	e := t.expect.GET("/duplicates").
+		Expect()
+
+	e.Status(http.StatusOK)
+
+	response := e.JSON(jsonAPIContent).Schema(schema("DuplicateGroupsDataResponse")).Object()
+	response.Value("meta").Object().Value("total").IsEqual(2)
+
+	data := response.Value("data").Array()
+	data.Length().IsEqual(2)
+
+	group := data.Value(0).Object()
+	group.Value("type").IsEqual("duplicateGroups")
+	group.Value("id").IsEqual("1")
+	group.Value("attributes").Object().Value("title").IsEqual("song")
+	group.Value("relationships").Object().Value("tracks").Object().Value("data").IsEqual([]any{
+		map[string]any{"type": "tracks", "id": "1"},
+		map[string]any{"type": "tracks", "id": "3"},
+	})
+
+	data.Value(1).Object().Value("id").IsEqual("4")
+}
+
+func (t *DuplicatesTest) TestDuplicates_GetAll_Filtered() {
+	e := t.expect.GET("/duplicates").
+		WithQuery("filter[similarity]", 0.9).
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.JSON(jsonAPIContent).Schema(schema("DuplicateGroupsDataResponse")).Object().
+		Value("data").Array().Length().IsEqual(1)
+}
+
+func (t *DuplicatesTest) TestDuplicates_GetAll_Paginated() {
+	e := t.expect.GET("/duplicates").
+		WithQuery("page[size]", 1).
+		Expect()
+
+	e.Status(http.StatusOK)
+
+	response := e.JSON(jsonAPIContent).Schema(schema("DuplicateGroupsDataResponse")).Object()
+	response.Value("data").Array().Length().IsEqual(1)
+	response.Value("links").Object().Value("next").String().Contains("page%5Bnumber%5D=2")
+
+	e = t.expect.GET("/duplicates").
+		WithQuery("page[size]", 1).
+		WithQuery("page[after]", 1).
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.JSON(jsonAPIContent).Schema(schema("DuplicateGroupsDataResponse")).Object().
+		Value("data").Array().Value(0).Object().Value("id").IsEqual("4")
+}
+
+func (t *DuplicatesTest) TestDuplicates_GetAll_IncludeTracks() {
+	e := t.expect.GET("/duplicates").
+		WithQuery("include", "tracks").
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.JSON(jsonAPIContent).Schema(schema("DuplicateGroupsDataResponse")).Object().
+		Value("included").Array().Length().IsEqual(4)
+}
+
+func (t *DuplicatesTest) TestDuplicates_GetAll_BadQuery() {
+	tests := []struct {
+		name  string
+		param string
+		value string
+	}{
+		{"zero_similarity", "filter[similarity]", "0"},
+		{"large_similarity", "filter[similarity]", "1.5"},
+		{"non_numeric_similarity", "filter[similarity]", "high"},
+		{"unknown_filter", "filter[title]", "song"},
+		{"sort", "sort", "id"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func() {
+			e := t.expect.GET("/duplicates").
+				WithQuery(test.param, test.value).
+				Expect()
+
+			e.Status(http.StatusBadRequest)
+			e.JSON(jsonAPIContent).Schema(errorResponse()).Object().
+				Value("errors").Array().Value(0).Object().
+				Value("source").Object().Value("parameter").IsEqual(test.param)
+		})
+	}
+}
+
+func (t *DuplicatesTest) TestDuplicates_Merge_Ok() {
+	e := t.expect.POST("/1/merge").
+		WithJSON(map[string]any{
+			"data": []any{map[string]any{"type": "tracks", "id": "3"}},
+		}).
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.Header("ETag").IsEqual(`"2"`)
+
+	attrs := e.JSON(jsonAPIContent).Schema(trackDataResponse()).Object().
+		Value("data").Object().Value("attributes").Object()
+	attrs.Value("title").IsEqual("Song")
+	attrs.Value("year").IsEqual(2011)
+
+	t.expect.GET("/3").
+		Expect().
+		Status(http.StatusNotFound)
+}
+
+func (t *DuplicatesTest) TestDuplicates_Merge_NotFound() {
+	e := t.expect.POST("/1/merge").
+		WithJSON(map[string]any{
+			"data": []any{
+				map[string]any{"type": "tracks", "id": "3"},
+				map[string]any{"type": "tracks", "id": "10"},
+			},
+		}).
+		Expect()
+
+	e.Status(http.StatusNotFound)
+	e.JSON(jsonAPIContent).Schema(errorResponse()).Object().
+		Value("errors").Array().Value(0).Object().
+		Value("source").Object().Value("pointer").IsEqual("/data/1")
+
+	t.expect.POST("/10/merge").
+		WithJSON(map[string]any{
+			"data": []any{map[string]any{"type": "tracks", "id": "3"}},
+		}).
+		Expect().
+		Status(http.StatusNotFound)
+}
+
+func (t *DuplicatesTest) TestDuplicates_Merge_BadRequest() {
+	tests := []struct {
+		name string
+		data []any
+	}{
+		{"empty", []any{}},
+		{"itself", []any{map[string]any{"type": "tracks", "id": "1"}}},
+		{"repeated", []any{map[string]any{"type": "tracks", "id": "3"}, map[string]any{"type": "tracks", "id": "3"}}},
+		{"wrong_type", []any{map[string]any{"type": "albums", "id": "3"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func() {
+			e := t.expect.POST("/1/merge").
+				WithJSON(map[string]any{"data": test.data}).
+				Expect()
+
+			e.Status(http.StatusBadRequest)
+			e.JSON(jsonAPIContent).Schema(errorResponse())
+		})
+	}
+}
+
+func (t *DuplicatesTest) TestDuplicates_Merge_Preconditions() {
+	t.serve(&api.Config{RequireIfMatch: true})
+	req := map[string]any{
+		"data": []any{map[string]any{"type": "tracks", "id": "3"}},
+	}
+
+	t.expect.POST("/1/merge").
+		WithJSON(req).
+		Expect().
+		Status(http.StatusPreconditionRequired)
+
+	t.expect.POST("/1/merge").
+		WithHeader("If-Match", `"2"`).
+		WithJSON(req).
+		Expect().
+		Status(http.StatusPreconditionFailed)
+
+	t.expect.POST("/1/merge").
+		WithHeader("If-Match", `"1"`).
+		WithJSON(req).
+		Expect().
+		Status(http.StatusOK)
+}
@@ -1,28 +1,60 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/cerfical/muzik/internal/log"
 	"github.com/cerfical/muzik/internal/model"
 )
 
-const encodeMediaType = "application/json"
+// jsonAPIVersion is the version of the JSON:API specification implemented by the server.
+const jsonAPIVersion = "1.1"
 
-type trackDataResponse struct {
-	Data *model.Track `json:"data"`
+// document is a top-level document carrying primary data, which is either a single resource, a collection of resources, or resource linkage.
+type document struct {
+	JSONAPI  *jsonAPIObject `json:"jsonapi,omitempty"`
+	Links    *documentLinks `json:"links,omitempty"`
+	Meta     any            `json:"meta,omitempty"`
+	Data     any            `json:"data"`
+	Included []any          `json:"included,omitempty"`
+}
+
+type jsonAPIObject struct {
+	Version string `json:"version"`
+}
+
+type documentLinks struct {
+	Self  string `json:"self"`
+	First string `json:"first,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+}
+
+type collectionMeta struct {
+	Total int `json:"total"`
+}
+
+// trashedTrack is a track in the trash, with the time it was moved there in the resource meta.
+type trashedTrack struct {
+	*model.Track
+	Meta trashMeta `json:"meta"`
 }
 
-type tracksDataResponse struct {
-	Data []model.Track `json:"data"`
+type trashMeta struct {
+	*model.TrackMeta
+	DeletedAt time.Time `json:"deletedAt"`
 }
 
 type errorResponse struct {
-	Errors []errorInfo `json:"errors"`
+	JSONAPI *jsonAPIObject `json:"jsonapi,omitempty"`
+	Errors  []errorInfo    `json:"errors"`
 }
 
 type errorInfo struct {
@@ -33,80 +65,205 @@ type errorInfo struct {
 }
 
 type errorSource struct {
-	Header string `json:"header"`
+	Header    string `json:"header,omitempty"`
+	Parameter string `json:"parameter,omitempty"`
+	Pointer   string `json:"pointer,omitempty"`
 }
 
-type newTrackRequest struct {
+// createTracksRequest carries a single track or an array of them, which are decoded one by one to report the problems with each of them.
+type createTracksRequest struct {
+	Data json.RawMessage `json:"data"`
+}
+
+// maxBulkSize is the maximum number of resources in a bulk request.
+const maxBulkSize = 500
+
+// isBulkData checks whether the primary data of a request body is an array of resources.
+func isBulkData(data json.RawMessage) bool {
+	return len(data) > 0 && data[0] == '['
+}
+
+// checkBulkSize checks the number of resources in a bulk request, reporting the problem to the client.
+func checkBulkSize(w http.ResponseWriter, r *http.Request, n int) bool {
+	switch {
+	case n == 0:
+		badRequest("The request body must contain at least one resource", "/data")(w, r)
+		return false
+	case n > maxBulkSize:
+		badRequest(fmt.Sprintf("The request body must contain at most %d resources", maxBulkSize), "/data")(w, r)
+		return false
+	default:
+		return true
+	}
+}
+
+type updateTrackRequest struct {
 	Data *model.Track `json:"data"`
 }
 
-func encode(w http.ResponseWriter, status int, r any) {
-	w.Header().Set("Content-Type", encodeMediaType)
+// resourceRequest is a request to create or update a resource consisting of attributes only.
+type resourceRequest[R any] struct {
+	Data *R `json:"data"`
+}
+
+type playlistRequest struct {
+	Data *model.Playlist `json:"data"`
+}
+
+type relationshipRequest struct {
+	Data []model.ResourceID `json:"data"`
+}
+
+type insertTracksRequest struct {
+	Data []model.ResourceID `json:"data"`
+	Meta *insertTracksMeta  `json:"meta"`
+}
+
+type insertTracksMeta struct {
+	// Position is the index in the playlist to insert the tracks at.
+	Position *int `json:"position"`
+}
+
+// encode writes v to the response in the representation negotiated by [accepts].
+//
+// Documents are completed with members common to all of them, and according to [documentParams].
+func encode(w http.ResponseWriter, r *http.Request, status int, v any) {
+	switch doc := v.(type) {
+	case *document:
+		completed, err := doc.complete(r)
+		if err != nil {
+			internalError("Failed to complete the response document", err, documentLog(r))(w, r)
+			return
+		}
+		v = completed
+	case errorResponse:
+		doc.JSONAPI = &jsonAPI
+		v = doc
+	}
+
+	c := responseCodec(r)
+
+	var buf bytes.Buffer
+	if err := c.encode(&buf, v); err != nil {
+		// Not all documents can be represented in all formats, so report the failure in the default one
+		c, status = defaultCodec, http.StatusInternalServerError
+		buf.Reset()
+		c.encode(&buf, errorResponse{
+			Errors: []errorInfo{{
+				Title:  "Internal server error",
+				Detail: fmt.Sprintf("The response cannot be represented as '%s'", responseType(r)),
+				Status: http.StatusInternalServerError,
+			}},
+		})
+	}
+
+	w.Header().Set("Content-Type", c.mediaType)
 	w.WriteHeader(status)
+	buf.WriteTo(w)
+}
 
-	json.NewEncoder(w).Encode(r)
+func encodeJSON(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
 }
 
-func decode[T any](r io.Reader) (*T, error) {
+// decodeJSON decodes JSON from r into v, leaving fields absent from the input untouched.
+func decodeJSON(r io.Reader, v any) error {
 	dec := json.NewDecoder(r)
 	dec.DisallowUnknownFields()
 
-	var req T
-	if err := dec.Decode(&req); err != nil {
-		if errMsg, ok := describeError(err); ok {
-			return nil, &parseError{errMsg}
+	if err := dec.Decode(v); err != nil {
+		if parseErr := describeError(err); parseErr != nil {
+			return parseErr
 		}
-		return nil, err
+		return err
 	}
 
 	if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
-		return nil, &parseError{"The request body must contain a single JSON object"}
+		return &parseError{msg: "The request body must contain a single JSON object"}
 	}
 
-	return &req, nil
+	return nil
 }
 
-func describeError(err error) (string, bool) {
+// parseRequest decodes the request body into req, reporting any failures to the client.
+func parseRequest(w http.ResponseWriter, r *http.Request, req any, log *log.Logger) bool {
+	return parseRequestBody(w, r, r.Body, req, log)
+}
+
+// parseRequestBody is like [parseRequest], but decodes the request body from the specified reader.
+func parseRequestBody(w http.ResponseWriter, r *http.Request, body io.Reader, req any, log *log.Logger) bool {
+	err := decodeRequestBody(r, body, req)
+	if err == nil {
+		return true
+	}
+
+	if reqErrs := (requestErrors)(nil); errors.As(err, &reqErrs) {
+		reportErrors(reqErrs)(w, r)
+	} else {
+		internalError("Parsing of the request body was interrupted due to an unexpected error", err, log)(w, r)
+	}
+	return false
+}
+
+// decodeRequestBody decodes the request body from the specified reader into req, returning problems with the body as [requestErrors].
+func decodeRequestBody(r *http.Request, body io.Reader, req any) error {
+	err := requestCodec(r).decode(body, req)
+	if parseErr := (*parseError)(nil); errors.As(err, &parseErr) {
+		return requestErrors{malformedBody(parseErr.msg, parseErr.pointer)}
+	}
+	if typeErr := (*model.TypeMismatchError)(nil); errors.As(err, &typeErr) {
+		return requestErrors{conflictInfo(fmt.Sprintf("The resource type '%s' does not match the expected type '%s'", typeErr.Got, typeErr.Want))}
+	}
+	return err
+}
+
+func describeError(err error) *parseError {
 	// Check for type errors
 	if e := (&json.UnmarshalTypeError{}); errors.As(err, &e) {
 		if e.Field != "" {
-			return fmt.Sprintf("The request body contains an invalid value for the field '%s'", e.Field), true
+			return &parseError{
+				msg:     fmt.Sprintf("The request body contains an invalid value for the field '%s'", e.Field),
+				pointer: "/" + strings.ReplaceAll(e.Field, ".", "/"),
+			}
 		}
-		return "The root element of the request body is invalid", true
+		return &parseError{msg: "The root element of the request body is invalid"}
 	}
 
 	// Check for generic syntax errors
 	if e := (&json.SyntaxError{}); errors.As(err, &e) {
-		return fmt.Sprintf("The request body has a syntax error at position %d", e.Offset), true
+		return &parseError{msg: fmt.Sprintf("The request body has a syntax error at position %d", e.Offset)}
 	}
 
 	// Check for empty request body
 	if errors.Is(err, io.EOF) {
-		return "The request body must not be empty", true
+		return &parseError{msg: "The request body must not be empty"}
 	}
 
 	// TODO: https://github.com/golang/go/issues/25956
 	if errors.Is(err, io.ErrUnexpectedEOF) {
-		return "The request body contains invalid JSON content", true
+		return &parseError{msg: "The request body contains invalid JSON content"}
 	}
 
 	// TODO: https://github.com/golang/go/issues/29035
 	errMsg := err.Error()
 	if unknownFieldMsg := `json: unknown field "`; strings.HasPrefix(errMsg, unknownFieldMsg) {
 		field := strings.TrimSuffix(strings.TrimPrefix(errMsg, unknownFieldMsg), `"`)
-		return fmt.Sprintf("The request body contains an unknown field '%s'", field), true
+		return &parseError{msg: fmt.Sprintf("The request body contains an unknown field '%s'", field)}
 	}
 
 	// TODO: Provide more info about errors, maybe?
 	if strings.HasPrefix(errMsg, "json: invalid use of ,string struct tag") {
-		return "The request body contains fields with unexpected values", true
+		return &parseError{msg: "The request body contains fields with unexpected values"}
 	}
 
-	return "", false
+	return nil
 }
 
 type parseError struct {
 	msg string
+
+	// pointer optionally refers to the part of the request body that caused the error.
+	pointer string
 }
 
 func (e *parseError) Error() string {
@@ -5,10 +5,19 @@ import (
 	"net/http"
 
 	"github.com/cerfical/muzik/internal/log"
+	"github.com/cerfical/muzik/internal/model"
 )
 
+// requestErrors are problems with a request found within a transaction.
+// Transactions may be retried, so instead of responding right away, they return the problems to be reported once they are over.
+type requestErrors []errorInfo
+
+func (e requestErrors) Error() string {
+	return e[0].Detail
+}
+
 func notFound(w http.ResponseWriter, r *http.Request) {
-	encode(w, http.StatusNotFound, errorResponse{
+	encode(w, r, http.StatusNotFound, errorResponse{
 		Errors: []errorInfo{{
 			Title:  "Resource not found",
 			Detail: fmt.Sprintf("The requested path '%s' does not refer to a valid resource", r.URL.Path),
@@ -17,11 +26,116 @@ func notFound(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func relatedNotFound(w http.ResponseWriter, r *http.Request) {
+	encode(w, r, http.StatusNotFound, errorResponse{
+		Errors: []errorInfo{{
+			Title:  "Related resource not found",
+			Detail: "The request refers to a related resource that does not exist",
+			Status: http.StatusNotFound,
+		}},
+	})
+}
+
+// badRequest reports a malformed request body, with pointer optionally referring to the offending part of the body.
+func badRequest(detail, pointer string) http.HandlerFunc {
+	return reportErrors([]errorInfo{malformedBody(detail, pointer)})
+}
+
+func malformedBody(detail, pointer string) errorInfo {
+	var source *errorSource
+	if pointer != "" {
+		source = &errorSource{Pointer: pointer}
+	}
+
+	return errorInfo{
+		Title:  "The request body is malformed",
+		Detail: detail,
+		Status: http.StatusBadRequest,
+		Source: source,
+	}
+}
+
+// invalidFields reports invalid values of resource fields located under the specified JSON pointer.
+func invalidFields(errs model.ValidationError, pointer string) http.HandlerFunc {
+	return reportErrors(fieldErrors(errs, pointer))
+}
+
+func fieldErrors(errs model.ValidationError, pointer string) []errorInfo {
+	infos := make([]errorInfo, len(errs))
+	for i, e := range errs {
+		infos[i] = errorInfo{
+			Title:  "Invalid attribute value",
+			Detail: e.Msg,
+			Status: http.StatusUnprocessableEntity,
+			Source: &errorSource{
+				Pointer: pointer + "/" + e.Field,
+			},
+		}
+	}
+	return infos
+}
+
+// reportErrors responds with several errors at once, with their common status or, if they differ, with 400 Bad Request.
+func reportErrors(errs []errorInfo) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := errs[0].Status
+		for _, e := range errs[1:] {
+			if e.Status != status {
+				status = http.StatusBadRequest
+				break
+			}
+		}
+
+		encode(w, r, status, errorResponse{
+			Errors: errs,
+		})
+	}
+}
+
+func invalidParameter(param, detail string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		encode(w, r, http.StatusBadRequest, errorResponse{
+			Errors: []errorInfo{{
+				Title:  "Invalid query parameter",
+				Detail: detail,
+				Status: http.StatusBadRequest,
+				Source: &errorSource{
+					Parameter: param,
+				},
+			}},
+		})
+	}
+}
+
+func contentTooLarge(limit int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		encode(w, r, http.StatusRequestEntityTooLarge, errorResponse{
+			Errors: []errorInfo{{
+				Title:  "Content too large",
+				Detail: fmt.Sprintf("The request body must not exceed %d bytes", limit),
+				Status: http.StatusRequestEntityTooLarge,
+			}},
+		})
+	}
+}
+
+func conflict(detail string) http.HandlerFunc {
+	return reportErrors([]errorInfo{conflictInfo(detail)})
+}
+
+func conflictInfo(detail string) errorInfo {
+	return errorInfo{
+		Title:  "Resource conflict",
+		Detail: detail,
+		Status: http.StatusConflict,
+	}
+}
+
 func internalError(msg string, err error, log *log.Logger) http.HandlerFunc {
-	return func(w http.ResponseWriter, _ *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
 		log.Error(msg, err)
 
-		encode(w, http.StatusInternalServerError, errorResponse{
+		encode(w, r, http.StatusInternalServerError, errorResponse{
 			Errors: []errorInfo{{
 				Title:  "Internal server error",
 				Status: http.StatusInternalServerError,
@@ -29,3 +143,15 @@ func internalError(msg string, err error, log *log.Logger) http.HandlerFunc {
 		})
 	}
 }
+
+var errPreconditionFailed = requestErrors{{
+	Title:  "Precondition failed",
+	Detail: "The resource has been modified since the revision named in the If-Match header",
+	Status: http.StatusPreconditionFailed,
+}}
+
+var errPreconditionRequired = requestErrors{{
+	Title:  "Precondition required",
+	Detail: "The request must be made conditional on the current revision of the resource with an If-Match header",
+	Status: http.StatusPreconditionRequired,
+}}
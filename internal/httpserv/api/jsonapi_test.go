@@ -0,0 +1,235 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/cerfical/muzik/internal/httpserv/api"
+	"github.com/cerfical/muzik/internal/mocks"
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/gavv/httpexpect/v2"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestJSONAPI(t *testing.T) {
+	suite.Run(t, new(JSONAPITest))
+}
+
+type JSONAPITest struct {
+	suite.Suite
+
+	store  *mocks.Store
+	expect *httpexpect.Expect
+}
+
+func (t *JSONAPITest) SetupTest() {
+	t.store = mocks.NewStore(t.T())
+	t.expect = httpexpect.WithConfig(httpexpect.Config{
+		TestName: t.T().Name(),
+		BaseURL:  "/api",
+		Reporter: httpexpect.NewAssertReporter(t.T()),
+		Client: &http.Client{
+			Transport: httpexpect.NewBinder(api.NewHandler(&api.Config{}, t.store, nil, nil)),
+		},
+	})
+}
+
+func (t *JSONAPITest) SetupSubTest() {
+	t.SetupTest()
+}
+
+func (t *JSONAPITest) TestTopLevelMembers() {
+	t.store.EXPECT().
+		GetTrack(mock.Anything, 1).
+		Return(&sampleTracks[0], nil)
+
+	e := t.expect.GET("/tracks/1").
+		Expect()
+
+	e.Status(http.StatusOK).
+		Header("Content-Type").IsEqual("application/vnd.api+json")
+
+	doc := e.JSON(jsonAPIContent).Schema(trackDataResponse()).Object()
+	doc.Value("jsonapi").IsEqual(map[string]any{"version": "1.1"})
+	doc.Value("links").Object().Value("self").IsEqual("/api/tracks/1")
+	doc.Path("$.data.type").IsEqual("tracks")
+	doc.NotContainsKey("included")
+}
+
+func (t *JSONAPITest) TestInclude() {
+	track := model.Track{
+		ID:    1,
+		Attrs: sampleTracks[0].Attrs,
+		Rels:  model.TrackRels{AlbumID: 1, ArtistIDs: []int{2, 1}},
+	}
+
+	t.store.EXPECT().
+		GetTrack(mock.Anything, 1).
+		Return(&track, nil)
+
+	// Related resources are fetched together for each type, and included in order of appearance
+	t.store.EXPECT().
+		GetAlbumsByIDs(mock.Anything, []int{1}).
+		Return(sampleAlbums[:1], nil).
+		Once()
+	t.store.EXPECT().
+		GetArtistsByIDs(mock.Anything, []int{2, 1}).
+		Return(sampleArtists, nil).
+		Once()
+
+	e := t.expect.GET("/tracks/1").
+		WithQuery("include", "artists,album").
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.JSON(jsonAPIContent).Schema(trackDataResponse()).Object().
+		Value("included").IsEqual([]any{sampleArtists[1], sampleArtists[0], sampleAlbums[0]})
+}
+
+func (t *JSONAPITest) TestInclude_Nested() {
+	playlist := model.Playlist{
+		ID:    1,
+		Attrs: samplePlaylists[0].Attrs,
+		Rels:  model.PlaylistRels{TrackIDs: []int{2, 1, 2}},
+	}
+	tracks := []model.Track{
+		{ID: 1, Attrs: sampleTracks[0].Attrs, Rels: model.TrackRels{ArtistIDs: []int{1}}},
+		{ID: 2, Attrs: sampleTracks[1].Attrs, Rels: model.TrackRels{ArtistIDs: []int{1}}},
+	}
+
+	t.store.EXPECT().
+		GetPlaylist(mock.Anything, 1).
+		Return(&playlist, nil)
+	t.store.EXPECT().
+		GetTracksByIDs(mock.Anything, []int{2, 1}).
+		Return(tracks, nil).
+		Once()
+	t.store.EXPECT().
+		GetArtistsByIDs(mock.Anything, []int{1}).
+		Return([]model.Artist{}, nil).
+		Once()
+
+	e := t.expect.GET("/playlists/1").
+		WithQuery("include", "tracks.artists").
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.JSON(jsonAPIContent).Schema(playlistDataResponse()).Object().
+		Value("included").IsEqual([]any{tracks[1], tracks[0]})
+}
+
+func (t *JSONAPITest) TestInclude_BadRequest() {
+	tests := []struct {
+		name string
+		path string
+		rels string
+	}{
+		{"unknown_relationship", "/tracks/1", "playlists"},
+		{"unknown_nested_relationship", "/playlists/1", "tracks.genres"},
+		{"empty_path", "/tracks/1", "album,"},
+		{"resource_linkage", "/playlists/1/relationships/tracks", "tracks"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func() {
+			e := t.expect.GET(test.path).
+				WithQuery("include", test.rels).
+				Expect()
+
+			e.Status(http.StatusBadRequest)
+			e.JSON(jsonAPIContent).Schema(errorResponse()).
+				Path("$.errors[0].source.parameter").IsEqual("include")
+		})
+	}
+}
+
+func (t *JSONAPITest) TestSparseFieldsets() {
+	track := model.Track{
+		ID:    1,
+		Attrs: sampleTracks[0].Attrs,
+		Rels:  model.TrackRels{AlbumID: 1},
+	}
+
+	t.store.EXPECT().
+		GetTracks(mock.Anything, mock.Anything).
+		Return(&model.TrackPage{Tracks: []model.Track{track}, Total: 1}, nil)
+	t.store.EXPECT().
+		GetAlbumsByIDs(mock.Anything, []int{1}).
+		Return(sampleAlbums[:1], nil)
+
+	e := t.expect.GET("/tracks/").
+		WithQuery("include", "album").
+		WithQuery("fields[tracks]", "title,album").
+		WithQuery("fields[albums]", "").
+		Expect()
+
+	e.Status(http.StatusOK)
+
+	// Resources with sparse fieldsets omit otherwise required members, so the schema does not apply
+	doc := e.JSON(jsonAPIContent).Object()
+	doc.Value("data").IsEqual([]any{map[string]any{
+		"type":       "tracks",
+		"id":         "1",
+		"attributes": map[string]any{"title": "Example Track #1"},
+		"relationships": map[string]any{
+			"album": map[string]any{"data": map[string]any{"type": "albums", "id": "1"}},
+		},
+	}})
+	doc.Value("included").IsEqual([]any{map[string]any{
+		"type": "albums",
+		"id":   "1",
+	}})
+}
+
+func (t *JSONAPITest) TestQueryParams_BadRequest() {
+	tests := []struct {
+		name  string
+		param string
+	}{
+		{"unknown_family", "filters[title]"},
+		{"reserved_name", "search"},
+		{"fields_without_type", "fields"},
+		{"fields_with_unknown_type", "fields[genres]"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func() {
+			e := t.expect.GET("/artists/").
+				WithQuery(test.param, "x").
+				Expect()
+
+			e.Status(http.StatusBadRequest)
+			e.JSON(jsonAPIContent).Schema(errorResponse()).
+				Path("$.errors[0].source.parameter").IsEqual(test.param)
+		})
+	}
+}
+
+func (t *JSONAPITest) TestQueryParams_ImplementationSpecific() {
+	t.store.EXPECT().
+		GetArtists(mock.Anything).
+		Return(sampleArtists, nil)
+
+	e := t.expect.GET("/artists/").
+		WithQuery("camelCase", "x").
+		Expect()
+
+	e.Status(http.StatusOK)
+}
+
+func (t *JSONAPITest) TestTypeMismatch() {
+	e := t.expect.POST("/artists/").
+		WithHeader("Content-Type", "application/vnd.api+json").
+		WithJSON(map[string]any{
+			"data": map[string]any{
+				"type":       "albums",
+				"attributes": map[string]any{"name": "Example Artist"},
+			},
+		}).
+		Expect()
+
+	e.Status(http.StatusConflict)
+	e.JSON(jsonAPIContent).Schema(errorResponse()).
+		Path("$.jsonapi.version").IsEqual("1.1")
+}
@@ -1,29 +1,37 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"mime"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 
+	"github.com/cerfical/muzik/internal/audit"
+	"github.com/cerfical/muzik/internal/httpserv"
 	"github.com/cerfical/muzik/internal/log"
 	"github.com/pkg/errors"
 )
 
-// accepts checks Accept header for the presence of the specified media type.
-func accepts(mediaType string) func(http.HandlerFunc) http.HandlerFunc {
+// accepts negotiates the media type of the response with the Accept header, choosing from the specified media types in order of preference.
+//
+// The chosen media type is made available to handlers through [responseType].
+func accepts(mediaTypes ...string) func(http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
-			if checkAcceptHeader(mediaType, r.Header.Get("Accept")) {
-				next.ServeHTTP(w, r)
+			w.Header().Add("Vary", "Accept")
+			if mediaType, ok := negotiate(r.Header.Get("Accept"), mediaTypes); ok {
+				ctx := context.WithValue(r.Context(), responseTypeKey{}, mediaType)
+				next.ServeHTTP(w, r.WithContext(ctx))
 				return
 			}
 
-			encode(w, http.StatusNotAcceptable, errorResponse{
+			encode(w, r, http.StatusNotAcceptable, errorResponse{
 				Errors: []errorInfo{{
 					Title:  "Media type is not acceptable",
-					Detail: fmt.Sprintf("The only acceptable media type is '%s'", mediaType),
+					Detail: fmt.Sprintf("The acceptable media types are %s", quoteList(mediaTypes)),
 					Status: http.StatusNotAcceptable,
 					Source: &errorSource{
 						Header: "Accept",
@@ -34,43 +42,129 @@ func accepts(mediaType string) func(http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-func checkAcceptHeader(supportedType, acceptHeader string) bool {
-	if acceptHeader == "" {
-		// Ignore empty Accept headers
-		return true
+type responseTypeKey struct{}
+
+// responseType reports the media type negotiated for the response by [accepts].
+func responseType(r *http.Request) string {
+	if mediaType, ok := r.Context().Value(responseTypeKey{}).(string); ok {
+		return mediaType
+	}
+	return defaultCodec.mediaType
+}
+
+// mediaRange is a single media range of the Accept header.
+type mediaRange struct {
+	mainType, subType string
+	params            map[string]string
+	q                 float64
+}
+
+// specificity ranks the media range against a media type, returning -1 if it does not match.
+//
+// Media ranges with parameters only match media types that support all of them.
+func (m *mediaRange) specificity(mediaType string) int {
+	for name, val := range m.params {
+		if !mediaTypeParamAllowed(mediaType, name, val) {
+			return -1
+		}
 	}
 
-	supMain, supSub := splitMediaType(supportedType)
-	accTypes := strings.Split(acceptHeader, ",")
+	mainType, subType := splitMediaType(mediaType)
+	switch {
+	case m.mainType == "*" && m.subType == "*":
+		return 0
+	case m.mainType != mainType:
+		return -1
+	case m.subType == "*":
+		return 1
+	case m.subType == subType:
+		return 2
+	default:
+		return -1
+	}
+}
 
-	for _, accType := range accTypes {
+func parseAcceptHeader(acceptHeader string) []mediaRange {
+	var ranges []mediaRange
+	for _, accType := range strings.Split(acceptHeader, ",") {
 		accType, params, err := mime.ParseMediaType(accType)
 		if err != nil {
 			continue
 		}
 
-		if len(params) > 0 {
-			// Q-values is the only allowed media type parameter
-			val, ok := params["q"]
-			if len(params) != 1 || !ok {
+		q := 1.0
+		if val, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(val, 64); err != nil || q < 0 || q > 1 {
 				continue
 			}
+			delete(params, "q")
+		}
 
-			// Q-value is invalid, or explicitly set to 0
-			if valNum, err := strconv.ParseFloat(val, 64); err != nil || valNum == 0 {
-				continue
+		mainType, subType := splitMediaType(accType)
+		ranges = append(ranges, mediaRange{mainType, subType, params, q})
+	}
+	return ranges
+}
+
+// negotiate selects the media type with the highest quality according to the Accept header.
+//
+// The quality of a media type is determined by the most specific media range matching it.
+// Ties are broken by the order of the supported media types.
+//
+// As required by JSON:API, nothing is acceptable if every instance of the JSON:API media type in the header has unsupported parameters.
+func negotiate(acceptHeader string, supportedTypes []string) (string, bool) {
+	if strings.TrimSpace(acceptHeader) == "" {
+		// Absence of the Accept header means any media type is acceptable
+		return supportedTypes[0], true
+	}
+
+	ranges := parseAcceptHeader(acceptHeader)
+	if slices.Contains(supportedTypes, jsonAPIMediaType) && !acceptsJSONAPI(ranges) {
+		return "", false
+	}
+
+	best, bestQ := "", 0.0
+	for _, supType := range supportedTypes {
+		q, specificity := 0.0, -1
+		for _, rng := range ranges {
+			if s := rng.specificity(supType); s > specificity {
+				q, specificity = rng.q, s
 			}
 		}
 
-		accMain, accSub := splitMediaType(accType)
-		if (accMain != supMain && accMain != "*") || (accSub != supSub && accSub != "*") {
+		if q > bestQ {
+			best, bestQ = supType, q
+		}
+	}
+
+	return best, best != ""
+}
+
+// acceptsJSONAPI checks that the JSON:API media type is either absent from the media ranges or present at least once without unsupported parameters.
+func acceptsJSONAPI(ranges []mediaRange) bool {
+	present := false
+	for _, rng := range ranges {
+		if rng.mainType+"/"+rng.subType != jsonAPIMediaType {
 			continue
 		}
 
-		return true
+		if rng.specificity(jsonAPIMediaType) >= 0 {
+			return true
+		}
+		present = true
 	}
+	return !present
+}
 
-	return false
+// mediaTypeParamAllowed reports whether the media type parameter is supported for the media type.
+//
+// The JSON:API media type only allows profiles, which can be safely ignored, and no extensions, since none are implemented.
+// Other media types only allow the charset parameter with the value utf-8.
+func mediaTypeParamAllowed(mediaType, name, val string) bool {
+	if mediaType == jsonAPIMediaType {
+		return name == "profile"
+	}
+	return name == "charset" && strings.EqualFold(val, "utf-8")
 }
 
 func splitMediaType(mediaType string) (string, string) {
@@ -78,31 +172,38 @@ func splitMediaType(mediaType string) (string, string) {
 	return mainType, subType
 }
 
-// hasContentType checks Content-Type for the presence of the specified media type.
-func hasContentType(mediaType string) func(http.HandlerFunc) http.HandlerFunc {
+// hasContentType checks Content-Type for the presence of one of the specified media types.
+func hasContentType(mediaTypes ...string) func(http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
 			contentType := r.Header.Get("Content-Type")
-			if !hasContentBody(r) || checkContentType(contentType, mediaType) {
+			if !hasContentBody(r) || checkContentType(contentType, mediaTypes) {
 				next.ServeHTTP(w, r)
 				return
 			}
 
-			if h, ok := acceptHeaderForMethod(r.Method); ok {
-				w.Header().Set(h, mediaType)
-			}
+			unsupportedMediaType(mediaTypes)(w, r)
+		}
+	}
+}
 
-			encode(w, http.StatusUnsupportedMediaType, errorResponse{
-				Errors: []errorInfo{{
-					Title:  "Media type is unsupported",
-					Detail: fmt.Sprintf("Unexpected content type '%s', only '%s' is allowed", contentType, mediaType),
-					Status: http.StatusUnsupportedMediaType,
-					Source: &errorSource{
-						Header: "Content-Type",
-					},
-				}},
-			})
+// unsupportedMediaType reports that the request content type is not one of the supported media types.
+func unsupportedMediaType(mediaTypes []string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h, ok := acceptHeaderForMethod(r.Method); ok {
+			w.Header().Set(h, strings.Join(mediaTypes, ", "))
 		}
+
+		encode(w, r, http.StatusUnsupportedMediaType, errorResponse{
+			Errors: []errorInfo{{
+				Title:  "Media type is unsupported",
+				Detail: fmt.Sprintf("Unexpected content type '%s', the supported media types are %s", r.Header.Get("Content-Type"), quoteList(mediaTypes)),
+				Status: http.StatusUnsupportedMediaType,
+				Source: &errorSource{
+					Header: "Content-Type",
+				},
+			}},
+		})
 	}
 }
 
@@ -115,27 +216,34 @@ func hasContentBody(r *http.Request) bool {
 	}
 }
 
-func checkContentType(contentType, mediaType string) bool {
+func checkContentType(contentType string, mediaTypes []string) bool {
 	if contentType == "" {
 		// Ignore empty Content-Type headers
 		return true
 	}
 
 	contentType, params, err := mime.ParseMediaType(contentType)
-	if err != nil || contentType != mediaType {
+	if err != nil || !slices.Contains(mediaTypes, contentType) {
 		return false
 	}
 
-	if len(params) > 0 {
-		// charset with the value utf-8 is the only allowed content type parameter
-		if val, ok := params["charset"]; len(params) != 1 || !ok || val != "utf-8" {
+	for name, val := range params {
+		if !mediaTypeParamAllowed(contentType, name, val) {
 			return false
 		}
 	}
-
 	return true
 }
 
+// quoteList formats a list of values for error messages.
+func quoteList(vals []string) string {
+	quoted := make([]string, len(vals))
+	for i, v := range vals {
+		quoted[i] = "'" + v + "'"
+	}
+	return strings.Join(quoted, ", ")
+}
+
 func acceptHeaderForMethod(method string) (string, bool) {
 	switch method {
 	case http.MethodPatch:
@@ -161,3 +269,17 @@ func panicRecover(log *log.Logger) func(http.HandlerFunc) http.HandlerFunc {
 		}
 	}
 }
+
+// auditOrigin attributes changes made while handling a request to the actor named by the specified header and to the request ID.
+func auditOrigin(actorHeader string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			origin := audit.Origin{RequestID: httpserv.RequestID(r.Context())}
+			if actorHeader != "" {
+				origin.Actor = r.Header.Get(actorHeader)
+			}
+
+			next.ServeHTTP(w, r.WithContext(audit.NewContext(r.Context(), origin)))
+		}
+	}
+}
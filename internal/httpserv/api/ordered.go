@@ -0,0 +1,116 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+)
+
+// orderedObject is a JSON object with the order of its members preserved.
+type orderedObject []objectMember
+
+type objectMember struct {
+	key string
+	val any
+}
+
+// MarshalJSON encodes the object with its members in order.
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, err := json.Marshal(m.key)
+		if err != nil {
+			return nil, err
+		}
+
+		val, err := json.Marshal(m.val)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (o orderedObject) get(key string) (any, bool) {
+	for _, m := range o {
+		if m.key == key {
+			return m.val, true
+		}
+	}
+	return nil, false
+}
+
+// set replaces the value of a member, or removes the member if the value is nil.
+func (o orderedObject) set(key string, val any) orderedObject {
+	i := slices.IndexFunc(o, func(m objectMember) bool { return m.key == key })
+	switch {
+	case i < 0 && val != nil:
+		return append(o, objectMember{key, val})
+	case i >= 0 && val == nil:
+		return slices.Delete(o, i, i+1)
+	case i >= 0:
+		o[i].val = val
+	}
+	return o
+}
+
+// toOrdered converts v to its JSON representation, preserving the order of object members.
+func toOrdered(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return decodeOrdered(json.NewDecoder(bytes.NewReader(b)))
+}
+
+// decodeOrdered decodes a JSON value, representing objects as [orderedObject] and numbers as [json.Number].
+func decodeOrdered(dec *json.Decoder) (any, error) {
+	dec.UseNumber()
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch tok {
+	case json.Delim('{'):
+		var obj orderedObject
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			val, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, objectMember{keyTok.(string), val})
+		}
+		_, err := dec.Token()
+		return obj, err
+	case json.Delim('['):
+		arr := []any{}
+		for dec.More() {
+			val, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, val)
+		}
+		_, err := dec.Token()
+		return arr, err
+	default:
+		return tok, nil
+	}
+}
@@ -0,0 +1,223 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/cerfical/muzik/internal/playlistfmt"
+)
+
+const defaultPlaylistName = "Imported playlist"
+
+// playlistMediaTypes lists preferred media types of all supported playlist formats.
+var playlistMediaTypes = func() []string {
+	var mediaTypes []string
+	for _, f := range playlistfmt.Formats {
+		mediaTypes = append(mediaTypes, f.MediaType)
+	}
+	return mediaTypes
+}()
+
+// importMediaTypes lists media types accepted in playlist import requests.
+var importMediaTypes = func() []string {
+	var mediaTypes []string
+	for _, f := range playlistfmt.Formats {
+		mediaTypes = append(mediaTypes, f.MediaType)
+		mediaTypes = append(mediaTypes, f.Aliases...)
+	}
+	return mediaTypes
+}()
+
+// exportMediaTypes lists media types that track collections can be rendered as.
+var exportMediaTypes = append(slices.Clone(encodeMediaTypes), playlistMediaTypes...)
+
+// exportsPlaylist reports whether a playlist file was negotiated for the response instead of an API document.
+func exportsPlaylist(r *http.Request) bool {
+	_, ok := playlistfmt.ByMediaType(responseType(r))
+	return ok
+}
+
+// writePlaylist renders the tracks as a playlist file, in the format negotiated for the response.
+func writePlaylist(w http.ResponseWriter, r *http.Request, store model.Store, title string, tracks []model.Track) error {
+	format, ok := playlistfmt.ByMediaType(responseType(r))
+	if !ok {
+		return fmt.Errorf("unsupported playlist media type %q", responseType(r))
+	}
+
+	entries, err := playlistEntries(r, store, tracks)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := format.Encode(&buf, &playlistfmt.Playlist{Title: title, Entries: entries}); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", format.MediaType)
+	w.WriteHeader(http.StatusOK)
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// playlistEntries describes the tracks as playlist entries, resolving names of their artists and albums.
+func playlistEntries(r *http.Request, store model.Store, tracks []model.Track) ([]playlistfmt.Entry, error) {
+	artists, err := store.GetArtists(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	artistNames := make(map[int]string, len(artists))
+	for _, a := range artists {
+		artistNames[a.ID] = a.Attrs.Name
+	}
+
+	albums, err := store.GetAlbums(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	albumTitles := make(map[int]string, len(albums))
+	for _, a := range albums {
+		albumTitles[a.ID] = a.Attrs.Title
+	}
+
+	entries := make([]playlistfmt.Entry, len(tracks))
+	for i, t := range tracks {
+		var creators []string
+		for _, id := range t.Rels.ArtistIDs {
+			creators = append(creators, artistNames[id])
+		}
+
+		e := playlistfmt.Entry{
+			Location: trackURL(r, t.ID),
+			Title:    t.Attrs.Title,
+			Creator:  strings.Join(creators, ", "),
+			Album:    albumTitles[t.Rels.AlbumID],
+		}
+
+		if t.Attrs.Duration != nil {
+			e.Duration = time.Duration(*t.Attrs.Duration) * time.Millisecond
+		}
+		if t.Attrs.TrackNumber != nil {
+			e.TrackNumber = *t.Attrs.TrackNumber
+		}
+
+		entries[i] = e
+	}
+	return entries, nil
+}
+
+// trackURL builds an absolute URL of a track if the request carries enough information to do so.
+func trackURL(r *http.Request, id int) string {
+	u := url.URL{
+		Path: "/api/tracks/" + strconv.Itoa(id),
+	}
+
+	if r.Host != "" {
+		u.Host = r.Host
+		u.Scheme = "http"
+		if r.TLS != nil {
+			u.Scheme = "https"
+		}
+	}
+	return u.String()
+}
+
+// trackIDFromURL is the inverse of [trackURL], ignoring the scheme and host.
+func trackIDFromURL(location string) (int, bool) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return 0, false
+	}
+
+	idStr, ok := strings.CutPrefix(u.Path, "/api/tracks/")
+	if !ok {
+		return 0, false
+	}
+
+	id, err := strconv.Atoi(idStr)
+	return id, err == nil
+}
+
+// matchTrack finds a track corresponding to the playlist entry, creating a new one if there is none.
+//
+// A track is matched by its URL first, then by its title and duration, if known.
+func matchTrack(ctx context.Context, store model.TrackStore, e *playlistfmt.Entry) (int, error) {
+	if id, ok := trackIDFromURL(e.Location); ok {
+		_, err := store.GetTrack(ctx, id)
+		if err == nil {
+			return id, nil
+		}
+
+		if !errors.Is(err, model.ErrNotFound) {
+			return 0, err
+		}
+	}
+
+	title := e.Title
+	if title == "" {
+		// Fall back to the file name without an extension, as players usually do
+		base := path.Base(strings.ReplaceAll(e.Location, `\`, "/"))
+		title = strings.TrimSuffix(base, path.Ext(base))
+	}
+
+	page, err := store.GetTracks(ctx, &model.TrackQuery{
+		Filter: model.TrackFilter{Title: title},
+		Sort:   []model.TrackOrder{{Field: model.TrackID}},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, t := range page.Tracks {
+		if strings.EqualFold(t.Attrs.Title, title) && durationMatches(t.Attrs.Duration, e.Duration) {
+			return t.ID, nil
+		}
+	}
+
+	track := model.Track{
+		Attrs: model.TrackAttrs{
+			Title: title,
+		},
+	}
+
+	if e.Duration > 0 {
+		ms := int(e.Duration.Milliseconds())
+		track.Attrs.Duration = &ms
+	}
+	if e.TrackNumber > 0 {
+		track.Attrs.TrackNumber = &e.TrackNumber
+	}
+
+	if err := track.Attrs.Validate(); err != nil {
+		return 0, err
+	}
+
+	created, err := store.CreateTrack(ctx, &track)
+	if err != nil {
+		return 0, err
+	}
+	return created.ID, nil
+}
+
+// durationMatches compares a track duration in milliseconds to a playlist entry duration.
+// Some formats only store whole seconds, so durations within a second of each other are considered equal.
+func durationMatches(trackDuration *int, entryDuration time.Duration) bool {
+	if trackDuration == nil || entryDuration == 0 {
+		return true
+	}
+
+	diff := time.Duration(*trackDuration)*time.Millisecond - entryDuration
+	return diff.Abs() < time.Second
+}
@@ -0,0 +1,358 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/cerfical/muzik/internal/log"
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/cerfical/muzik/internal/playlistfmt"
+)
+
+type playlistsHandler struct {
+	store model.Store
+	log   *log.Logger
+}
+
+func (h *playlistsHandler) get(w http.ResponseWriter, r *http.Request) {
+	playlist, ok := h.getPlaylist(w, r)
+	if !ok {
+		return
+	}
+
+	if exportsPlaylist(r) {
+		h.export(w, r, playlist)
+		return
+	}
+
+	encode(w, r, http.StatusOK, &document{
+		Data: playlist,
+	})
+}
+
+func (h *playlistsHandler) getAll(w http.ResponseWriter, r *http.Request) {
+	playlists, err := h.store.GetPlaylists(r.Context())
+	if err != nil {
+		internalError("Failed to read playlists data from persistent storage", err, h.log)(w, r)
+		return
+	}
+
+	encode(w, r, http.StatusOK, &document{
+		Data: playlists,
+	})
+}
+
+func (h *playlistsHandler) create(w http.ResponseWriter, r *http.Request) {
+	var newPlaylist playlistRequest
+	if !parseRequest(w, r, &newPlaylist, h.log) {
+		return
+	}
+
+	if newPlaylist.Data == nil {
+		badRequest("The request body must contain a playlist resource", "/data")(w, r)
+		return
+	}
+
+	playlist, err := h.store.CreatePlaylist(r.Context(), newPlaylist.Data)
+	if err != nil {
+		h.storeError(w, r, err)
+		return
+	}
+
+	location := r.URL.JoinPath(strconv.Itoa(playlist.ID))
+	w.Header().Set("Location", location.String())
+
+	encode(w, r, http.StatusCreated, &document{
+		Data: playlist,
+	})
+}
+
+func (h *playlistsHandler) update(w http.ResponseWriter, r *http.Request) {
+	playlist, ok := h.getPlaylist(w, r)
+	if !ok {
+		return
+	}
+	id := playlist.ID
+
+	patch := playlistRequest{Data: playlist}
+	if !parseRequest(w, r, &patch, h.log) {
+		return
+	}
+
+	if patch.Data == nil {
+		badRequest("The request body must contain a playlist resource", "/data")(w, r)
+		return
+	}
+
+	if patch.Data.ID != id {
+		conflict(fmt.Sprintf("The resource ID '%d' does not match the ID '%d' in the request path", patch.Data.ID, id))(w, r)
+		return
+	}
+
+	playlist, err := h.store.UpdatePlaylist(r.Context(), patch.Data)
+	if err != nil {
+		h.storeError(w, r, err)
+		return
+	}
+
+	encode(w, r, http.StatusOK, &document{
+		Data: playlist,
+	})
+}
+
+func (h *playlistsHandler) delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		notFound(w, r)
+		return
+	}
+
+	if err := h.store.DeletePlaylist(r.Context(), id); err != nil {
+		h.storeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// export renders the playlist as a playlist file.
+func (h *playlistsHandler) export(w http.ResponseWriter, r *http.Request, playlist *model.Playlist) {
+	tracks := make([]model.Track, len(playlist.Rels.TrackIDs))
+	cache := make(map[int]*model.Track)
+
+	for i, id := range playlist.Rels.TrackIDs {
+		track, ok := cache[id]
+		if !ok {
+			var err error
+			if track, err = h.store.GetTrack(r.Context(), id); err != nil {
+				internalError("Failed to read track data from persistent storage", err, h.log)(w, r)
+				return
+			}
+			cache[id] = track
+		}
+		tracks[i] = *track
+	}
+
+	if err := writePlaylist(w, r, h.store, playlist.Attrs.Name, tracks); err != nil {
+		internalError("Failed to export playlist", err, h.log)(w, r)
+	}
+}
+
+// importPlaylist creates a playlist from a playlist file, matching its entries to existing tracks or creating new ones.
+func (h *playlistsHandler) importPlaylist(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	format, ok := playlistfmt.ByMediaType(mediaType)
+	if !ok {
+		unsupportedMediaType(importMediaTypes)(w, r)
+		return
+	}
+
+	file, err := format.Decode(r.Body)
+	if err != nil {
+		badRequest(fmt.Sprintf("The request body is not a valid %s playlist: %v", format.Name, err), "")(w, r)
+		return
+	}
+
+	playlist := model.Playlist{
+		Attrs: model.PlaylistAttrs{
+			Name: file.Title,
+		},
+		Rels: model.PlaylistRels{
+			TrackIDs: make([]int, len(file.Entries)),
+		},
+	}
+
+	if playlist.Attrs.Name == "" {
+		playlist.Attrs.Name = defaultPlaylistName
+	}
+
+	// Tracks created for unmatched entries are only kept if the whole playlist is imported
+	var created *model.Playlist
+	err = h.store.RunInTx(r.Context(), func(tx model.TxStores) error {
+		for i := range file.Entries {
+			id, err := matchTrack(r.Context(), tx, &file.Entries[i])
+			if err != nil {
+				return &entryError{i, err}
+			}
+			playlist.Rels.TrackIDs[i] = id
+		}
+
+		var err error
+		created, err = tx.CreatePlaylist(r.Context(), &playlist)
+		return err
+	})
+
+	if err != nil {
+		if entryErr := (*entryError)(nil); errors.As(err, &entryErr) {
+			if validationErr := (model.ValidationError)(nil); errors.As(err, &validationErr) {
+				badRequest(fmt.Sprintf("Entry %d of the playlist cannot be imported: %s", entryErr.index+1, validationErr[0].Msg), "")(w, r)
+			} else {
+				internalError("Failed to match playlist entries to tracks", err, h.log)(w, r)
+			}
+		} else {
+			h.storeError(w, r, err)
+		}
+		return
+	}
+
+	location := r.URL.ResolveReference(&url.URL{Path: strconv.Itoa(created.ID)})
+	w.Header().Set("Location", location.String())
+
+	encode(w, r, http.StatusCreated, &document{
+		Data: created,
+	})
+}
+
+// entryError is an error matching an entry of an imported playlist to a track.
+type entryError struct {
+	index int
+	err   error
+}
+
+func (e *entryError) Error() string {
+	return fmt.Sprintf("entry %d: %v", e.index+1, e.err)
+}
+
+func (e *entryError) Unwrap() error {
+	return e.err
+}
+
+func (h *playlistsHandler) getTracks(w http.ResponseWriter, r *http.Request) {
+	playlist, ok := h.getPlaylist(w, r)
+	if !ok {
+		return
+	}
+	h.encodeTracks(w, r, playlist)
+}
+
+func (h *playlistsHandler) insertTracks(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		notFound(w, r)
+		return
+	}
+
+	var req insertTracksRequest
+	if !parseRequest(w, r, &req, h.log) {
+		return
+	}
+
+	trackIDs, ok := parseTrackIDs(w, r, req.Data)
+	if !ok {
+		return
+	}
+
+	index := -1
+	if req.Meta != nil && req.Meta.Position != nil {
+		if index = *req.Meta.Position; index < 0 {
+			badRequest("The insert position must not be negative", "/meta/position")(w, r)
+			return
+		}
+	}
+
+	playlist, err := h.store.InsertPlaylistTracks(r.Context(), id, index, trackIDs)
+	if err != nil {
+		h.storeError(w, r, err)
+		return
+	}
+	h.encodeTracks(w, r, playlist)
+}
+
+func (h *playlistsHandler) setTracks(w http.ResponseWriter, r *http.Request) {
+	h.editTracks(w, r, h.store.SetPlaylistTracks)
+}
+
+func (h *playlistsHandler) removeTracks(w http.ResponseWriter, r *http.Request) {
+	h.editTracks(w, r, h.store.RemovePlaylistTracks)
+}
+
+func (h *playlistsHandler) editTracks(w http.ResponseWriter, r *http.Request, edit func(ctx context.Context, id int, trackIDs []int) (*model.Playlist, error)) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		notFound(w, r)
+		return
+	}
+
+	var req relationshipRequest
+	if !parseRequest(w, r, &req, h.log) {
+		return
+	}
+
+	trackIDs, ok := parseTrackIDs(w, r, req.Data)
+	if !ok {
+		return
+	}
+
+	playlist, err := edit(r.Context(), id, trackIDs)
+	if err != nil {
+		h.storeError(w, r, err)
+		return
+	}
+	h.encodeTracks(w, r, playlist)
+}
+
+func (h *playlistsHandler) encodeTracks(w http.ResponseWriter, r *http.Request, playlist *model.Playlist) {
+	data := make([]model.ResourceID, len(playlist.Rels.TrackIDs))
+	for i, id := range playlist.Rels.TrackIDs {
+		data[i] = model.ResourceID{Type: model.TrackType, ID: id}
+	}
+
+	encode(w, r, http.StatusOK, &document{
+		Data: data,
+	})
+}
+
+func (h *playlistsHandler) getPlaylist(w http.ResponseWriter, r *http.Request) (*model.Playlist, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		notFound(w, r)
+		return nil, false
+	}
+
+	playlist, err := h.store.GetPlaylist(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			notFound(w, r)
+		} else {
+			internalError("Failed to read playlist data from persistent storage", err, h.log)(w, r)
+		}
+		return nil, false
+	}
+
+	return playlist, true
+}
+
+func (h *playlistsHandler) storeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, model.ErrNotFound):
+		notFound(w, r)
+	case errors.Is(err, model.ErrRelatedNotFound):
+		relatedNotFound(w, r)
+	default:
+		internalError("Failed to save playlist data to persistent storage", err, h.log)(w, r)
+	}
+}
+
+// parseTrackIDs extracts track IDs from the resource linkage of a request, reporting any problems to the client.
+func parseTrackIDs(w http.ResponseWriter, r *http.Request, data []model.ResourceID) ([]int, bool) {
+	if data == nil {
+		badRequest("The request body must contain an array of track identifiers", "/data")(w, r)
+		return nil, false
+	}
+
+	ids := make([]int, len(data))
+	for i, ref := range data {
+		if ref.Type != model.TrackType {
+			badRequest(fmt.Sprintf("Expected a resource of type '%s', got '%s'", model.TrackType, ref.Type), fmt.Sprintf("/data/%d/type", i))(w, r)
+			return nil, false
+		}
+		ids[i] = ref.ID
+	}
+
+	return ids, true
+}
@@ -0,0 +1,421 @@
+package api_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cerfical/muzik/internal/httpserv/api"
+	"github.com/cerfical/muzik/internal/mocks"
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/gavv/httpexpect/v2"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var samplePlaylists = []model.Playlist{
+	{
+		ID: 1,
+		Attrs: model.PlaylistAttrs{
+			Name:        "Example Playlist #1",
+			Description: "Tracks to test the API with",
+		},
+		Rels: model.PlaylistRels{
+			TrackIDs: []int{2, 1, 2},
+		},
+	},
+	{
+		ID: 2,
+		Attrs: model.PlaylistAttrs{
+			Name: "Example Playlist #2",
+		},
+		Rels: model.PlaylistRels{
+			TrackIDs: []int{},
+		},
+	},
+}
+
+func TestPlaylists(t *testing.T) {
+	suite.Run(t, new(PlaylistsTest))
+}
+
+type PlaylistsTest struct {
+	suite.Suite
+
+	store  *mocks.Store
+	expect *httpexpect.Expect
+}
+
+func (t *PlaylistsTest) SetupTest() {
+	t.store = mocks.NewStore(t.T())
+
+	// Run transactions directly against the mock
+	t.store.EXPECT().
+		RunInTx(mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, f func(model.TxStores) error) error {
+			return f(t.store)
+		}).
+		Maybe()
+	t.expect = httpexpect.WithConfig(httpexpect.Config{
+		TestName: t.T().Name(),
+		BaseURL:  "/api/playlists",
+		Reporter: httpexpect.NewAssertReporter(t.T()),
+		Client: &http.Client{
+			Transport: httpexpect.NewBinder(api.NewHandler(&api.Config{}, t.store, nil, nil)),
+		},
+	})
+}
+
+func (t *PlaylistsTest) TestPlaylists_Get_Ok() {
+	t.store.EXPECT().
+		GetPlaylist(mock.Anything, 1).
+		Return(&samplePlaylists[0], nil)
+
+	e := t.expect.GET("/1").
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.JSON(jsonAPIContent).Schema(playlistDataResponse()).Object().
+		Value("data").IsEqual(samplePlaylists[0])
+}
+
+func (t *PlaylistsTest) TestPlaylists_Get_NotFound() {
+	t.store.EXPECT().
+		GetPlaylist(mock.Anything, 3).
+		Return(nil, model.ErrNotFound)
+
+	e := t.expect.GET("/3").
+		Expect()
+
+	e.Status(http.StatusNotFound)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
+
+func (t *PlaylistsTest) TestPlaylists_GetAll_Ok() {
+	t.store.EXPECT().
+		GetPlaylists(mock.Anything).
+		Return(samplePlaylists, nil)
+
+	e := t.expect.GET("/").
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.JSON(jsonAPIContent).Schema(playlistsDataResponse()).Object().
+		Value("data").IsEqual(samplePlaylists)
+}
+
+func (t *PlaylistsTest) TestPlaylists_Create_Ok() {
+	t.store.EXPECT().
+		CreatePlaylist(mock.Anything, &model.Playlist{Attrs: samplePlaylists[0].Attrs, Rels: samplePlaylists[0].Rels}).
+		Return(&samplePlaylists[0], nil)
+
+	e := t.expect.POST("/").
+		WithJSON(map[string]any{
+			"data": map[string]any{
+				"attributes":    samplePlaylists[0].Attrs,
+				"relationships": samplePlaylists[0].Rels,
+			},
+		}).
+		Expect()
+
+	e.Status(http.StatusCreated).
+		Header("Location").IsEqual("/api/playlists/1")
+	e.JSON(jsonAPIContent).Schema(playlistDataResponse())
+}
+
+func (t *PlaylistsTest) TestPlaylists_Create_RelatedNotFound() {
+	t.store.EXPECT().
+		CreatePlaylist(mock.Anything, mock.Anything).
+		Return(nil, model.ErrRelatedNotFound)
+
+	e := t.expect.POST("/").
+		WithJSON(map[string]any{
+			"data": map[string]any{
+				"attributes":    samplePlaylists[0].Attrs,
+				"relationships": samplePlaylists[0].Rels,
+			},
+		}).
+		Expect()
+
+	e.Status(http.StatusNotFound)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
+
+func (t *PlaylistsTest) TestPlaylists_Update_KeepsOmittedFields() {
+	want := samplePlaylists[0]
+	want.Attrs.Name = "New Name"
+
+	t.store.EXPECT().
+		GetPlaylist(mock.Anything, 1).
+		Return(&model.Playlist{ID: 1, Attrs: samplePlaylists[0].Attrs, Rels: samplePlaylists[0].Rels}, nil)
+	t.store.EXPECT().
+		UpdatePlaylist(mock.Anything, &want).
+		Return(&want, nil)
+
+	e := t.expect.PATCH("/1").
+		WithJSON(map[string]any{
+			"data": map[string]any{"id": "1", "attributes": map[string]any{"name": "New Name"}},
+		}).
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.JSON(jsonAPIContent).Schema(playlistDataResponse()).Object().
+		Value("data").IsEqual(want)
+}
+
+func (t *PlaylistsTest) TestPlaylists_Delete_Ok() {
+	t.store.EXPECT().
+		DeletePlaylist(mock.Anything, 1).
+		Return(nil)
+
+	e := t.expect.DELETE("/1").
+		Expect()
+
+	e.Status(http.StatusNoContent)
+	e.Body().IsEmpty()
+}
+
+func (t *PlaylistsTest) TestPlaylists_Get_Export() {
+	e := t.store.EXPECT()
+	e.GetPlaylist(mock.Anything, 1).
+		Return(&samplePlaylists[0], nil)
+	e.GetTrack(mock.Anything, 1).
+		Return(&sampleTracks[0], nil).
+		Once()
+	e.GetTrack(mock.Anything, 2).
+		Return(&sampleTracks[1], nil).
+		Once()
+	e.GetArtists(mock.Anything).
+		Return([]model.Artist{}, nil)
+	e.GetAlbums(mock.Anything).
+		Return([]model.Album{}, nil)
+
+	r := t.expect.GET("/1").
+		WithHeader("Accept", "audio/x-mpegurl, application/vnd.apple.mpegurl").
+		Expect()
+
+	r.Status(http.StatusOK).
+		Header("Content-Type").IsEqual("application/vnd.apple.mpegurl")
+	r.Body().IsEqual(strings.Join([]string{
+		"#EXTM3U",
+		"#PLAYLIST:Example Playlist #1",
+		"#EXTINF:-1,Example Track #2",
+		"/api/tracks/2",
+		"#EXTINF:215,Example Track #1",
+		"/api/tracks/1",
+		"#EXTINF:-1,Example Track #2",
+		"/api/tracks/2",
+		"",
+	}, "\n"))
+}
+
+func (t *PlaylistsTest) TestPlaylists_Import_Ok() {
+	e := t.store.EXPECT()
+
+	// Matched by URL
+	e.GetTrack(mock.Anything, 1).
+		Return(&sampleTracks[0], nil)
+
+	// Matched by title, with the track of a different duration skipped
+	e.GetTracks(mock.Anything, &model.TrackQuery{
+		Filter: model.TrackFilter{Title: "example track #2"},
+		Sort:   []model.TrackOrder{{Field: model.TrackID}},
+	}).Return(&model.TrackPage{Tracks: []model.Track{
+		{ID: 3, Attrs: model.TrackAttrs{Title: "Example Track #2", Duration: ptr(1000)}},
+		sampleTracks[1],
+	}}, nil)
+
+	// Created from the file name
+	e.GetTracks(mock.Anything, mock.Anything).
+		Return(&model.TrackPage{Tracks: []model.Track{}}, nil)
+	e.CreateTrack(mock.Anything, &model.Track{Attrs: model.TrackAttrs{Title: "new", Duration: ptr(3000)}}).
+		Return(&model.Track{ID: 4}, nil)
+
+	e.CreatePlaylist(mock.Anything, &model.Playlist{
+		Attrs: model.PlaylistAttrs{Name: "Imported playlist"},
+		Rels:  model.PlaylistRels{TrackIDs: []int{1, 2, 4}},
+	}).Return(&model.Playlist{ID: 5, Attrs: model.PlaylistAttrs{Name: "Imported playlist"}, Rels: model.PlaylistRels{TrackIDs: []int{1, 2, 4}}}, nil)
+
+	r := t.expect.POST("/import").
+		WithHeader("Content-Type", "audio/x-scpls").
+		WithText(strings.Join([]string{
+			"[playlist]",
+			"File1=http://example.com/api/tracks/1",
+			"File2=/music/track2.mp3",
+			"Title2=example track #2",
+			"Length2=120",
+			"File3=C:\\Music\\new.mp3",
+			"Length3=3",
+		}, "\n")).
+		Expect()
+
+	r.Status(http.StatusCreated).
+		Header("Location").IsEqual("/api/playlists/5")
+	r.JSON(jsonAPIContent).Schema(playlistDataResponse())
+}
+
+func (t *PlaylistsTest) TestPlaylists_Import_BadRequest() {
+	e := t.expect.POST("/import").
+		WithHeader("Content-Type", "application/xspf+xml").
+		WithText("<playlist>").
+		Expect()
+
+	e.Status(http.StatusBadRequest)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
+
+func (t *PlaylistsTest) TestPlaylists_Import_UnsupportedMediaType() {
+	tests := []struct {
+		name        string
+		contentType string
+	}{
+		{"json", "application/json"},
+		{"missing", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func() {
+			e := t.expect.POST("/import").
+				WithHeader("Content-Type", test.contentType).
+				WithText("#EXTM3U").
+				Expect()
+
+			e.Status(http.StatusUnsupportedMediaType).
+				Header("Accept-Post").Contains("audio/x-scpls")
+			e.JSON(jsonAPIContent).Schema(errorResponse())
+		})
+	}
+}
+
+func (t *PlaylistsTest) TestPlaylists_GetTracks_Ok() {
+	t.store.EXPECT().
+		GetPlaylist(mock.Anything, 1).
+		Return(&samplePlaylists[0], nil)
+
+	e := t.expect.GET("/1/relationships/tracks").
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.JSON(jsonAPIContent).Schema(playlistTracksDataResponse()).Object().
+		Value("data").IsEqual(trackIdentifiers(2, 1, 2))
+}
+
+func (t *PlaylistsTest) TestPlaylists_InsertTracks_Ok() {
+	tests := []struct {
+		name  string
+		meta  map[string]any
+		index int
+	}{
+		{"append", nil, -1},
+		{"at_position", map[string]any{"position": 1}, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func() {
+			t.store.EXPECT().
+				InsertPlaylistTracks(mock.Anything, 1, test.index, []int{3, 3}).
+				Return(&samplePlaylists[0], nil).
+				Once()
+
+			body := map[string]any{"data": trackIdentifiers(3, 3)}
+			if test.meta != nil {
+				body["meta"] = test.meta
+			}
+
+			e := t.expect.POST("/1/relationships/tracks").
+				WithJSON(body).
+				Expect()
+
+			e.Status(http.StatusOK)
+			e.JSON(jsonAPIContent).Schema(playlistTracksDataResponse())
+		})
+	}
+}
+
+func (t *PlaylistsTest) TestPlaylists_InsertTracks_BadPosition() {
+	e := t.expect.POST("/1/relationships/tracks").
+		WithJSON(map[string]any{
+			"data": trackIdentifiers(3),
+			"meta": map[string]any{"position": -1},
+		}).
+		Expect()
+
+	e.Status(http.StatusBadRequest)
+	e.JSON(jsonAPIContent).Schema(errorResponse()).Object().
+		Value("errors").Array().Value(0).Object().
+		Value("source").Object().Value("pointer").IsEqual("/meta/position")
+}
+
+func (t *PlaylistsTest) TestPlaylists_SetTracks_Ok() {
+	t.store.EXPECT().
+		SetPlaylistTracks(mock.Anything, 1, []int{2, 1, 2}).
+		Return(&samplePlaylists[0], nil)
+
+	e := t.expect.PATCH("/1/relationships/tracks").
+		WithJSON(map[string]any{"data": trackIdentifiers(2, 1, 2)}).
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.JSON(jsonAPIContent).Schema(playlistTracksDataResponse()).Object().
+		Value("data").IsEqual(trackIdentifiers(2, 1, 2))
+}
+
+func (t *PlaylistsTest) TestPlaylists_SetTracks_RelatedNotFound() {
+	t.store.EXPECT().
+		SetPlaylistTracks(mock.Anything, 1, []int{4}).
+		Return(nil, model.ErrRelatedNotFound)
+
+	e := t.expect.PATCH("/1/relationships/tracks").
+		WithJSON(map[string]any{"data": trackIdentifiers(4)}).
+		Expect()
+
+	e.Status(http.StatusNotFound)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
+
+func (t *PlaylistsTest) TestPlaylists_RemoveTracks_Ok() {
+	t.store.EXPECT().
+		RemovePlaylistTracks(mock.Anything, 1, []int{2}).
+		Return(&model.Playlist{ID: 1, Rels: model.PlaylistRels{TrackIDs: []int{1}}}, nil)
+
+	e := t.expect.DELETE("/1/relationships/tracks").
+		WithJSON(map[string]any{"data": trackIdentifiers(2)}).
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.JSON(jsonAPIContent).Schema(playlistTracksDataResponse()).Object().
+		Value("data").IsEqual(trackIdentifiers(1))
+}
+
+func (t *PlaylistsTest) TestPlaylists_EditTracks_BadRequest() {
+	tests := []struct {
+		name    string
+		body    map[string]any
+		pointer string
+	}{
+		{"no_data", map[string]any{}, "/data"},
+		{"wrong_type", map[string]any{"data": []any{map[string]any{"type": "albums", "id": "1"}}}, "/data/0/type"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func() {
+			e := t.expect.PATCH("/1/relationships/tracks").
+				WithJSON(test.body).
+				Expect()
+
+			e.Status(http.StatusBadRequest)
+			e.JSON(jsonAPIContent).Schema(errorResponse()).Object().
+				Value("errors").Array().Value(0).Object().
+				Value("source").Object().Value("pointer").IsEqual(test.pointer)
+		})
+	}
+}
+
+func trackIdentifiers(ids ...int) []model.ResourceID {
+	refs := make([]model.ResourceID, len(ids))
+	for i, id := range ids {
+		refs[i] = model.ResourceID{Type: model.TrackType, ID: id}
+	}
+	return refs
+}
@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cerfical/muzik/internal/model"
+)
+
+// trackETag makes a strong entity tag identifying the representation of a revision of a track selected by the request.
+//
+// Representations other than the default one, in another media type or with included resources or sparse fieldsets,
+// have the revision tagged with a hash of what sets them apart, as in "3-1a2b3c4d".
+func trackETag(r *http.Request, track *model.Track) string {
+	etag := strconv.Itoa(track.Revision)
+	if variant := representationVariant(r); variant != "" {
+		h := fnv.New32a()
+		h.Write([]byte(variant))
+		etag += "-" + strconv.FormatUint(uint64(h.Sum32()), 16)
+	}
+	return `"` + etag + `"`
+}
+
+// representationVariant describes how the representation selected by the request differs from the default one.
+func representationVariant(r *http.Request) string {
+	params := make(url.Values)
+	for param, vals := range r.URL.Query() {
+		if family, _, _ := strings.Cut(param, "["); family == includeParam || family == fieldsParam {
+			params[param] = vals
+		}
+	}
+
+	mediaType := responseType(r)
+	if mediaType == defaultCodec.mediaType && len(params) == 0 {
+		return ""
+	}
+	return mediaType + "?" + params.Encode()
+}
+
+// matchETag reports whether any of the entity tags listed in conditional headers matches etag.
+// Weak comparison ignores the weak indicator of the listed tags, while strong comparison never matches weak tags.
+func matchETag(headers []string, etag string, weak bool) bool {
+	for _, header := range headers {
+		for _, tag := range strings.Split(header, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" {
+				return true
+			}
+
+			if t, ok := strings.CutPrefix(tag, "W/"); ok {
+				if !weak {
+					continue
+				}
+				tag = t
+			}
+
+			if tag == etag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// notModified responds with 304 Not Modified if the client already has the current revision of the track.
+func notModified(w http.ResponseWriter, r *http.Request, track *model.Track) bool {
+	etag := trackETag(r, track)
+	w.Header().Set("ETag", etag)
+
+	if !matchETag(r.Header.Values("If-None-Match"), etag, true) {
+		return false
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// checkRevision checks a write of the track against its current revision if the request is conditional.
+func (h *tracksHandler) checkRevision(r *http.Request, tx model.TrackStore, id int) error {
+	return h.checkRevisionOf(r, id, tx.GetTrack)
+}
+
+// checkTrashedRevision checks a write of the track in the trash against its revision there if the request is conditional.
+func (h *tracksHandler) checkTrashedRevision(r *http.Request, tx model.TrackStore, id int) error {
+	return h.checkRevisionOf(r, id, tx.GetTrashedTrack)
+}
+
+// checkRevisionOf checks a write of the track looked up with get against its revision if the request is conditional.
+func (h *tracksHandler) checkRevisionOf(r *http.Request, id int, get func(context.Context, int) (*model.Track, error)) error {
+	if len(r.Header.Values("If-Match")) == 0 {
+		// Unconditional writes are rejected before looking the track up
+		if h.requireIfMatch {
+			return errPreconditionRequired
+		}
+		return nil
+	}
+
+	_, err := h.lookupTrack(r, id, get)
+	return err
+}
+
+// currentTrack looks up the track a write applies to and checks the request against its current revision.
+func (h *tracksHandler) currentTrack(r *http.Request, tx model.TrackStore, id int) (*model.Track, error) {
+	return h.lookupTrack(r, id, tx.GetTrack)
+}
+
+// lookupTrack looks up the track a write applies to with get and checks the request against its revision.
+func (h *tracksHandler) lookupTrack(r *http.Request, id int, get func(context.Context, int) (*model.Track, error)) (*model.Track, error) {
+	current, err := get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) && len(r.Header.Values("If-Match")) != 0 {
+			// A missing track has no revision for the precondition to match, not even "*"
+			return nil, errPreconditionFailed
+		}
+		return nil, err
+	}
+
+	if err := h.checkIfMatch(r, current); err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
+// checkIfMatch checks that the request is conditional on the current revision of the track.
+func (h *tracksHandler) checkIfMatch(r *http.Request, current *model.Track) error {
+	ifMatch := r.Header.Values("If-Match")
+	if len(ifMatch) == 0 {
+		if h.requireIfMatch {
+			return errPreconditionRequired
+		}
+		return nil
+	}
+
+	if !matchETag(ifMatch, trackETag(r, current), false) {
+		return errPreconditionFailed
+	}
+	return nil
+}
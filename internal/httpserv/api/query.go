@@ -0,0 +1,408 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cerfical/muzik/internal/dedupe"
+	"github.com/cerfical/muzik/internal/log"
+	"github.com/cerfical/muzik/internal/model"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+const (
+	pageSizeParam   = "page[size]"
+	pageNumberParam = "page[number]"
+	pageAfterParam  = "page[after]"
+	sortParam       = "sort"
+
+	filterTitleParam = "filter[title]"
+
+	filterResourceTypeParam = "filter[resourceType]"
+	filterResourceIDParam   = "filter[resourceId]"
+	filterSinceParam        = "filter[since]"
+	filterUntilParam        = "filter[until]"
+
+	filterSimilarityParam = "filter[similarity]"
+
+	searchTextParam = "q"
+	filterTypeParam = "filter[type]"
+
+	tagsParam = "tags"
+)
+
+// tagsMode selects what audio uploads do with the tags embedded in the files.
+type tagsMode string
+
+const (
+	// tagsFill fills in the attributes the track lacks, reporting the ones that differ in the response meta.
+	tagsFill tagsMode = "fill"
+
+	// tagsReject fills in the missing attributes like tagsFill, but rejects files with tags that differ from the attributes of the track.
+	tagsReject tagsMode = "reject"
+
+	tagsIgnore tagsMode = "ignore"
+)
+
+var trackFields = map[string]model.TrackField{
+	"id":    model.TrackID,
+	"title": model.TrackTitle,
+}
+
+// parseTrackQuery parses query parameters for selecting a page of the tracks collection.
+func parseTrackQuery(query url.Values) (*model.TrackQuery, *pageParams, error) {
+	page, err := parsePage(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	q := page.trackQuery()
+	if q.Sort, err = parseSort(query); err != nil {
+		return nil, nil, err
+	}
+
+	if err := parseTrackFilter(query, &q.Filter); err != nil {
+		return nil, nil, err
+	}
+
+	return q, page, nil
+}
+
+func parseSort(query url.Values) ([]model.TrackOrder, error) {
+	if !query.Has(sortParam) {
+		return nil, nil
+	}
+
+	var order []model.TrackOrder
+	seen := make(map[model.TrackField]bool)
+
+	for _, name := range strings.Split(query.Get(sortParam), ",") {
+		name, desc := strings.CutPrefix(name, "-")
+
+		field, ok := trackFields[name]
+		if !ok {
+			return nil, &queryError{sortParam, fmt.Sprintf("Tracks cannot be sorted by the field '%s'", name)}
+		}
+
+		if seen[field] {
+			return nil, &queryError{sortParam, fmt.Sprintf("The sort field '%s' is specified more than once", name)}
+		}
+		seen[field] = true
+
+		order = append(order, model.TrackOrder{Field: field, Desc: desc})
+	}
+
+	return order, nil
+}
+
+func parseTrackFilter(query url.Values, filter *model.TrackFilter) error {
+	for param := range query {
+		if !strings.HasPrefix(param, "filter[") {
+			continue
+		}
+
+		switch param {
+		case filterTitleParam:
+			filter.Title = query.Get(param)
+		default:
+			return &queryError{param, fmt.Sprintf("The filter parameter '%s' is not supported", param)}
+		}
+	}
+	return nil
+}
+
+// parseAuditQuery parses query parameters for selecting a page of the audit log.
+func parseAuditQuery(query url.Values) (*model.AuditQuery, *pageParams, error) {
+	page, err := parsePage(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if query.Has(sortParam) {
+		return nil, nil, &queryError{sortParam, "Audit entries are always sorted in the order they were made"}
+	}
+
+	q := model.AuditQuery{
+		Limit:  page.size,
+		Offset: (page.number - 1) * page.size,
+		After:  page.after,
+	}
+
+	for param := range query {
+		if !strings.HasPrefix(param, "filter[") {
+			continue
+		}
+
+		switch param {
+		case filterResourceTypeParam:
+			q.Resource.Type = query.Get(param)
+			if _, ok := relationshipTypes[q.Resource.Type]; !ok || q.Resource.Type == model.AuditEntryType || q.Resource.Type == model.DuplicateGroupType {
+				return nil, nil, &queryError{param, fmt.Sprintf("The resource type '%s' is not audited", q.Resource.Type)}
+			}
+		case filterResourceIDParam:
+			if err := parsePositiveInt(query, param, &q.Resource.ID); err != nil {
+				return nil, nil, err
+			}
+			if !query.Has(filterResourceTypeParam) {
+				return nil, nil, &queryError{param, fmt.Sprintf("The parameter requires '%s'", filterResourceTypeParam)}
+			}
+		case filterSinceParam:
+			if err := parseTime(query, param, &q.Since); err != nil {
+				return nil, nil, err
+			}
+		case filterUntilParam:
+			if err := parseTime(query, param, &q.Until); err != nil {
+				return nil, nil, err
+			}
+		default:
+			return nil, nil, &queryError{param, fmt.Sprintf("The filter parameter '%s' is not supported", param)}
+		}
+	}
+
+	return &q, page, nil
+}
+
+// parseDuplicatesQuery parses query parameters for selecting a page of duplicate groups, along with the minimum similarity of their tracks.
+func parseDuplicatesQuery(query url.Values) (float64, *pageParams, error) {
+	page, err := parsePage(query)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if query.Has(sortParam) {
+		return 0, nil, &queryError{sortParam, "Duplicate groups are always sorted by the IDs of their first tracks"}
+	}
+
+	similarity := dedupe.DefaultSimilarity
+	for param := range query {
+		if !strings.HasPrefix(param, "filter[") {
+			continue
+		}
+
+		if param != filterSimilarityParam {
+			return 0, nil, &queryError{param, fmt.Sprintf("The filter parameter '%s' is not supported", param)}
+		}
+
+		v, err := strconv.ParseFloat(query.Get(param), 64)
+		if err != nil || v <= 0 || v > 1 {
+			return 0, nil, &queryError{param, "The similarity must be a number greater than 0 and at most 1"}
+		}
+		similarity = v
+	}
+
+	return similarity, page, nil
+}
+
+// parseTagsMode parses query parameters for uploading audio files.
+func parseTagsMode(query url.Values) (tagsMode, error) {
+	if !query.Has(tagsParam) {
+		return tagsFill, nil
+	}
+
+	switch mode := tagsMode(query.Get(tagsParam)); mode {
+	case tagsFill, tagsReject, tagsIgnore:
+		return mode, nil
+	default:
+		return "", &queryError{tagsParam, fmt.Sprintf("The tags mode must be one of %s", quoteList([]string{string(tagsFill), string(tagsReject), string(tagsIgnore)}))}
+	}
+}
+
+// parseSearchQuery parses query parameters for searching the library.
+func parseSearchQuery(query url.Values) (*model.SearchQuery, *pageParams, error) {
+	page, err := parsePage(query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if query.Has(pageAfterParam) {
+		return nil, nil, &queryError{pageAfterParam, "Search results can only be paginated by page number"}
+	}
+
+	if query.Has(sortParam) {
+		return nil, nil, &queryError{sortParam, "Search results are always sorted by relevance"}
+	}
+
+	q := model.SearchQuery{
+		Text:   query.Get(searchTextParam),
+		Limit:  page.size,
+		Offset: (page.number - 1) * page.size,
+	}
+
+	if strings.TrimSpace(q.Text) == "" {
+		return nil, nil, &queryError{searchTextParam, "The search query must not be empty"}
+	}
+
+	for param := range query {
+		if !strings.HasPrefix(param, "filter[") {
+			continue
+		}
+
+		if param != filterTypeParam {
+			return nil, nil, &queryError{param, fmt.Sprintf("The filter parameter '%s' is not supported", param)}
+		}
+
+		for _, typ := range strings.Split(query.Get(param), ",") {
+			if !slices.Contains(model.SearchableTypes, typ) {
+				return nil, nil, &queryError{param, fmt.Sprintf("The resource type '%s' cannot be searched for", typ)}
+			}
+			q.Types = append(q.Types, typ)
+		}
+	}
+
+	return &q, page, nil
+}
+
+func parseTime(query url.Values, param string, t *time.Time) error {
+	v, err := time.Parse(time.RFC3339, query.Get(param))
+	if err != nil {
+		return &queryError{param, fmt.Sprintf("The parameter '%s' must be a date and time in RFC 3339 format", param)}
+	}
+
+	*t = v
+	return nil
+}
+
+// pageParams describes the page of a collection requested by the client.
+type pageParams struct {
+	size   int
+	number int
+	after  int
+}
+
+func parsePage(query url.Values) (*pageParams, error) {
+	for param := range query {
+		if !strings.HasPrefix(param, "page[") {
+			continue
+		}
+
+		switch param {
+		case pageSizeParam, pageNumberParam, pageAfterParam:
+		default:
+			return nil, &queryError{param, fmt.Sprintf("The pagination parameter '%s' is not supported", param)}
+		}
+	}
+
+	page := pageParams{size: defaultPageSize, number: 1}
+	if err := parsePositiveInt(query, pageSizeParam, &page.size); err != nil {
+		return nil, err
+	}
+
+	if page.size > maxPageSize {
+		return nil, &queryError{pageSizeParam, fmt.Sprintf("The page size must not exceed %d", maxPageSize)}
+	}
+
+	if err := parsePositiveInt(query, pageNumberParam, &page.number); err != nil {
+		return nil, err
+	}
+
+	if err := parsePositiveInt(query, pageAfterParam, &page.after); err != nil {
+		return nil, err
+	}
+
+	if query.Has(pageNumberParam) && query.Has(pageAfterParam) {
+		return nil, &queryError{pageAfterParam, fmt.Sprintf("The parameter cannot be combined with '%s'", pageNumberParam)}
+	}
+
+	return &page, nil
+}
+
+func parsePositiveInt(query url.Values, param string, v *int) error {
+	if !query.Has(param) {
+		return nil
+	}
+
+	n, err := strconv.Atoi(query.Get(param))
+	if err != nil || n <= 0 {
+		return &queryError{param, fmt.Sprintf("The parameter '%s' must be a positive integer", param)}
+	}
+
+	*v = n
+	return nil
+}
+
+func (p *pageParams) trackQuery() *model.TrackQuery {
+	return &model.TrackQuery{
+		Limit:  p.size,
+		Offset: (p.number - 1) * p.size,
+		After:  p.after,
+	}
+}
+
+// links builds links to navigate from the specified page to adjacent pages of the tracks collection.
+func (p *pageParams) links(u *url.URL, page *model.TrackPage) *documentLinks {
+	lastID := 0
+	if n := len(page.Tracks); n > 0 {
+		lastID = page.Tracks[n-1].ID
+	}
+	return p.collectionLinks(u, len(page.Tracks), lastID, page.Total)
+}
+
+// collectionLinks builds links to navigate from a page of a collection with the specified number of resources to adjacent pages.
+func (p *pageParams) collectionLinks(u *url.URL, count, lastID, total int) *documentLinks {
+	links := documentLinks{
+		Self:  u.String(),
+		First: pageLink(u, map[string]string{pageNumberParam: "", pageAfterParam: ""}),
+	}
+
+	if p.after > 0 {
+		// Cursor-based pagination can only move forward
+		if count == p.size {
+			links.Next = pageLink(u, map[string]string{pageAfterParam: strconv.Itoa(lastID)})
+		}
+		return &links
+	}
+
+	if p.number > 1 {
+		links.Prev = pageLink(u, map[string]string{pageNumberParam: strconv.Itoa(p.number - 1)})
+	}
+
+	if (p.number-1)*p.size+count < total {
+		links.Next = pageLink(u, map[string]string{pageNumberParam: strconv.Itoa(p.number + 1)})
+	}
+
+	return &links
+}
+
+// pageLink makes a copy of u with the specified query parameters replaced, or removed if set to an empty value.
+func pageLink(u *url.URL, params map[string]string) string {
+	query := u.Query()
+	for k, v := range params {
+		if v != "" {
+			query.Set(k, v)
+		} else {
+			query.Del(k)
+		}
+	}
+
+	link := *u
+	link.RawQuery = query.Encode()
+	return link.String()
+}
+
+// reportQueryError reports an error in the request query parameters to the client.
+func reportQueryError(w http.ResponseWriter, r *http.Request, err error, log *log.Logger) {
+	if queryErr := (*queryError)(nil); errors.As(err, &queryErr) {
+		invalidParameter(queryErr.param, queryErr.msg)(w, r)
+	} else {
+		internalError("Parsing of the request query was interrupted due to an unexpected error", err, log)(w, r)
+	}
+}
+
+type queryError struct {
+	param string
+	msg   string
+}
+
+func (e *queryError) Error() string {
+	return e.msg
+}
@@ -0,0 +1,217 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/cerfical/muzik/internal/log"
+	"github.com/cerfical/muzik/internal/model"
+)
+
+// resourceStore provides access to the stored resources of type R with attributes of type A.
+type resourceStore[R, A any] struct {
+	get    func(context.Context, int) (*R, error)
+	getAll func(context.Context) ([]R, error)
+	create func(context.Context, *A) (*R, error)
+	update func(context.Context, int, *A) (*R, error)
+	delete func(context.Context, int) error
+}
+
+// resourceKind describes how resources of type R with attributes of type A are identified and reported.
+type resourceKind[R, A any] struct {
+	// name is the singular name of the resource used in messages, and article is the article preceding it.
+	name    string
+	article string
+
+	// conflict is the detail reported when a resource cannot be deleted because tracks still refer to it.
+	conflict string
+
+	id    func(*R) int
+	attrs func(*R) *A
+}
+
+// resourceHandler serves a collection of resources that consist of attributes only, such as artists and albums.
+type resourceHandler[R, A any] struct {
+	kind  resourceKind[R, A]
+	store resourceStore[R, A]
+	log   *log.Logger
+}
+
+func newArtistsHandler(store model.Store, log *log.Logger) *resourceHandler[model.Artist, model.ArtistAttrs] {
+	return &resourceHandler[model.Artist, model.ArtistAttrs]{
+		kind: resourceKind[model.Artist, model.ArtistAttrs]{
+			name:     "artist",
+			article:  "an",
+			conflict: "The artist cannot be deleted while there are tracks by the artist, including ones in the trash",
+			id:       func(a *model.Artist) int { return a.ID },
+			attrs:    func(a *model.Artist) *model.ArtistAttrs { return &a.Attrs },
+		},
+		store: resourceStore[model.Artist, model.ArtistAttrs]{
+			get:    store.GetArtist,
+			getAll: store.GetArtists,
+			create: store.CreateArtist,
+			update: store.UpdateArtist,
+			delete: store.DeleteArtist,
+		},
+		log: log,
+	}
+}
+
+func newAlbumsHandler(store model.Store, log *log.Logger) *resourceHandler[model.Album, model.AlbumAttrs] {
+	return &resourceHandler[model.Album, model.AlbumAttrs]{
+		kind: resourceKind[model.Album, model.AlbumAttrs]{
+			name:     "album",
+			article:  "an",
+			conflict: "The album cannot be deleted while there are tracks on the album, including ones in the trash",
+			id:       func(a *model.Album) int { return a.ID },
+			attrs:    func(a *model.Album) *model.AlbumAttrs { return &a.Attrs },
+		},
+		store: resourceStore[model.Album, model.AlbumAttrs]{
+			get:    store.GetAlbum,
+			getAll: store.GetAlbums,
+			create: store.CreateAlbum,
+			update: store.UpdateAlbum,
+			delete: store.DeleteAlbum,
+		},
+		log: log,
+	}
+}
+
+func (h *resourceHandler[R, A]) get(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		notFound(w, r)
+		return
+	}
+
+	res, err := h.store.get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			notFound(w, r)
+			return
+		}
+		internalError(h.failed("read", "from"), err, h.log)(w, r)
+		return
+	}
+
+	encode(w, r, http.StatusOK, &document{
+		Data: res,
+	})
+}
+
+func (h *resourceHandler[R, A]) getAll(w http.ResponseWriter, r *http.Request) {
+	res, err := h.store.getAll(r.Context())
+	if err != nil {
+		internalError(fmt.Sprintf("Failed to read %ss data from persistent storage", h.kind.name), err, h.log)(w, r)
+		return
+	}
+
+	encode(w, r, http.StatusOK, &document{
+		Data: res,
+	})
+}
+
+func (h *resourceHandler[R, A]) create(w http.ResponseWriter, r *http.Request) {
+	var newRes resourceRequest[R]
+	if !parseRequest(w, r, &newRes, h.log) {
+		return
+	}
+
+	if newRes.Data == nil {
+		h.missingData(w, r)
+		return
+	}
+
+	res, err := h.store.create(r.Context(), h.kind.attrs(newRes.Data))
+	if err != nil {
+		internalError(h.failed("save", "to"), err, h.log)(w, r)
+		return
+	}
+
+	location := r.URL.JoinPath(strconv.Itoa(h.kind.id(res)))
+	w.Header().Set("Location", location.String())
+
+	encode(w, r, http.StatusCreated, &document{
+		Data: res,
+	})
+}
+
+func (h *resourceHandler[R, A]) update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		notFound(w, r)
+		return
+	}
+
+	res, err := h.store.get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			notFound(w, r)
+			return
+		}
+		internalError(h.failed("read", "from"), err, h.log)(w, r)
+		return
+	}
+
+	patch := resourceRequest[R]{Data: res}
+	if !parseRequest(w, r, &patch, h.log) {
+		return
+	}
+
+	if patch.Data == nil {
+		h.missingData(w, r)
+		return
+	}
+
+	if patchID := h.kind.id(patch.Data); patchID != id {
+		conflict(fmt.Sprintf("The resource ID '%d' does not match the ID '%d' in the request path", patchID, id))(w, r)
+		return
+	}
+
+	res, err = h.store.update(r.Context(), id, h.kind.attrs(patch.Data))
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			notFound(w, r)
+		} else {
+			internalError(h.failed("save", "to"), err, h.log)(w, r)
+		}
+		return
+	}
+
+	encode(w, r, http.StatusOK, &document{
+		Data: res,
+	})
+}
+
+func (h *resourceHandler[R, A]) delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		notFound(w, r)
+		return
+	}
+
+	if err := h.store.delete(r.Context(), id); err != nil {
+		switch {
+		case errors.Is(err, model.ErrNotFound):
+			notFound(w, r)
+		case errors.Is(err, model.ErrConflict):
+			conflict(h.kind.conflict)(w, r)
+		default:
+			internalError(h.failed("delete", "from"), err, h.log)(w, r)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *resourceHandler[R, A]) missingData(w http.ResponseWriter, r *http.Request) {
+	badRequest(fmt.Sprintf("The request body must contain %s %s resource", h.kind.article, h.kind.name), "/data")(w, r)
+}
+
+func (h *resourceHandler[R, A]) failed(op, prep string) string {
+	return fmt.Sprintf("Failed to %s %s data %s persistent storage", op, h.kind.name, prep)
+}
@@ -0,0 +1,291 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/cerfical/muzik/internal/httpserv/api"
+	"github.com/cerfical/muzik/internal/mocks"
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/gavv/httpexpect/v2"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+var sampleArtists = []model.Artist{
+	{
+		ID: 1,
+		Attrs: model.ArtistAttrs{
+			Name: "Example Artist #1",
+		},
+	},
+	{
+		ID: 2,
+		Attrs: model.ArtistAttrs{
+			Name: "Example Artist #2",
+		},
+	},
+}
+
+var sampleAlbums = []model.Album{
+	{
+		ID: 1,
+		Attrs: model.AlbumAttrs{
+			Title: "Example Album #1",
+		},
+	},
+	{
+		ID: 2,
+		Attrs: model.AlbumAttrs{
+			Title: "Example Album #2",
+		},
+	},
+}
+
+func TestResources(t *testing.T) {
+	suite.Run(t, new(ResourcesTest))
+}
+
+// ResourcesTest covers the handler shared by artists and albums.
+// Its behavior is exercised through albums, and artists are only checked for what differs.
+type ResourcesTest struct {
+	suite.Suite
+
+	store  *mocks.Store
+	expect *httpexpect.Expect
+}
+
+func (t *ResourcesTest) SetupTest() {
+	t.store = mocks.NewStore(t.T())
+	t.expect = httpexpect.WithConfig(httpexpect.Config{
+		TestName: t.T().Name(),
+		BaseURL:  "/api",
+		Reporter: httpexpect.NewAssertReporter(t.T()),
+		Client: &http.Client{
+			Transport: httpexpect.NewBinder(api.NewHandler(&api.Config{}, t.store, nil, nil)),
+		},
+	})
+}
+
+func (t *ResourcesTest) TestResources_Get_Ok() {
+	t.store.EXPECT().
+		GetAlbum(mock.Anything, 1).
+		Return(&sampleAlbums[0], nil)
+
+	e := t.expect.GET("/albums/1").
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.JSON(jsonAPIContent).Schema(albumDataResponse()).Object().
+		Value("data").IsEqual(sampleAlbums[0])
+}
+
+func (t *ResourcesTest) TestResources_Get_NotFound() {
+	t.store.EXPECT().
+		GetAlbum(mock.Anything, 3).
+		Return(nil, model.ErrNotFound)
+
+	e := t.expect.GET("/albums/3").
+		Expect()
+
+	e.Status(http.StatusNotFound)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
+
+func (t *ResourcesTest) TestResources_Get_InvalidID() {
+	e := t.expect.GET("/albums/abc").
+		Expect()
+
+	e.Status(http.StatusNotFound)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
+
+func (t *ResourcesTest) TestResources_GetAll_Ok() {
+	t.store.EXPECT().
+		GetAlbums(mock.Anything).
+		Return(sampleAlbums, nil)
+
+	e := t.expect.GET("/albums/").
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.JSON(jsonAPIContent).Schema(albumsDataResponse()).Object().
+		Value("data").IsEqual(sampleAlbums)
+}
+
+func (t *ResourcesTest) TestResources_Create_Ok() {
+	t.store.EXPECT().
+		CreateAlbum(mock.Anything, &sampleAlbums[0].Attrs).
+		Return(&sampleAlbums[0], nil)
+
+	e := t.expect.POST("/albums/").
+		WithJSON(map[string]any{
+			"data": map[string]any{"attributes": sampleAlbums[0].Attrs},
+		}).
+		Expect()
+
+	e.Status(http.StatusCreated).
+		Header("Location").IsEqual("/api/albums/1")
+	e.JSON(jsonAPIContent).Schema(albumDataResponse())
+}
+
+func (t *ResourcesTest) TestResources_Update_Ok() {
+	attrs := model.AlbumAttrs{Title: "New Title"}
+
+	t.store.EXPECT().
+		GetAlbum(mock.Anything, 1).
+		Return(&model.Album{ID: 1, Attrs: sampleAlbums[0].Attrs}, nil)
+	t.store.EXPECT().
+		UpdateAlbum(mock.Anything, 1, &attrs).
+		Return(&model.Album{ID: 1, Attrs: attrs}, nil)
+
+	e := t.expect.PATCH("/albums/1").
+		WithJSON(map[string]any{
+			"data": map[string]any{"id": "1", "attributes": attrs},
+		}).
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.JSON(jsonAPIContent).Schema(albumDataResponse())
+}
+
+func (t *ResourcesTest) TestResources_Update_IDMismatch() {
+	t.store.EXPECT().
+		GetAlbum(mock.Anything, 1).
+		Return(&model.Album{ID: 1, Attrs: sampleAlbums[0].Attrs}, nil)
+
+	e := t.expect.PATCH("/albums/1").
+		WithJSON(map[string]any{
+			"data": map[string]any{"id": "2", "attributes": sampleAlbums[0].Attrs},
+		}).
+		Expect()
+
+	e.Status(http.StatusConflict)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
+
+func (t *ResourcesTest) TestResources_Delete_Ok() {
+	t.store.EXPECT().
+		DeleteAlbum(mock.Anything, 1).
+		Return(nil)
+
+	e := t.expect.DELETE("/albums/1").
+		Expect()
+
+	e.Status(http.StatusNoContent)
+	e.Body().IsEmpty()
+}
+
+func (t *ResourcesTest) TestResources_Delete_NotFound() {
+	t.store.EXPECT().
+		DeleteAlbum(mock.Anything, 3).
+		Return(model.ErrNotFound)
+
+	e := t.expect.DELETE("/albums/3").
+		Expect()
+
+	e.Status(http.StatusNotFound)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
+
+func (t *ResourcesTest) TestResources_Artists() {
+	attrs := model.ArtistAttrs{Name: "New Name"}
+
+	t.store.EXPECT().
+		GetArtist(mock.Anything, 1).
+		Return(&sampleArtists[0], nil)
+	t.store.EXPECT().
+		GetArtists(mock.Anything).
+		Return(sampleArtists, nil)
+	t.store.EXPECT().
+		CreateArtist(mock.Anything, &sampleArtists[1].Attrs).
+		Return(&sampleArtists[1], nil)
+	t.store.EXPECT().
+		UpdateArtist(mock.Anything, 1, &attrs).
+		Return(&model.Artist{ID: 1, Attrs: attrs}, nil)
+	t.store.EXPECT().
+		DeleteArtist(mock.Anything, 2).
+		Return(nil)
+
+	t.expect.GET("/artists/1").
+		Expect().
+		Status(http.StatusOK).
+		JSON(jsonAPIContent).Schema(artistDataResponse()).Object().
+		Value("data").IsEqual(sampleArtists[0])
+
+	t.expect.GET("/artists/").
+		Expect().
+		Status(http.StatusOK).
+		JSON(jsonAPIContent).Schema(artistsDataResponse()).Object().
+		Value("data").IsEqual(sampleArtists)
+
+	t.expect.POST("/artists/").
+		WithJSON(map[string]any{
+			"data": map[string]any{"attributes": sampleArtists[1].Attrs},
+		}).
+		Expect().
+		Status(http.StatusCreated).
+		Header("Location").IsEqual("/api/artists/2")
+
+	t.expect.PATCH("/artists/1").
+		WithJSON(map[string]any{
+			"data": map[string]any{"id": "1", "attributes": attrs},
+		}).
+		Expect().
+		Status(http.StatusOK).
+		JSON(jsonAPIContent).Schema(artistDataResponse()).Object().
+		Value("data").Object().Value("attributes").IsEqual(attrs)
+
+	t.expect.DELETE("/artists/2").
+		Expect().
+		Status(http.StatusNoContent)
+}
+
+func (t *ResourcesTest) TestResources_Messages() {
+	tests := []struct {
+		name     string
+		path     string
+		delete   func(*mocks.Store)
+		missing  string
+		conflict string
+	}{
+		{
+			name: "artists",
+			path: "/artists/",
+			delete: func(s *mocks.Store) {
+				s.EXPECT().DeleteArtist(mock.Anything, 1).Return(model.ErrConflict)
+			},
+			missing:  "The request body must contain an artist resource",
+			conflict: "The artist cannot be deleted while there are tracks by the artist, including ones in the trash",
+		},
+		{
+			name: "albums",
+			path: "/albums/",
+			delete: func(s *mocks.Store) {
+				s.EXPECT().DeleteAlbum(mock.Anything, 1).Return(model.ErrConflict)
+			},
+			missing:  "The request body must contain an album resource",
+			conflict: "The album cannot be deleted while there are tracks on the album, including ones in the trash",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func() {
+			t.expect.POST(test.path).
+				WithJSON(map[string]any{"data": nil}).
+				Expect().
+				Status(http.StatusBadRequest).
+				JSON(jsonAPIContent).Schema(errorResponse()).Object().
+				Value("errors").Array().Value(0).Object().
+				Value("detail").IsEqual(test.missing)
+
+			test.delete(t.store)
+			t.expect.DELETE(test.path + "1").
+				Expect().
+				Status(http.StatusConflict).
+				JSON(jsonAPIContent).Schema(errorResponse()).Object().
+				Value("errors").Array().Value(0).Object().
+				Value("detail").IsEqual(test.conflict)
+		})
+	}
+}
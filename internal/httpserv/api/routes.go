@@ -3,25 +3,132 @@ package api
 import (
 	"net/http"
 
+	"github.com/cerfical/muzik/internal/blob"
 	"github.com/cerfical/muzik/internal/httpserv/router"
 	"github.com/cerfical/muzik/internal/log"
 	"github.com/cerfical/muzik/internal/model"
 )
 
-func setupRoutes(store model.TrackStore, log *log.Logger) http.Handler {
-	tracks := tracksHandler{store, log}
-	router := router.New().
+func setupRoutes(config *Config, store model.Store, blobs blob.Store, log *log.Logger) http.Handler {
+	tracks := tracksHandler{
+		store:          store,
+		blobs:          blobs,
+		log:            log,
+		requireIfMatch: config.RequireIfMatch,
+		maxAudioSize:   config.MaxAudioSize,
+		maxBodySize:    config.MaxBodySize,
+	}
+	artists := newArtistsHandler(store, log)
+	albums := newAlbumsHandler(store, log)
+	playlists := playlistsHandler{store, log}
+	audit := auditHandler{store, log}
+	search := searchHandler{store, log}
+
+	r := router.New()
+	docs := r.With(hasContentType(decodeMediaTypes...), accepts(encodeMediaTypes...))
+	docs.With(documentParams(model.TrackType, store, log)).
 		Routes("/api/tracks/{id}", []router.Endpoint{
 			{Method: "GET", Handler: tracks.get},
+			{Method: "PATCH", Handler: tracks.update},
+			{Method: "PUT", Handler: tracks.replace},
 			{Method: "DELETE", Handler: tracks.delete},
 		}).
+		Routes("/api/tracks/{id}/restore", []router.Endpoint{
+			{Method: "POST", Handler: tracks.restore},
+		}).
+		Routes("/api/tracks/{id}/merge", []router.Endpoint{
+			{Method: "POST", Handler: tracks.merge},
+		}).
 		Routes("/api/tracks/", []router.Endpoint{
 			{Method: "POST", Handler: tracks.create},
-			{Method: "GET", Handler: tracks.getAll},
+			{Method: "DELETE", Handler: tracks.deleteAll},
+		}).
+		Routes("/api/trash/tracks", []router.Endpoint{
+			{Method: "GET", Handler: tracks.getTrash},
+		})
+
+	docs.With(documentParams(model.ArtistType, store, log)).
+		Routes("/api/artists/{id}", []router.Endpoint{
+			{Method: "GET", Handler: artists.get},
+			{Method: "PATCH", Handler: artists.update},
+			{Method: "DELETE", Handler: artists.delete},
+		}).
+		Routes("/api/artists/", []router.Endpoint{
+			{Method: "POST", Handler: artists.create},
+			{Method: "GET", Handler: artists.getAll},
+		})
+
+	docs.With(documentParams(model.AlbumType, store, log)).
+		Routes("/api/albums/{id}", []router.Endpoint{
+			{Method: "GET", Handler: albums.get},
+			{Method: "PATCH", Handler: albums.update},
+			{Method: "DELETE", Handler: albums.delete},
+		}).
+		Routes("/api/albums/", []router.Endpoint{
+			{Method: "POST", Handler: albums.create},
+			{Method: "GET", Handler: albums.getAll},
+		})
+
+	// Relationship endpoints respond with resource linkage, to which no related resources can be added
+	docs.With(documentParams("", store, log)).
+		Routes("/api/playlists/{id}/relationships/tracks", []router.Endpoint{
+			{Method: "GET", Handler: playlists.getTracks},
+			{Method: "POST", Handler: playlists.insertTracks},
+			{Method: "PATCH", Handler: playlists.setTracks},
+			{Method: "DELETE", Handler: playlists.removeTracks},
+		})
+
+	docs.With(documentParams(model.PlaylistType, store, log)).
+		Routes("/api/playlists/{id}", []router.Endpoint{
+			{Method: "PATCH", Handler: playlists.update},
+			{Method: "DELETE", Handler: playlists.delete},
 		}).
-		Use(hasContentType(encodeMediaType)).
-		Use(accepts(encodeMediaType)).
-		Use(panicRecover(log))
+		Routes("/api/playlists/", []router.Endpoint{
+			{Method: "POST", Handler: playlists.create},
+			{Method: "GET", Handler: playlists.getAll},
+		})
+
+	docs.With(documentParams(model.AuditEntryType, store, log)).
+		Routes("/api/audit", []router.Endpoint{
+			{Method: "GET", Handler: audit.getAll},
+		})
+
+	docs.With(documentParams(model.DuplicateGroupType, store, log)).
+		Routes("/api/tracks/duplicates", []router.Endpoint{
+			{Method: "GET", Handler: tracks.getDuplicates},
+		})
+
+	// Search results mix resources of different types, so related resources cannot be included
+	docs.With(documentParams("", store, log, searchTextParam)).
+		Routes("/api/search", []router.Endpoint{
+			{Method: "GET", Handler: search.search},
+		})
+
+	// Track collections can also be exported to and imported from playlist files
+	exports := r.With(accepts(exportMediaTypes...))
+	exports.With(documentParams(model.TrackType, store, log)).
+		Routes("/api/tracks/", []router.Endpoint{
+			{Method: "GET", Handler: tracks.getAll},
+		})
+	exports.With(documentParams(model.PlaylistType, store, log)).
+		Routes("/api/playlists/{id}", []router.Endpoint{
+			{Method: "GET", Handler: playlists.get},
+		})
+
+	r.With(hasContentType(importMediaTypes...), accepts(encodeMediaTypes...), documentParams(model.PlaylistType, store, log)).
+		Routes("/api/playlists/import", []router.Endpoint{
+			{Method: "POST", Handler: playlists.importPlaylist},
+		})
+
+	r.With(hasContentType(audioMediaTypes...), accepts(encodeMediaTypes...), documentParams(model.TrackType, store, log, tagsParam)).
+		Routes("/api/tracks/{id}/audio", []router.Endpoint{
+			{Method: "PUT", Handler: tracks.uploadAudio},
+		})
+
+	// Audio is streamed as is, whatever the client accepts, since players rarely ask for specific media types
+	r.Routes("/api/tracks/{id}/stream", []router.Endpoint{
+		{Method: "GET", Handler: tracks.stream},
+	})
 
-	return router
+	return r.Use(auditOrigin(config.ActorHeader)).Use(panicRecover(log))
 }
@@ -22,24 +22,24 @@ func TestRoutes(t *testing.T) {
 type RoutesTest struct {
 	suite.Suite
 
-	store  *mocks.TrackStore
+	store  *mocks.Store
 	expect *httpexpect.Expect
 }
 
 func (t *RoutesTest) SetupSubTest() {
-	t.store = mocks.NewTrackStore(t.T())
+	t.store = mocks.NewStore(t.T())
 	t.expect = httpexpect.WithConfig(httpexpect.Config{
 		TestName: t.T().Name(),
 		Reporter: httpexpect.NewAssertReporter(t.T()),
 		BaseURL:  "/api/tracks/",
 		Client: &http.Client{
-			Transport: httpexpect.NewBinder(api.NewHandler(t.store, nil)),
+			Transport: httpexpect.NewBinder(api.NewHandler(&api.Config{}, t.store, nil, nil)),
 		},
 	})
 
 	e := t.store.EXPECT()
-	e.GetTracks(mock.Anything).
-		Return([]model.Track{}, nil).
+	e.GetTracks(mock.Anything, mock.Anything).
+		Return(&model.TrackPage{Tracks: []model.Track{}}, nil).
 		Maybe()
 	e.GetTrack(mock.Anything, mock.Anything).
 		Return(&model.Track{}, nil).
@@ -47,7 +47,10 @@ func (t *RoutesTest) SetupSubTest() {
 	e.DeleteTrack(mock.Anything, mock.Anything).
 		Return(nil).
 		Maybe()
-	e.CreateTrack(mock.Anything, &model.TrackAttrs{}).
+	e.CreateTrack(mock.Anything, &model.Track{}).
+		Return(&model.Track{}, nil).
+		Maybe()
+	e.UpdateTrack(mock.Anything, mock.Anything).
 		Return(&model.Track{}, nil).
 		Maybe()
 }
@@ -59,6 +62,8 @@ func (t *RoutesTest) TestContentTypeCheck_Ok() {
 	}{
 		{"json", "application/json"},
 		{"json_with_utf8", "application/json;charset=utf-8"},
+		{"jsonapi", "application/vnd.api+json"},
+		{"jsonapi_with_profile", `application/vnd.api+json;profile="https://example.com/profile"`},
 	}
 
 	for _, test := range tests {
@@ -80,6 +85,8 @@ func (t *RoutesTest) TestContentTypeCheck_Fail() {
 		{"json_with_invalid_charset", "application/json;charset=utf8"},
 		{"json_with_unknown_param", "application/json;q=1"},
 		{"json_with_invalid_param", "application/json;q"},
+		{"jsonapi_with_charset", "application/vnd.api+json;charset=utf-8"},
+		{"jsonapi_with_ext", `application/vnd.api+json;ext="https://example.com/ext"`},
 		{"unknown_type", "application/xml"},
 	}
 
@@ -90,8 +97,8 @@ func (t *RoutesTest) TestContentTypeCheck_Fail() {
 				Expect()
 
 			e.Status(http.StatusUnsupportedMediaType).
-				Header("Accept-Post").IsEqual("application/json")
-			e.JSON().Schema(errorResponse())
+				Header("Accept-Post").IsEqual("application/vnd.api+json, application/json, application/msgpack, application/yaml")
+			e.JSON(jsonAPIContent).Schema(errorResponse())
 		})
 	}
 }
@@ -104,6 +111,8 @@ func (t *RoutesTest) TestAcceptHeaderCheck_Ok() {
 		{"json", "application/json"},
 		{"any_type", "*/*"},
 		{"nonzero_qvalue", "application/json;q=1"},
+		{"jsonapi_with_profile", `application/vnd.api+json;profile="https://example.com/profile"`},
+		{"jsonapi_once_unmodified", `application/vnd.api+json;ext="https://example.com/ext", application/vnd.api+json`},
 	}
 
 	for _, test := range tests {
@@ -128,6 +137,7 @@ func (t *RoutesTest) TestAcceptHeaderCheck_Fail() {
 		{"invalid_qvalue", "application/json;q=str"},
 		{"unknown_param", "application/json;p=1"},
 		{"invalid_param", "application/json;p"},
+		{"jsonapi_only_with_ext", `application/vnd.api+json;ext="https://example.com/ext", */*`},
 	}
 
 	for _, test := range tests {
@@ -137,7 +147,7 @@ func (t *RoutesTest) TestAcceptHeaderCheck_Fail() {
 				Expect()
 
 			e.Status(http.StatusNotAcceptable)
-			e.JSON().Schema(errorResponse())
+			e.JSON(jsonAPIContent).Schema(errorResponse())
 		})
 	}
 }
@@ -146,10 +156,41 @@ func trackDataResponse() string {
 	return schema("TrackDataResponse")
 }
 
+// jsonAPIContent expects response bodies to be JSON:API documents.
+var jsonAPIContent = httpexpect.ContentOpts{MediaType: "application/vnd.api+json"}
+
 func tracksDataResponse() string {
 	return schema("TracksDataResponse")
 }
 
+func artistDataResponse() string {
+	return schema("ArtistDataResponse")
+}
+
+func artistsDataResponse() string {
+	return schema("ArtistsDataResponse")
+}
+
+func albumDataResponse() string {
+	return schema("AlbumDataResponse")
+}
+
+func albumsDataResponse() string {
+	return schema("AlbumsDataResponse")
+}
+
+func playlistDataResponse() string {
+	return schema("PlaylistDataResponse")
+}
+
+func playlistsDataResponse() string {
+	return schema("PlaylistsDataResponse")
+}
+
+func playlistTracksDataResponse() string {
+	return schema("PlaylistTracksDataResponse")
+}
+
 func errorResponse() string {
 	return schema("ErrorResponse")
 }
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/cerfical/muzik/internal/log"
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/cerfical/muzik/internal/search"
+)
+
+type searchHandler struct {
+	store model.SearchStore
+	log   *log.Logger
+}
+
+// searchMeta describes how a resource matches a search query.
+type searchMeta struct {
+	Score float64 `json:"score"`
+
+	// Highlights maps the names of matching attributes to snippets of their values, with matches surrounded by <mark> tags.
+	Highlights map[string]string `json:"highlights"`
+}
+
+func (h *searchHandler) search(w http.ResponseWriter, r *http.Request) {
+	query, params, err := parseSearchQuery(r.URL.Query())
+	if err != nil {
+		reportQueryError(w, r, err, h.log)
+		return
+	}
+
+	page, err := h.store.Search(r.Context(), query)
+	if err != nil {
+		internalError("Failed to search persistent storage", err, h.log)(w, r)
+		return
+	}
+
+	terms := search.Terms(query.Text)
+	data := make([]any, len(page.Hits))
+	for i, hit := range page.Hits {
+		res, err := toOrdered(hit.Resource)
+		if err != nil {
+			internalError("Failed to encode search results", err, h.log)(w, r)
+			return
+		}
+
+		meta, err := toOrdered(&searchMeta{
+			Score:      hit.Score,
+			Highlights: highlights(hit.Resource, terms),
+		})
+		if err != nil {
+			internalError("Failed to encode search results", err, h.log)(w, r)
+			return
+		}
+
+		data[i] = addMeta(res.(orderedObject), meta.(orderedObject))
+	}
+
+	encode(w, r, http.StatusOK, &document{
+		Links: params.collectionLinks(r.URL, len(page.Hits), 0, page.Total),
+		Meta:  &collectionMeta{Total: page.Total},
+		Data:  data,
+	})
+}
+
+// addMeta adds members to the meta object of a resource, keeping the ones it already has, such as the audio of a track.
+func addMeta(res orderedObject, members orderedObject) orderedObject {
+	meta, _ := res.get("meta")
+	merged, _ := meta.(orderedObject)
+	for _, m := range members {
+		merged = merged.set(m.key, m.val)
+	}
+	return res.set("meta", merged)
+}
+
+// highlights makes snippets of the searchable attributes of a resource that match the terms.
+func highlights(res any, terms []string) map[string]string {
+	var attrs map[string]string
+	switch res := res.(type) {
+	case *model.Track:
+		attrs = map[string]string{
+			"title":   res.Attrs.Title,
+			"genres":  strings.Join(res.Attrs.Genres, ", "),
+			"comment": res.Attrs.Comment,
+		}
+	case *model.Artist:
+		attrs = map[string]string{"name": res.Attrs.Name}
+	case *model.Album:
+		attrs = map[string]string{"title": res.Attrs.Title}
+	}
+
+	snippets := make(map[string]string)
+	for name, text := range attrs {
+		if snippet, ok := search.Highlight(text, terms); ok {
+			snippets[name] = snippet
+		}
+	}
+	return snippets
+}
@@ -0,0 +1,206 @@
+package api_test
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/cerfical/muzik/internal/httpserv/api"
+	"github.com/cerfical/muzik/internal/memstore"
+	"github.com/cerfical/muzik/internal/mocks"
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/gavv/httpexpect/v2"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSearch(t *testing.T) {
+	suite.Run(t, new(SearchTest))
+}
+
+type SearchTest struct {
+	suite.Suite
+
+	store  *mocks.Store
+	expect *httpexpect.Expect
+}
+
+func (t *SearchTest) SetupTest() {
+	t.store = mocks.NewStore(t.T())
+	t.serve(t.store)
+}
+
+func (t *SearchTest) serve(store model.Store) {
+	t.expect = httpexpect.WithConfig(httpexpect.Config{
+		TestName: t.T().Name(),
+		BaseURL:  "/api",
+		Reporter: httpexpect.NewAssertReporter(t.T()),
+		Client: &http.Client{
+			Transport: httpexpect.NewBinder(api.NewHandler(&api.Config{}, store, nil, nil)),
+		},
+	})
+}
+
+func (t *SearchTest) TestSearch_Ok() {
+	t.store.EXPECT().
+		Search(mock.Anything, &model.SearchQuery{Text: "caf", Limit: 20}).
+		Return(&model.SearchPage{
+			Hits: []model.SearchHit{
+				{Resource: &model.Track{
+					ID:    1,
+					Attrs: model.TrackAttrs{Title: "Café del Mar", Comment: "At the café"},
+					Meta:  &model.TrackMeta{Audio: &model.Audio{ContentType: "audio/flac", Size: 10, SHA256: strings.Repeat("ab", 32)}},
+				}, Score: 1},
+				{Resource: &model.Artist{ID: 2, Attrs: model.ArtistAttrs{Name: "Cafe Quartet"}}, Score: 1},
+				{Resource: &model.Album{ID: 3, Attrs: model.AlbumAttrs{Title: "Café <Live>"}}, Score: 0.5},
+			},
+			Total: 3,
+		}, nil)
+
+	e := t.expect.GET("/search").
+		WithQuery("q", "caf").
+		Expect()
+
+	e.Status(http.StatusOK)
+
+	response := e.JSON(jsonAPIContent).Schema(schema("SearchResultsDataResponse")).Object()
+	response.Value("meta").Object().Value("total").IsEqual(3)
+	response.Value("links").Object().NotContainsKey("next")
+
+	data := response.Value("data").Array()
+	data.Length().IsEqual(3)
+
+	track := data.Value(0).Object()
+	track.Value("type").IsEqual("tracks")
+	track.Value("meta").Object().Value("highlights").IsEqual(map[string]any{
+		"title":   "<mark>Café</mark> del Mar",
+		"comment": "At the <mark>café</mark>",
+	})
+
+	// Search results keep the meta of the resources
+	track.Value("meta").Object().Value("audio").Object().Value("contentType").IsEqual("audio/flac")
+
+	data.Value(1).Object().Value("type").IsEqual("artists")
+	data.Value(1).Object().Value("meta").Object().Value("highlights").IsEqual(map[string]any{
+		"name": "<mark>Cafe</mark> Quartet",
+	})
+
+	album := data.Value(2).Object()
+	album.Value("type").IsEqual("albums")
+	album.Value("meta").Object().Value("score").IsEqual(0.5)
+	album.Value("meta").Object().Value("highlights").IsEqual(map[string]any{
+		"title": "<mark>Café</mark> &lt;Live&gt;",
+	})
+}
+
+func (t *SearchTest) TestSearch_Paginated() {
+	t.store.EXPECT().
+		Search(mock.Anything, &model.SearchQuery{
+			Text:   "rock",
+			Types:  []string{model.TrackType, model.AlbumType},
+			Limit:  1,
+			Offset: 1,
+		}).
+		Return(&model.SearchPage{
+			Hits: []model.SearchHit{
+				{Resource: &model.Album{ID: 1, Attrs: model.AlbumAttrs{Title: "Rock"}}, Score: 1},
+			},
+			Total: 3,
+		}, nil)
+
+	e := t.expect.GET("/search").
+		WithQuery("q", "rock").
+		WithQuery("filter[type]", "tracks,albums").
+		WithQuery("page[size]", 1).
+		WithQuery("page[number]", 2).
+		Expect()
+
+	e.Status(http.StatusOK)
+
+	links := e.JSON(jsonAPIContent).Schema(schema("SearchResultsDataResponse")).Object().
+		Value("links").Object()
+	links.ContainsKey("prev")
+	links.ContainsKey("next")
+}
+
+func (t *SearchTest) TestSearch_BadQuery() {
+	tests := []struct {
+		name  string
+		param string
+		value string
+	}{
+		{"empty_text", "q", " "},
+		{"unknown_type", "filter[type]", "playlists"},
+		{"unknown_filter", "filter[title]", "rock"},
+		{"cursor", "page[after]", "1"},
+		{"sort", "sort", "title"},
+		{"include", "include", "album"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func() {
+			req := t.expect.GET("/search")
+			if test.param != "q" {
+				req = req.WithQuery("q", "rock")
+			}
+
+			e := req.WithQuery(test.param, test.value).
+				Expect()
+
+			e.Status(http.StatusBadRequest)
+			e.JSON(jsonAPIContent).Schema(errorResponse()).Object().
+				Value("errors").Array().Value(0).Object().
+				Value("source").Object().Value("parameter").IsEqual(test.param)
+		})
+	}
+}
+
+func (t *SearchTest) TestSearch_MissingText() {
+	e := t.expect.GET("/search").
+		Expect()
+
+	e.Status(http.StatusBadRequest)
+	e.JSON(jsonAPIContent).Schema(errorResponse()).Object().
+		Value("errors").Array().Value(0).Object().
+		Value("source").Object().Value("parameter").IsEqual("q")
+}
+
+func (t *SearchTest) TestSearch_StoreError() {
+	t.store.EXPECT().
+		Search(mock.Anything, mock.Anything).
+		Return(nil, errors.New("boom"))
+
+	e := t.expect.GET("/search").
+		WithQuery("q", "rock").
+		Expect()
+
+	e.Status(http.StatusInternalServerError)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
+
+func (t *SearchTest) TestSearch_Memstore() {
+	t.serve(memstore.New())
+
+	t.expect.POST("/tracks/").
+		WithJSON(map[string]any{
+			"data": map[string]any{
+				"type":       "tracks",
+				"attributes": map[string]any{"title": "Rhapsody", "genres": []string{"Rock", "Opera"}},
+			},
+		}).
+		Expect().
+		Status(http.StatusCreated)
+
+	data := t.expect.GET("/search").
+		WithQuery("q", "rock").
+		Expect().
+		Status(http.StatusOK).
+		JSON(jsonAPIContent).Schema(schema("SearchResultsDataResponse")).Object().
+		Value("data").Array()
+
+	data.Length().IsEqual(1)
+	data.Value(0).Object().Value("meta").Object().Value("highlights").IsEqual(map[string]any{
+		"genres": "<mark>Rock</mark>, Opera",
+	})
+}
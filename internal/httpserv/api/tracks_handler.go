@@ -1,17 +1,28 @@
 package api
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
+	"github.com/cerfical/muzik/internal/blob"
 	"github.com/cerfical/muzik/internal/log"
 	"github.com/cerfical/muzik/internal/model"
 )
 
 type tracksHandler struct {
-	store model.TrackStore
+	store model.Store
+	blobs blob.Store
 	log   *log.Logger
+
+	requireIfMatch bool
+	maxAudioSize   int64
+	maxBodySize    int64
 }
 
 func (h *tracksHandler) get(w http.ResponseWriter, r *http.Request) {
@@ -31,54 +42,386 @@ func (h *tracksHandler) get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	encode(w, http.StatusOK, trackDataResponse{
+	if notModified(w, r, track) {
+		return
+	}
+
+	encode(w, r, http.StatusOK, &document{
 		Data: track,
 	})
 }
 
 func (h *tracksHandler) getAll(w http.ResponseWriter, r *http.Request) {
-	tracks, err := h.store.GetTracks(r.Context())
+	query, params, err := parseTrackQuery(r.URL.Query())
+	if err != nil {
+		reportQueryError(w, r, err, h.log)
+		return
+	}
+
+	if exportsPlaylist(r) {
+		h.exportAll(w, r, query)
+		return
+	}
+
+	page, err := h.store.GetTracks(r.Context(), query)
 	if err != nil {
 		internalError("Failed to read tracks data from persistent storage", err, h.log)(w, r)
 		return
 	}
 
-	encode(w, http.StatusOK, tracksDataResponse{
-		Data: tracks,
+	encode(w, r, http.StatusOK, &document{
+		Links: params.links(r.URL, page),
+		Meta:  &collectionMeta{Total: page.Total},
+		Data:  page.Tracks,
 	})
 }
 
-func (h *tracksHandler) create(w http.ResponseWriter, r *http.Request) {
-	newTrack, err := decode[newTrackRequest](r.Body)
-	if err != nil {
-		if parseErr := (*parseError)(nil); errors.As(err, &parseErr) {
-			encode(w, http.StatusBadRequest, errorResponse{
-				Errors: []errorInfo{{
-					Title:  "The request body is malformed",
-					Detail: parseErr.Error(),
-					Status: http.StatusBadRequest,
-				}},
-			})
-		} else {
-			internalError("Parsing of the request body was interrupted due to an unexpected error", err, h.log)(w, r)
+// exportAll renders all tracks selected by the query as a playlist file, which is never split into pages.
+func (h *tracksHandler) exportAll(w http.ResponseWriter, r *http.Request, query *model.TrackQuery) {
+	for param := range r.URL.Query() {
+		if strings.HasPrefix(param, "page[") {
+			reportQueryError(w, r, &queryError{param, "Playlists are exported in full, so the parameter is not supported"}, h.log)
+			return
+		}
+	}
+
+	var tracks []model.Track
+	query.Limit, query.Offset, query.After = maxPageSize, 0, 0
+	for {
+		page, err := h.store.GetTracks(r.Context(), query)
+		if err != nil {
+			internalError("Failed to read tracks data from persistent storage", err, h.log)(w, r)
+			return
+		}
+
+		tracks = append(tracks, page.Tracks...)
+		if len(page.Tracks) < query.Limit {
+			break
 		}
+		query.After = page.Tracks[len(page.Tracks)-1].ID
+	}
+
+	if err := writePlaylist(w, r, h.store, "", tracks); err != nil {
+		internalError("Failed to export tracks as a playlist", err, h.log)(w, r)
+	}
+}
+
+func (h *tracksHandler) create(w http.ResponseWriter, r *http.Request) {
+	body, ok := h.readBody(w, r)
+	if !ok {
 		return
 	}
 
-	track, err := h.store.CreateTrack(r.Context(), (*model.TrackAttrs)(&newTrack.Data.Attrs))
+	var req createTracksRequest
+	if !parseRequestBody(w, r, bytes.NewReader(body), &req, h.log) {
+		return
+	}
+
+	if isBulkData(req.Data) {
+		h.createAll(w, r, req.Data)
+		return
+	}
+
+	newTrack, errs, err := decodeTrack(req.Data, "/data")
 	if err != nil {
-		internalError("Failed to save track data to persistent storage", err, h.log)(w, r)
+		internalError("Parsing of the request body was interrupted due to an unexpected error", err, h.log)(w, r)
+		return
+	}
+	if errs != nil {
+		reportErrors(errs)(w, r)
+		return
+	}
+
+	track, err := h.store.CreateTrack(r.Context(), newTrack)
+	if err != nil {
+		if errors.Is(err, model.ErrRelatedNotFound) {
+			relatedNotFound(w, r)
+		} else {
+			internalError("Failed to save track data to persistent storage", err, h.log)(w, r)
+		}
 		return
 	}
 
 	location := r.URL.JoinPath(strconv.Itoa(track.ID))
 	w.Header().Set("Location", location.String())
+	w.Header().Set("ETag", trackETag(r, track))
 
-	encode(w, http.StatusCreated, trackDataResponse{
+	encode(w, r, http.StatusCreated, &document{
 		Data: track,
 	})
 }
 
+// createAll creates all tracks of a bulk request, or none of them if any is invalid.
+func (h *tracksHandler) createAll(w http.ResponseWriter, r *http.Request, data json.RawMessage) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		internalError("Parsing of the request body was interrupted due to an unexpected error", err, h.log)(w, r)
+		return
+	}
+
+	tracks, ok := parseBulkTracks(w, r, items, h.log)
+	if !ok {
+		return
+	}
+
+	created, err := h.store.CreateTracks(r.Context(), tracks)
+	if err != nil {
+		var itemErrs model.ItemErrors
+		switch {
+		case errors.As(err, &itemErrs):
+			reportErrors(bulkErrors(itemErrs, "relationships"))(w, r)
+		case errors.Is(err, model.ErrRelatedNotFound):
+			relatedNotFound(w, r)
+		default:
+			internalError("Failed to save tracks data to persistent storage", err, h.log)(w, r)
+		}
+		return
+	}
+
+	encode(w, r, http.StatusCreated, &document{
+		Data: created,
+	})
+}
+
+// parseBulkTracks decodes and validates each track of a bulk request, reporting the problems with all of them to the client.
+func parseBulkTracks(w http.ResponseWriter, r *http.Request, data []json.RawMessage, log *log.Logger) ([]model.Track, bool) {
+	if !checkBulkSize(w, r, len(data)) {
+		return nil, false
+	}
+
+	tracks := make([]model.Track, len(data))
+	var errs []errorInfo
+	for i, item := range data {
+		track, trackErrs, err := decodeTrack(item, fmt.Sprintf("/data/%d", i))
+		if err != nil {
+			internalError("Parsing of the request body was interrupted due to an unexpected error", err, log)(w, r)
+			return nil, false
+		}
+
+		if trackErrs != nil {
+			errs = append(errs, trackErrs...)
+			continue
+		}
+		tracks[i] = *track
+	}
+
+	if errs != nil {
+		reportErrors(errs)(w, r)
+		return nil, false
+	}
+	return tracks, true
+}
+
+// decodeTrack decodes and validates the track data located under the specified JSON pointer in a request body,
+// describing the problems with it, or failing on errors unrelated to the data.
+func decodeTrack(data json.RawMessage, pointer string) (*model.Track, []errorInfo, error) {
+	var track *model.Track
+	if len(data) != 0 {
+		if err := decodeJSON(bytes.NewReader(data), &track); err != nil {
+			if parseErr := (*parseError)(nil); errors.As(err, &parseErr) {
+				return nil, []errorInfo{malformedBody(parseErr.msg, pointer+parseErr.pointer)}, nil
+			}
+			if typeErr := (*model.TypeMismatchError)(nil); errors.As(err, &typeErr) {
+				return nil, []errorInfo{{
+					Title:  "Resource conflict",
+					Detail: fmt.Sprintf("The resource type '%s' does not match the expected type '%s'", typeErr.Got, typeErr.Want),
+					Status: http.StatusConflict,
+					Source: &errorSource{Pointer: pointer + "/type"},
+				}}, nil
+			}
+			return nil, nil, err
+		}
+	}
+
+	// Missing data is reported as null
+	if errs := trackErrors(track, pointer); errs != nil {
+		return nil, errs, nil
+	}
+	return track, nil, nil
+}
+
+// readBody reads the request body into memory, reporting any problems to the client.
+func (h *tracksHandler) readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body := r.Body
+	if h.maxBodySize > 0 {
+		body = http.MaxBytesReader(w, body, h.maxBodySize)
+	}
+
+	b, err := io.ReadAll(body)
+	if err != nil {
+		if maxBytesErr := (*http.MaxBytesError)(nil); errors.As(err, &maxBytesErr) {
+			contentTooLarge(maxBytesErr.Limit)(w, r)
+		} else {
+			badRequest(fmt.Sprintf("The request body could not be read: %v", err), "")(w, r)
+		}
+		return nil, false
+	}
+	return b, true
+}
+
+// deleteAll moves all tracks identified in the request body to the trash, or none of them if any does not exist.
+func (h *tracksHandler) deleteAll(w http.ResponseWriter, r *http.Request) {
+	if h.requireIfMatch {
+		// Revisions of several tracks cannot be checked at once, so they must be deleted one by one
+		reportErrors(errPreconditionRequired)(w, r)
+		return
+	}
+
+	var req relationshipRequest
+	if !parseRequest(w, r, &req, h.log) {
+		return
+	}
+
+	ids, ok := parseTrackIDs(w, r, req.Data)
+	if !ok || !checkBulkSize(w, r, len(ids)) {
+		return
+	}
+
+	var errs []errorInfo
+	seen := make(map[int]bool)
+	for i, id := range ids {
+		if seen[id] {
+			errs = append(errs, malformedBody(fmt.Sprintf("The track '%d' is listed more than once", id), fmt.Sprintf("/data/%d", i)))
+		}
+		seen[id] = true
+	}
+
+	if errs != nil {
+		reportErrors(errs)(w, r)
+		return
+	}
+
+	if err := h.store.DeleteTracks(r.Context(), ids); err != nil {
+		if itemErrs := (model.ItemErrors)(nil); errors.As(err, &itemErrs) {
+			reportErrors(bulkErrors(itemErrs, ""))(w, r)
+		} else {
+			internalError("Failed to delete tracks data from persistent storage", err, h.log)(w, r)
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// bulkErrors describes the failures of store operations on the resources of a bulk request, pointing to the specified member of each resource.
+func bulkErrors(errs model.ItemErrors, member string) []errorInfo {
+	infos := make([]errorInfo, len(errs))
+	for i, e := range errs {
+		pointer := fmt.Sprintf("/data/%d", e.Index)
+		if member != "" {
+			pointer += "/" + member
+		}
+
+		info := errorInfo{
+			Title:  "Internal server error",
+			Status: http.StatusInternalServerError,
+			Source: &errorSource{Pointer: pointer},
+		}
+		switch {
+		case errors.Is(e.Err, model.ErrNotFound):
+			info.Title, info.Status = "Resource not found", http.StatusNotFound
+			info.Detail = "The resource does not exist"
+		case errors.Is(e.Err, model.ErrRelatedNotFound):
+			info.Title, info.Status = "Related resource not found", http.StatusNotFound
+			info.Detail = "The resource refers to a related resource that does not exist"
+		}
+		infos[i] = info
+	}
+	return infos
+}
+
+func (h *tracksHandler) update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		notFound(w, r)
+		return
+	}
+
+	// The transaction may be retried, so keep the body around to decode it again
+	body, ok := h.readBody(w, r)
+	if !ok {
+		return
+	}
+
+	var track *model.Track
+	err = h.store.RunInTx(r.Context(), func(tx model.TxStores) error {
+		current, err := h.currentTrack(r, tx, id)
+		if err != nil {
+			return err
+		}
+
+		// Decode the request on top of the existing track so that omitted attributes keep their values
+		patch := updateTrackRequest{Data: current}
+		if err := decodeRequestBody(r, bytes.NewReader(body), &patch); err != nil {
+			return err
+		}
+		if err := checkTrack(id, patch.Data); err != nil {
+			return err
+		}
+
+		track, err = tx.UpdateTrack(r.Context(), patch.Data)
+		return err
+	})
+
+	h.respondSaved(w, r, track, err)
+}
+
+func (h *tracksHandler) replace(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		notFound(w, r)
+		return
+	}
+
+	var newTrack updateTrackRequest
+	if !parseRequest(w, r, &newTrack, h.log) {
+		return
+	}
+
+	if newTrack.Data != nil && newTrack.Data.ID == 0 {
+		// The ID is optional for full replacements
+		newTrack.Data.ID = id
+	}
+
+	if err := checkTrack(id, newTrack.Data); err != nil {
+		h.respondSaved(w, r, nil, err)
+		return
+	}
+
+	var track *model.Track
+	err = h.store.RunInTx(r.Context(), func(tx model.TxStores) error {
+		if err := h.checkRevision(r, tx, id); err != nil {
+			return err
+		}
+
+		var err error
+		track, err = tx.UpdateTrack(r.Context(), newTrack.Data)
+		return err
+	})
+
+	h.respondSaved(w, r, track, err)
+}
+
+// respondSaved responds with the updated track, or reports why it could not be updated.
+func (h *tracksHandler) respondSaved(w http.ResponseWriter, r *http.Request, track *model.Track, err error) {
+	reqErrs := (requestErrors)(nil)
+	switch {
+	case err == nil:
+		w.Header().Set("ETag", trackETag(r, track))
+		encode(w, r, http.StatusOK, &document{
+			Data: track,
+		})
+	case errors.As(err, &reqErrs):
+		reportErrors(reqErrs)(w, r)
+	case errors.Is(err, model.ErrNotFound):
+		notFound(w, r)
+	case errors.Is(err, model.ErrRelatedNotFound):
+		relatedNotFound(w, r)
+	default:
+		internalError("Failed to save track data to persistent storage", err, h.log)(w, r)
+	}
+}
+
 func (h *tracksHandler) delete(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
@@ -86,10 +429,21 @@ func (h *tracksHandler) delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.store.DeleteTrack(r.Context(), id); err != nil {
-		if errors.Is(err, model.ErrNotFound) {
+	err = h.store.RunInTx(r.Context(), func(tx model.TxStores) error {
+		if err := h.checkRevision(r, tx, id); err != nil {
+			return err
+		}
+		return tx.DeleteTrack(r.Context(), id)
+	})
+
+	if err != nil {
+		reqErrs := (requestErrors)(nil)
+		switch {
+		case errors.As(err, &reqErrs):
+			reportErrors(reqErrs)(w, r)
+		case errors.Is(err, model.ErrNotFound):
 			notFound(w, r)
-		} else {
+		default:
 			internalError("Failed to delete track data from persistent storage", err, h.log)(w, r)
 		}
 		return
@@ -97,3 +451,104 @@ func (h *tracksHandler) delete(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (h *tracksHandler) getTrash(w http.ResponseWriter, r *http.Request) {
+	query, params, err := parseTrackQuery(r.URL.Query())
+	if err != nil {
+		reportQueryError(w, r, err, h.log)
+		return
+	}
+
+	query.Trashed = true
+	page, err := h.store.GetTracks(r.Context(), query)
+	if err != nil {
+		internalError("Failed to read tracks data from persistent storage", err, h.log)(w, r)
+		return
+	}
+
+	tracks := make([]trashedTrack, len(page.Tracks))
+	for i := range page.Tracks {
+		track := &page.Tracks[i]
+		tracks[i] = trashedTrack{Track: track, Meta: trashMeta{TrackMeta: track.Meta}}
+		if track.DeletedAt != nil {
+			tracks[i].Meta.DeletedAt = *track.DeletedAt
+		}
+	}
+
+	encode(w, r, http.StatusOK, &document{
+		Links: params.links(r.URL, page),
+		Meta:  &collectionMeta{Total: page.Total},
+		Data:  tracks,
+	})
+}
+
+func (h *tracksHandler) restore(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		notFound(w, r)
+		return
+	}
+
+	var track *model.Track
+	err = h.store.RunInTx(r.Context(), func(tx model.TxStores) error {
+		if err := h.checkTrashedRevision(r, tx, id); err != nil {
+			return err
+		}
+
+		var err error
+		track, err = tx.RestoreTrack(r.Context(), id)
+		return err
+	})
+
+	if err != nil {
+		reqErrs := (requestErrors)(nil)
+		switch {
+		case errors.As(err, &reqErrs):
+			reportErrors(reqErrs)(w, r)
+		case errors.Is(err, model.ErrNotFound):
+			notFound(w, r)
+		default:
+			internalError("Failed to restore track data in persistent storage", err, h.log)(w, r)
+		}
+		return
+	}
+
+	w.Header().Set("ETag", trackETag(r, track))
+	encode(w, r, http.StatusOK, &document{
+		Data: track,
+	})
+}
+
+// checkTrack validates the track data from a request body and checks that it updates the track in the request path.
+func checkTrack(id int, data *model.Track) error {
+	if errs := trackErrors(data, "/data"); errs != nil {
+		return requestErrors(errs)
+	}
+
+	if data.ID != id {
+		return requestErrors{conflictInfo(fmt.Sprintf("The resource ID '%d' does not match the ID '%d' in the request path", data.ID, id))}
+	}
+	return nil
+}
+
+// trackErrors describes the problems with the track data located under the specified JSON pointer in a request body.
+func trackErrors(data *model.Track, pointer string) []errorInfo {
+	if data == nil {
+		return []errorInfo{malformedBody("The request body must contain a track resource", pointer)}
+	}
+
+	var validationErr model.ValidationError
+	if err := data.Attrs.Validate(); errors.As(err, &validationErr) {
+		return fieldErrors(validationErr, pointer+"/attributes")
+	}
+
+	seen := make(map[int]bool)
+	for _, id := range data.Rels.ArtistIDs {
+		if seen[id] {
+			return []errorInfo{malformedBody(fmt.Sprintf("The artist '%d' is listed more than once", id), pointer+"/relationships/artists")}
+		}
+		seen[id] = true
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package api_test
 
 import (
+	"context"
 	"net/http"
 	"testing"
 
@@ -16,7 +17,14 @@ var sampleTracks = []model.Track{
 	{
 		ID: 1,
 		Attrs: model.TrackAttrs{
-			Title: "Example Track #1",
+			Title:       "Example Track #1",
+			Duration:    ptr(215000),
+			TrackNumber: ptr(1),
+			Year:        ptr(1999),
+			Genres:      []string{"Rock", "Pop"},
+			ISRC:        "USRC17607839",
+			BPM:         ptr(120.5),
+			Explicit:    true,
 		},
 	},
 	{
@@ -34,18 +42,39 @@ func TestTracks(t *testing.T) {
 type TracksTest struct {
 	suite.Suite
 
-	store  *mocks.TrackStore
+	store  *mocks.Store
 	expect *httpexpect.Expect
+
+	// txAttempts is how many times transactions are run, to simulate retries
+	txAttempts int
 }
 
 func (t *TracksTest) SetupTest() {
-	t.store = mocks.NewTrackStore(t.T())
+	t.store = mocks.NewStore(t.T())
+
+	// Run transactions directly against the mock
+	t.txAttempts = 1
+	t.store.EXPECT().
+		RunInTx(mock.Anything, mock.Anything).
+		RunAndReturn(func(_ context.Context, f func(model.TxStores) error) (err error) {
+			for range t.txAttempts {
+				err = f(t.store)
+			}
+			return err
+		}).
+		Maybe()
+
+	t.serve(&api.Config{})
+}
+
+// serve directs the test requests to a handler set up with the specified config.
+func (t *TracksTest) serve(config *api.Config) {
 	t.expect = httpexpect.WithConfig(httpexpect.Config{
 		TestName: t.T().Name(),
 		BaseURL:  "/api/tracks",
 		Reporter: httpexpect.NewAssertReporter(t.T()),
 		Client: &http.Client{
-			Transport: httpexpect.NewBinder(api.NewHandler(t.store, nil)),
+			Transport: httpexpect.NewBinder(api.NewHandler(config, t.store, nil, nil)),
 		},
 	})
 }
@@ -64,8 +93,99 @@ func (t *TracksTest) TestTracks_Get_Ok() {
 		Expect()
 
 	e.Status(http.StatusOK)
-	e.JSON().Schema(trackDataResponse()).
-		IsEqual(&response)
+	e.JSON(jsonAPIContent).Schema(trackDataResponse()).
+		Object().Value("data").IsEqual(response.Data)
+}
+
+func (t *TracksTest) TestTracks_Get_ETag() {
+	track := sampleTracks[0]
+	track.Revision = 3
+
+	t.store.EXPECT().
+		GetTrack(mock.Anything, 1).
+		Return(&track, nil)
+
+	e := t.expect.GET("/1").
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.Header("ETag").IsEqual(`"3"`)
+}
+
+func (t *TracksTest) TestTracks_Get_ETagPerRepresentation() {
+	track := sampleTracks[0]
+	track.Revision = 3
+
+	t.store.EXPECT().
+		GetTrack(mock.Anything, 1).
+		Return(&track, nil)
+
+	etag := func(accept, query string) string {
+		return t.expect.GET("/1").
+			WithHeader("Accept", accept).
+			WithQueryString(query).
+			Expect().
+			Header("ETag").Raw()
+	}
+
+	// Each representation of the revision has a distinct tag
+	tags := []string{
+		etag("application/vnd.api+json", ""),
+		etag("application/json", ""),
+		etag("text/csv", ""),
+		etag("application/yaml", ""),
+		etag("application/vnd.api+json", "fields[tracks]=title"),
+		etag("application/vnd.api+json", "fields[tracks]=comment"),
+	}
+	t.Equal(`"3"`, tags[0])
+	for i := range tags {
+		for j := range i {
+			t.NotEqual(tags[i], tags[j])
+		}
+	}
+
+	// And a tag of one representation does not validate another
+	t.expect.GET("/1").
+		WithHeader("Accept", "text/csv").
+		WithHeader("If-None-Match", tags[0]).
+		Expect().
+		Status(http.StatusOK)
+}
+
+func (t *TracksTest) TestTracks_Get_NotModified() {
+	track := sampleTracks[0]
+	track.Revision = 3
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		status      int
+	}{
+		{"same_revision", `"3"`, http.StatusNotModified},
+		{"weak_tag", `W/"3"`, http.StatusNotModified},
+		{"tag_list", `"1", "3"`, http.StatusNotModified},
+		{"any", `*`, http.StatusNotModified},
+		{"other_revision", `"2"`, http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func() {
+			t.store.EXPECT().
+				GetTrack(mock.Anything, 1).
+				Return(&track, nil).
+				Once()
+
+			e := t.expect.GET("/1").
+				WithHeader("If-None-Match", test.ifNoneMatch).
+				Expect()
+
+			e.Status(test.status)
+			e.Header("ETag").IsEqual(`"3"`)
+			if test.status == http.StatusNotModified {
+				e.Body().IsEmpty()
+			}
+		})
+	}
 }
 
 func (t *TracksTest) TestTracks_Get_NotFound() {
@@ -77,25 +197,224 @@ func (t *TracksTest) TestTracks_Get_NotFound() {
 		Expect()
 
 	e.Status(http.StatusNotFound)
-	e.JSON().Schema(errorResponse())
+	e.JSON(jsonAPIContent).Schema(errorResponse())
 }
 
 func (t *TracksTest) TestTracks_GetAll_Ok() {
-	var response struct {
-		Data []model.Track `json:"data"`
-	}
-	response.Data = sampleTracks
+	t.store.EXPECT().
+		GetTracks(mock.Anything, &model.TrackQuery{Limit: 20}).
+		Return(&model.TrackPage{Tracks: sampleTracks, Total: 2}, nil)
+
+	e := t.expect.GET("/").
+		Expect()
+
+	e.Status(http.StatusOK)
+
+	response := e.JSON(jsonAPIContent).Schema(tracksDataResponse()).Object()
+	response.Value("data").IsEqual(sampleTracks)
+	response.Value("meta").Object().Value("total").IsEqual(2)
+	response.Value("links").Object().
+		NotContainsKey("prev").
+		NotContainsKey("next")
+}
+
+func (t *TracksTest) TestTracks_GetAll_Paginated() {
+	t.store.EXPECT().
+		GetTracks(mock.Anything, &model.TrackQuery{Limit: 2, Offset: 2}).
+		Return(&model.TrackPage{Tracks: sampleTracks, Total: 5}, nil)
+
+	e := t.expect.GET("/").
+		WithQuery("page[size]", 2).
+		WithQuery("page[number]", 2).
+		Expect()
+
+	e.Status(http.StatusOK)
+
+	links := e.JSON(jsonAPIContent).Schema(tracksDataResponse()).Object().
+		Value("links").Object()
+	links.Value("first").IsEqual("/api/tracks/?page%5Bsize%5D=2")
+	links.Value("prev").IsEqual("/api/tracks/?page%5Bnumber%5D=1&page%5Bsize%5D=2")
+	links.Value("next").IsEqual("/api/tracks/?page%5Bnumber%5D=3&page%5Bsize%5D=2")
+}
 
+func (t *TracksTest) TestTracks_GetAll_PaginatedByCursor() {
 	t.store.EXPECT().
-		GetTracks(mock.Anything).
-		Return(sampleTracks, nil)
+		GetTracks(mock.Anything, &model.TrackQuery{Limit: 2, After: 5}).
+		Return(&model.TrackPage{Tracks: sampleTracks, Total: 5}, nil)
 
 	e := t.expect.GET("/").
+		WithQuery("page[size]", 2).
+		WithQuery("page[after]", 5).
 		Expect()
 
 	e.Status(http.StatusOK)
-	e.JSON().Schema(tracksDataResponse()).
-		IsEqual(&response)
+
+	links := e.JSON(jsonAPIContent).Schema(tracksDataResponse()).Object().
+		Value("links").Object()
+	links.NotContainsKey("prev")
+	links.Value("next").IsEqual("/api/tracks/?page%5Bafter%5D=2&page%5Bsize%5D=2")
+}
+
+func (t *TracksTest) TestTracks_GetAll_FilteredAndSorted() {
+	t.store.EXPECT().
+		GetTracks(mock.Anything, &model.TrackQuery{
+			Filter: model.TrackFilter{Title: "example"},
+			Sort: []model.TrackOrder{
+				{Field: model.TrackTitle, Desc: true},
+				{Field: model.TrackID},
+			},
+			Limit: 20,
+		}).
+		Return(&model.TrackPage{Tracks: sampleTracks, Total: 2}, nil)
+
+	e := t.expect.GET("/").
+		WithQuery("filter[title]", "example").
+		WithQuery("sort", "-title,id").
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.JSON(jsonAPIContent).Schema(tracksDataResponse()).Object().
+		Value("data").IsEqual(sampleTracks)
+}
+
+func (t *TracksTest) TestTracks_GetAll_BadQuery() {
+	tests := []struct {
+		name  string
+		param string
+		query map[string]any
+	}{
+		{"unknown_sort_field", "sort", map[string]any{"sort": "-artist"}},
+		{"empty_sort_field", "sort", map[string]any{"sort": "title,"}},
+		{"duplicate_sort_field", "sort", map[string]any{"sort": "title,-title"}},
+		{"unknown_filter", "filter[artist]", map[string]any{"filter[artist]": "unknown"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func() {
+			e := t.expect.GET("/").
+				WithQueryObject(test.query).
+				Expect()
+
+			e.Status(http.StatusBadRequest)
+			e.JSON(jsonAPIContent).Schema(errorResponse()).Object().
+				Value("errors").Array().Value(0).Object().
+				Value("source").Object().
+				Value("parameter").IsEqual(test.param)
+		})
+	}
+}
+
+func (t *TracksTest) TestTracks_GetAll_BadPage() {
+	tests := []struct {
+		name  string
+		param string
+		query map[string]any
+	}{
+		{"zero_size", "page[size]", map[string]any{"page[size]": 0}},
+		{"too_large_size", "page[size]", map[string]any{"page[size]": 1000}},
+		{"invalid_number", "page[number]", map[string]any{"page[number]": "first"}},
+		{"cursor_with_number", "page[after]", map[string]any{"page[number]": 2, "page[after]": 1}},
+		{"unknown_param", "page[offset]", map[string]any{"page[offset]": 10}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func() {
+			e := t.expect.GET("/").
+				WithQueryObject(test.query).
+				Expect()
+
+			e.Status(http.StatusBadRequest)
+			e.JSON(jsonAPIContent).Schema(errorResponse()).Object().
+				Value("errors").Array().Value(0).Object().
+				Value("source").Object().
+				Value("parameter").IsEqual(test.param)
+		})
+	}
+}
+
+func (t *TracksTest) TestTracks_GetAll_Export() {
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		contains    string
+	}{
+		{"m3u8", "application/vnd.apple.mpegurl", "application/vnd.apple.mpegurl", "#EXTINF:215,Example Artist - Example Track #1"},
+		{"xspf", "application/xspf+xml", "application/xspf+xml", "<creator>Example Artist</creator>"},
+		{"pls", "audio/x-scpls", "audio/x-scpls", "Title1=Example Artist - Example Track #1"},
+		{"preferred_by_qvalue", "application/json;q=0.5, audio/x-scpls", "audio/x-scpls", "File2=/api/tracks/2"},
+		{"wildcard_subtype", "audio/*, application/json;q=0.9", "audio/x-scpls", "NumberOfEntries=2"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func() {
+			tracks := []model.Track{sampleTracks[0], sampleTracks[1]}
+			tracks[0].Rels.ArtistIDs = []int{1}
+
+			e := t.store.EXPECT()
+			e.GetTracks(mock.Anything, mock.Anything).
+				Return(&model.TrackPage{Tracks: tracks, Total: 2}, nil).
+				Once()
+			e.GetArtists(mock.Anything).
+				Return([]model.Artist{{ID: 1, Attrs: model.ArtistAttrs{Name: "Example Artist"}}}, nil).
+				Once()
+			e.GetAlbums(mock.Anything).
+				Return([]model.Album{}, nil).
+				Once()
+
+			r := t.expect.GET("/").
+				WithHeader("Accept", test.accept).
+				Expect()
+
+			r.Status(http.StatusOK).
+				Header("Content-Type").IsEqual(test.contentType)
+			r.Body().Contains(test.contains)
+		})
+	}
+}
+
+func (t *TracksTest) TestTracks_GetAll_ExportAllPages() {
+	full := make([]model.Track, 100)
+	for i := range full {
+		full[i] = model.Track{ID: i + 1, Attrs: model.TrackAttrs{Title: "Track"}}
+	}
+	last := []model.Track{{ID: 101, Attrs: model.TrackAttrs{Title: "Last Track"}}}
+
+	e := t.store.EXPECT()
+	e.GetTracks(mock.Anything, &model.TrackQuery{Limit: 100}).
+		Return(&model.TrackPage{Tracks: full, Total: 101}, nil)
+	e.GetTracks(mock.Anything, &model.TrackQuery{Limit: 100, After: 100}).
+		Return(&model.TrackPage{Tracks: last, Total: 101}, nil)
+	e.GetArtists(mock.Anything).
+		Return([]model.Artist{}, nil)
+	e.GetAlbums(mock.Anything).
+		Return([]model.Album{}, nil)
+
+	r := t.expect.GET("/").
+		WithHeader("Accept", "audio/x-scpls").
+		Expect()
+
+	r.Status(http.StatusOK)
+	r.Body().Contains("NumberOfEntries=101").Contains("Title101=Last Track")
+}
+
+func (t *TracksTest) TestTracks_GetAll_ExportPaginated() {
+	e := t.expect.GET("/").
+		WithHeader("Accept", "audio/x-scpls").
+		WithQuery("page[size]", 10).
+		Expect()
+
+	e.Status(http.StatusBadRequest)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
+
+func (t *TracksTest) TestTracks_GetAll_NotAcceptable() {
+	e := t.expect.GET("/").
+		WithHeader("Accept", "text/html, application/json;q=0").
+		Expect()
+
+	e.Status(http.StatusNotAcceptable)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
 }
 
 func (t *TracksTest) TestTracks_Create_Ok() {
@@ -107,16 +426,12 @@ func (t *TracksTest) TestTracks_Create_Ok() {
 	request.Data.Attrs = sampleTracks[0].Attrs
 
 	var response struct {
-		Data struct {
-			Attrs model.TrackAttrs `json:"attributes"`
-			ID    int              `json:"id,string"`
-		} `json:"data"`
+		Data model.Track `json:"data"`
 	}
-	response.Data.Attrs = sampleTracks[0].Attrs
-	response.Data.ID = sampleTracks[0].ID
+	response.Data = sampleTracks[0]
 
 	t.store.EXPECT().
-		CreateTrack(mock.Anything, &sampleTracks[0].Attrs).
+		CreateTrack(mock.Anything, &model.Track{Attrs: sampleTracks[0].Attrs}).
 		Return(&sampleTracks[0], nil)
 
 	e := t.expect.POST("/").
@@ -126,8 +441,153 @@ func (t *TracksTest) TestTracks_Create_Ok() {
 	e.Status(http.StatusCreated).
 		Header("Location").IsEqual("/api/tracks/1")
 
-	e.JSON().Schema(trackDataResponse()).
-		IsEqual(&response)
+	e.JSON(jsonAPIContent).Schema(trackDataResponse()).
+		Object().Value("data").IsEqual(response.Data)
+}
+
+func (t *TracksTest) TestTracks_Create_WithRelationships() {
+	track := model.Track{
+		ID:    1,
+		Attrs: sampleTracks[0].Attrs,
+		Rels: model.TrackRels{
+			AlbumID:   3,
+			ArtistIDs: []int{2, 1},
+		},
+	}
+
+	t.store.EXPECT().
+		CreateTrack(mock.Anything, &model.Track{Attrs: track.Attrs, Rels: track.Rels}).
+		Return(&track, nil)
+
+	e := t.expect.POST("/").
+		WithJSON(map[string]any{
+			"data": map[string]any{
+				"attributes": track.Attrs,
+				"relationships": map[string]any{
+					"album": map[string]any{
+						"data": map[string]any{"type": "albums", "id": "3"},
+					},
+					"artists": map[string]any{
+						"data": []any{
+							map[string]any{"type": "artists", "id": "2"},
+							map[string]any{"type": "artists", "id": "1"},
+						},
+					},
+				},
+			},
+		}).
+		Expect()
+
+	e.Status(http.StatusCreated)
+
+	rels := e.JSON(jsonAPIContent).Schema(trackDataResponse()).Object().
+		Value("data").Object().
+		Value("relationships").Object()
+	rels.Value("album").Object().Value("data").Object().Value("id").IsEqual("3")
+	rels.Value("artists").Object().Value("data").Array().Length().IsEqual(2)
+}
+
+func (t *TracksTest) TestTracks_Create_RelatedNotFound() {
+	t.store.EXPECT().
+		CreateTrack(mock.Anything, mock.Anything).
+		Return(nil, model.ErrRelatedNotFound)
+
+	e := t.expect.POST("/").
+		WithJSON(map[string]any{
+			"data": map[string]any{
+				"attributes": sampleTracks[0].Attrs,
+				"relationships": map[string]any{
+					"album": map[string]any{
+						"data": map[string]any{"type": "albums", "id": "9"},
+					},
+				},
+			},
+		}).
+		Expect()
+
+	e.Status(http.StatusNotFound)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
+
+func (t *TracksTest) TestTracks_Create_BadRelationships() {
+	tests := []struct {
+		name string
+		rels map[string]any
+	}{
+		{"wrong_type", map[string]any{
+			"album": map[string]any{
+				"data": map[string]any{"type": "artists", "id": "1"},
+			},
+		}},
+		{"duplicate_artists", map[string]any{
+			"artists": map[string]any{
+				"data": []any{
+					map[string]any{"type": "artists", "id": "1"},
+					map[string]any{"type": "artists", "id": "1"},
+				},
+			},
+		}},
+		{"unknown_relationship", map[string]any{
+			"genre": map[string]any{"data": nil},
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func() {
+			e := t.expect.POST("/").
+				WithJSON(map[string]any{
+					"data": map[string]any{
+						"attributes":    sampleTracks[0].Attrs,
+						"relationships": test.rels,
+					},
+				}).
+				Expect()
+
+			e.Status(http.StatusBadRequest)
+			e.JSON(jsonAPIContent).Schema(errorResponse())
+		})
+	}
+}
+
+func (t *TracksTest) TestTracks_Create_InvalidAttrs() {
+	e := t.expect.POST("/").
+		WithJSON(map[string]any{
+			"data": map[string]any{
+				"attributes": map[string]any{
+					"title":    "Example Track",
+					"duration": -1,
+					"isrc":     "US-RC1-76-07839",
+				},
+			},
+		}).
+		Expect()
+
+	e.Status(http.StatusUnprocessableEntity)
+
+	errs := e.JSON(jsonAPIContent).Schema(errorResponse()).Object().
+		Value("errors").Array()
+	errs.Length().IsEqual(2)
+	errs.Value(0).Object().Value("source").Object().Value("pointer").IsEqual("/data/attributes/duration")
+	errs.Value(1).Object().Value("source").Object().Value("pointer").IsEqual("/data/attributes/isrc")
+}
+
+func (t *TracksTest) TestTracks_Create_InvalidAttrType() {
+	e := t.expect.POST("/").
+		WithJSON(map[string]any{
+			"data": map[string]any{
+				"attributes": map[string]any{
+					"title": "Example Track",
+					"year":  "1999",
+				},
+			},
+		}).
+		Expect()
+
+	e.Status(http.StatusBadRequest)
+	e.JSON(jsonAPIContent).Schema(errorResponse()).Object().
+		Value("errors").Array().Value(0).Object().
+		Value("source").Object().
+		Value("pointer").IsEqual("/data/attributes/year")
 }
 
 func (t *TracksTest) TestTracks_Create_BadRequest() {
@@ -136,7 +596,337 @@ func (t *TracksTest) TestTracks_Create_BadRequest() {
 		Expect()
 
 	e.Status(http.StatusBadRequest)
-	e.JSON().Schema(errorResponse())
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
+
+func (t *TracksTest) TestTracks_Create_MissingData() {
+	e := t.expect.POST("/").
+		WithJSON(map[string]any{}).
+		Expect()
+
+	e.Status(http.StatusBadRequest)
+	e.JSON(jsonAPIContent).Schema(errorResponse()).Object().
+		Value("errors").Array().Value(0).Object().
+		Value("source").Object().Value("pointer").IsEqual("/data")
+}
+
+func (t *TracksTest) TestTracks_Create_TooLarge() {
+	t.serve(&api.Config{MaxBodySize: 16})
+
+	for _, data := range []any{
+		map[string]any{"attributes": sampleTracks[0].Attrs},
+		[]any{map[string]any{"attributes": sampleTracks[0].Attrs}},
+	} {
+		e := t.expect.POST("/").
+			WithJSON(map[string]any{"data": data}).
+			Expect()
+
+		e.Status(http.StatusRequestEntityTooLarge)
+		e.JSON(jsonAPIContent).Schema(errorResponse())
+	}
+}
+
+func (t *TracksTest) TestTracks_Update_Ok() {
+	var request struct {
+		Data struct {
+			Attrs struct {
+				Title string `json:"title"`
+			} `json:"attributes"`
+			ID int `json:"id,string"`
+		} `json:"data"`
+	}
+	request.Data.Attrs.Title = "New Title"
+	request.Data.ID = 1
+
+	var response struct {
+		Data model.Track `json:"data"`
+	}
+	response.Data = sampleTracks[0]
+	response.Data.Attrs.Title = "New Title"
+
+	t.store.EXPECT().
+		GetTrack(mock.Anything, 1).
+		Return(&model.Track{ID: 1, Attrs: sampleTracks[0].Attrs}, nil)
+	t.store.EXPECT().
+		UpdateTrack(mock.Anything, &response.Data).
+		Return(&response.Data, nil)
+
+	e := t.expect.PATCH("/1").
+		WithJSON(&request).
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.JSON(jsonAPIContent).Schema(trackDataResponse()).
+		Object().Value("data").IsEqual(response.Data)
+}
+
+func (t *TracksTest) TestTracks_Update_KeepsOmittedAttrs() {
+	t.store.EXPECT().
+		GetTrack(mock.Anything, 1).
+		Return(&model.Track{ID: 1, Attrs: sampleTracks[0].Attrs}, nil)
+	t.store.EXPECT().
+		UpdateTrack(mock.Anything, &sampleTracks[0]).
+		Return(&sampleTracks[0], nil)
+
+	e := t.expect.PATCH("/1").
+		WithJSON(map[string]any{
+			"data": map[string]any{"id": "1"},
+		}).
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.JSON(jsonAPIContent).Schema(trackDataResponse())
+}
+
+func (t *TracksTest) TestTracks_Update_Retried() {
+	t.txAttempts = 2
+
+	// The request must be applied anew to the track as it is on each attempt
+	updated := sampleTracks[0]
+	updated.Attrs.Comment = "New Comment"
+	t.store.EXPECT().
+		GetTrack(mock.Anything, 1).
+		Return(&model.Track{ID: 1, Attrs: sampleTracks[0].Attrs}, nil).
+		Times(2)
+	t.store.EXPECT().
+		UpdateTrack(mock.Anything, &updated).
+		Return(&updated, nil).
+		Times(2)
+
+	e := t.expect.PATCH("/1").
+		WithJSON(map[string]any{
+			"data": map[string]any{
+				"id":         "1",
+				"attributes": map[string]any{"comment": "New Comment"},
+			},
+		}).
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.JSON(jsonAPIContent).Schema(trackDataResponse()).
+		Object().Value("data").IsEqual(updated)
+}
+
+func (t *TracksTest) TestTracks_Update_RetriedAfterPreconditionFailed() {
+	t.txAttempts = 2
+
+	// Only the outcome of the last attempt is reported
+	current := model.Track{ID: 1, Attrs: sampleTracks[0].Attrs, Revision: 3}
+	updated := current
+	updated.Attrs.Comment = "New Comment"
+	saved := updated
+	saved.Revision = 4
+
+	t.store.EXPECT().
+		GetTrack(mock.Anything, 1).
+		Return(&model.Track{ID: 1, Attrs: sampleTracks[0].Attrs, Revision: 2}, nil).
+		Once()
+	t.store.EXPECT().
+		GetTrack(mock.Anything, 1).
+		Return(&current, nil).
+		Once()
+	t.store.EXPECT().
+		UpdateTrack(mock.Anything, &updated).
+		Return(&saved, nil)
+
+	e := t.expect.PATCH("/1").
+		WithHeader("If-Match", `"3"`).
+		WithJSON(map[string]any{
+			"data": map[string]any{
+				"id":         "1",
+				"attributes": map[string]any{"comment": "New Comment"},
+			},
+		}).
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.Header("ETag").IsEqual(`"4"`)
+}
+
+func (t *TracksTest) TestTracks_Update_IfMatch() {
+	current := model.Track{ID: 1, Attrs: sampleTracks[0].Attrs, Revision: 3}
+	updated := current
+	updated.Attrs.Comment = "New Comment"
+	saved := updated
+	saved.Revision = 4
+
+	t.store.EXPECT().
+		GetTrack(mock.Anything, 1).
+		Return(&current, nil)
+	t.store.EXPECT().
+		UpdateTrack(mock.Anything, &updated).
+		Return(&saved, nil)
+
+	e := t.expect.PATCH("/1").
+		WithHeader("If-Match", `"3"`).
+		WithJSON(map[string]any{
+			"data": map[string]any{
+				"id":         "1",
+				"attributes": map[string]any{"comment": "New Comment"},
+			},
+		}).
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.Header("ETag").IsEqual(`"4"`)
+}
+
+func (t *TracksTest) TestTracks_Update_PreconditionFailed() {
+	tests := []struct {
+		name    string
+		ifMatch string
+	}{
+		{"other_revision", `"2"`},
+		{"weak_tag", `W/"3"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func() {
+			t.store.EXPECT().
+				GetTrack(mock.Anything, 1).
+				Return(&model.Track{ID: 1, Attrs: sampleTracks[0].Attrs, Revision: 3}, nil).
+				Once()
+
+			e := t.expect.PATCH("/1").
+				WithHeader("If-Match", test.ifMatch).
+				WithJSON(map[string]any{
+					"data": map[string]any{"id": "1"},
+				}).
+				Expect()
+
+			e.Status(http.StatusPreconditionFailed)
+			e.JSON(jsonAPIContent).Schema(errorResponse())
+		})
+	}
+}
+
+func (t *TracksTest) TestTracks_Update_PreconditionRequired() {
+	t.serve(&api.Config{RequireIfMatch: true})
+
+	t.store.EXPECT().
+		GetTrack(mock.Anything, 1).
+		Return(&model.Track{ID: 1, Attrs: sampleTracks[0].Attrs, Revision: 3}, nil)
+
+	e := t.expect.PATCH("/1").
+		WithJSON(map[string]any{
+			"data": map[string]any{"id": "1"},
+		}).
+		Expect()
+
+	e.Status(http.StatusPreconditionRequired)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
+
+func (t *TracksTest) TestTracks_Update_NotFound() {
+	t.store.EXPECT().
+		GetTrack(mock.Anything, 3).
+		Return(nil, model.ErrNotFound)
+
+	e := t.expect.PATCH("/3").
+		WithJSON(map[string]any{
+			"data": map[string]any{"id": "3"},
+		}).
+		Expect()
+
+	e.Status(http.StatusNotFound)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
+
+func (t *TracksTest) TestTracks_Update_BadRequest() {
+	t.store.EXPECT().
+		GetTrack(mock.Anything, 1).
+		Return(&model.Track{ID: 1, Attrs: sampleTracks[0].Attrs}, nil)
+
+	e := t.expect.PATCH("/1").
+		WithJSON("").
+		Expect()
+
+	e.Status(http.StatusBadRequest)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
+
+func (t *TracksTest) TestTracks_Update_Conflict() {
+	t.store.EXPECT().
+		GetTrack(mock.Anything, 1).
+		Return(&model.Track{ID: 1, Attrs: sampleTracks[0].Attrs}, nil)
+
+	e := t.expect.PATCH("/1").
+		WithJSON(map[string]any{
+			"data": map[string]any{"id": "2"},
+		}).
+		Expect()
+
+	e.Status(http.StatusConflict)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
+
+func (t *TracksTest) TestTracks_Replace_Ok() {
+	var request struct {
+		Data struct {
+			Attrs model.TrackAttrs `json:"attributes"`
+		} `json:"data"`
+	}
+	request.Data.Attrs = sampleTracks[1].Attrs
+
+	var response struct {
+		Data model.Track `json:"data"`
+	}
+	response.Data = model.Track{
+		ID:    1,
+		Attrs: sampleTracks[1].Attrs,
+	}
+
+	t.store.EXPECT().
+		UpdateTrack(mock.Anything, &response.Data).
+		Return(&response.Data, nil)
+
+	e := t.expect.PUT("/1").
+		WithJSON(&request).
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.JSON(jsonAPIContent).Schema(trackDataResponse()).
+		Object().Value("data").IsEqual(response.Data)
+}
+
+func (t *TracksTest) TestTracks_Replace_PreconditionFailed() {
+	t.store.EXPECT().
+		GetTrack(mock.Anything, 1).
+		Return(&model.Track{ID: 1, Attrs: sampleTracks[0].Attrs, Revision: 3}, nil)
+
+	e := t.expect.PUT("/1").
+		WithHeader("If-Match", `"2"`).
+		WithJSON(map[string]any{
+			"data": map[string]any{"attributes": sampleTracks[1].Attrs},
+		}).
+		Expect()
+
+	e.Status(http.StatusPreconditionFailed)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
+
+func (t *TracksTest) TestTracks_Replace_NotFound() {
+	t.store.EXPECT().
+		UpdateTrack(mock.Anything, &model.Track{ID: 3, Attrs: sampleTracks[0].Attrs}).
+		Return(nil, model.ErrNotFound)
+
+	e := t.expect.PUT("/3").
+		WithJSON(map[string]any{
+			"data": map[string]any{"attributes": sampleTracks[0].Attrs},
+		}).
+		Expect()
+
+	e.Status(http.StatusNotFound)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
+
+func (t *TracksTest) TestTracks_Replace_BadRequest() {
+	e := t.expect.PUT("/1").
+		WithJSON(map[string]any{"data": nil}).
+		Expect()
+
+	e.Status(http.StatusBadRequest)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
 }
 
 func (t *TracksTest) TestTracks_Delete_Ok() {
@@ -151,6 +941,58 @@ func (t *TracksTest) TestTracks_Delete_Ok() {
 	e.Body().IsEmpty()
 }
 
+func (t *TracksTest) TestTracks_Delete_IfMatch() {
+	t.store.EXPECT().
+		GetTrack(mock.Anything, 1).
+		Return(&model.Track{ID: 1, Revision: 3}, nil)
+	t.store.EXPECT().
+		DeleteTrack(mock.Anything, 1).
+		Return(nil)
+
+	e := t.expect.DELETE("/1").
+		WithHeader("If-Match", `"1", "3"`).
+		Expect()
+
+	e.Status(http.StatusNoContent)
+}
+
+func (t *TracksTest) TestTracks_Delete_PreconditionFailed() {
+	t.store.EXPECT().
+		GetTrack(mock.Anything, 1).
+		Return(&model.Track{ID: 1, Revision: 3}, nil)
+
+	e := t.expect.DELETE("/1").
+		WithHeader("If-Match", `"2"`).
+		Expect()
+
+	e.Status(http.StatusPreconditionFailed)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
+
+func (t *TracksTest) TestTracks_Delete_IfMatchNotFound() {
+	t.store.EXPECT().
+		GetTrack(mock.Anything, 3).
+		Return(nil, model.ErrNotFound)
+
+	// Even "*" does not match a missing track
+	e := t.expect.DELETE("/3").
+		WithHeader("If-Match", "*").
+		Expect()
+
+	e.Status(http.StatusPreconditionFailed)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
+
+func (t *TracksTest) TestTracks_Delete_PreconditionRequired() {
+	t.serve(&api.Config{RequireIfMatch: true})
+
+	e := t.expect.DELETE("/1").
+		Expect()
+
+	e.Status(http.StatusPreconditionRequired)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
+
 func (t *TracksTest) TestTracks_Delete_NotFound() {
 	t.store.EXPECT().
 		DeleteTrack(mock.Anything, 3).
@@ -160,5 +1002,273 @@ func (t *TracksTest) TestTracks_Delete_NotFound() {
 		Expect()
 
 	e.Status(http.StatusNotFound)
-	e.JSON().Schema(errorResponse())
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
+
+func (t *TracksTest) TestTracks_CreateAll_Ok() {
+	t.store.EXPECT().
+		CreateTracks(mock.Anything, []model.Track{
+			{Attrs: model.TrackAttrs{Title: "First"}},
+			{Attrs: model.TrackAttrs{Title: "Second"}, Rels: model.TrackRels{AlbumID: 3}},
+		}).
+		Return([]model.Track{
+			{ID: 1, Attrs: model.TrackAttrs{Title: "First"}},
+			{ID: 2, Attrs: model.TrackAttrs{Title: "Second"}, Rels: model.TrackRels{AlbumID: 3}},
+		}, nil)
+
+	e := t.expect.POST("/").
+		WithJSON(map[string]any{
+			"data": []any{
+				map[string]any{"type": "tracks", "attributes": map[string]any{"title": "First"}},
+				map[string]any{
+					"type":       "tracks",
+					"attributes": map[string]any{"title": "Second"},
+					"relationships": map[string]any{
+						"album": map[string]any{"data": map[string]any{"type": "albums", "id": "3"}},
+					},
+				},
+			},
+		}).
+		Expect()
+
+	e.Status(http.StatusCreated)
+
+	data := e.JSON(jsonAPIContent).Schema(schema("CreatedTracksDataResponse")).Object().
+		Value("data").Array()
+	data.Length().IsEqual(2)
+	data.Value(0).Object().Value("id").IsEqual("1")
+	data.Value(1).Object().Value("id").IsEqual("2")
+}
+
+func (t *TracksTest) TestTracks_CreateAll_InvalidItems() {
+	e := t.expect.POST("/").
+		WithJSON(map[string]any{
+			"data": []any{
+				map[string]any{"attributes": map[string]any{"title": "Fine"}},
+				map[string]any{"attributes": map[string]any{"title": "Bad", "duration": -1}},
+				map[string]any{"type": "albums", "attributes": map[string]any{"title": "Wrong"}},
+				map[string]any{"attributes": map[string]any{"title": "Bad", "year": "1999"}},
+				nil,
+			},
+		}).
+		Expect()
+
+	e.Status(http.StatusBadRequest)
+
+	errs := e.JSON(jsonAPIContent).Schema(errorResponse()).Object().
+		Value("errors").Array()
+	errs.Length().IsEqual(4)
+
+	for i, pointer := range []string{"/data/1/attributes/duration", "/data/2/type", "/data/3/attributes/year", "/data/4"} {
+		errs.Value(i).Object().Value("source").Object().Value("pointer").IsEqual(pointer)
+	}
+	errs.Value(0).Object().Value("status").IsEqual("422")
+	errs.Value(1).Object().Value("status").IsEqual("409")
+}
+
+func (t *TracksTest) TestTracks_CreateAll_InvalidAttrs() {
+	e := t.expect.POST("/").
+		WithJSON(map[string]any{
+			"data": []any{
+				map[string]any{"attributes": map[string]any{"title": ""}},
+				map[string]any{"attributes": map[string]any{"title": "Bad", "duration": -1}},
+			},
+		}).
+		Expect()
+
+	// Errors of the same kind keep their status
+	e.Status(http.StatusUnprocessableEntity)
+	e.JSON(jsonAPIContent).Schema(errorResponse()).Object().
+		Value("errors").Array().Length().IsEqual(2)
+}
+
+func (t *TracksTest) TestTracks_CreateAll_RelatedNotFound() {
+	t.store.EXPECT().
+		CreateTracks(mock.Anything, mock.Anything).
+		Return(nil, model.ItemErrors{{Index: 1, Err: model.ErrRelatedNotFound}})
+
+	e := t.expect.POST("/").
+		WithJSON(map[string]any{
+			"data": []any{
+				map[string]any{"attributes": map[string]any{"title": "First"}},
+				map[string]any{"attributes": map[string]any{"title": "Second"}},
+			},
+		}).
+		Expect()
+
+	e.Status(http.StatusNotFound)
+	e.JSON(jsonAPIContent).Schema(errorResponse()).Object().
+		Value("errors").Array().Value(0).Object().
+		Value("source").Object().
+		Value("pointer").IsEqual("/data/1/relationships")
+}
+
+func (t *TracksTest) TestTracks_CreateAll_BadSize() {
+	tooMany := make([]any, 501)
+	for i := range tooMany {
+		tooMany[i] = map[string]any{"attributes": map[string]any{"title": "T"}}
+	}
+
+	for name, data := range map[string][]any{"empty": {}, "too_many": tooMany} {
+		t.Run(name, func() {
+			e := t.expect.POST("/").
+				WithJSON(map[string]any{"data": data}).
+				Expect()
+
+			e.Status(http.StatusBadRequest)
+			e.JSON(jsonAPIContent).Schema(errorResponse()).Object().
+				Value("errors").Array().Value(0).Object().
+				Value("source").Object().
+				Value("pointer").IsEqual("/data")
+		})
+	}
+}
+
+func (t *TracksTest) TestTracks_DeleteAll_Ok() {
+	t.store.EXPECT().
+		DeleteTracks(mock.Anything, []int{2, 1}).
+		Return(nil)
+
+	e := t.expect.DELETE("/").
+		WithJSON(map[string]any{
+			"data": []any{
+				map[string]any{"type": "tracks", "id": "2"},
+				map[string]any{"type": "tracks", "id": "1"},
+			},
+		}).
+		Expect()
+
+	e.Status(http.StatusNoContent)
+	e.Body().IsEmpty()
+}
+
+func (t *TracksTest) TestTracks_DeleteAll_NotFound() {
+	t.store.EXPECT().
+		DeleteTracks(mock.Anything, []int{1, 5, 6}).
+		Return(model.ItemErrors{{Index: 1, Err: model.ErrNotFound}, {Index: 2, Err: model.ErrNotFound}})
+
+	e := t.expect.DELETE("/").
+		WithJSON(map[string]any{
+			"data": []any{
+				map[string]any{"type": "tracks", "id": "1"},
+				map[string]any{"type": "tracks", "id": "5"},
+				map[string]any{"type": "tracks", "id": "6"},
+			},
+		}).
+		Expect()
+
+	e.Status(http.StatusNotFound)
+
+	errs := e.JSON(jsonAPIContent).Schema(errorResponse()).Object().
+		Value("errors").Array()
+	errs.Length().IsEqual(2)
+	errs.Value(0).Object().Value("source").Object().Value("pointer").IsEqual("/data/1")
+	errs.Value(1).Object().Value("source").Object().Value("pointer").IsEqual("/data/2")
+}
+
+func (t *TracksTest) TestTracks_DeleteAll_Duplicates() {
+	e := t.expect.DELETE("/").
+		WithJSON(map[string]any{
+			"data": []any{
+				map[string]any{"type": "tracks", "id": "1"},
+				map[string]any{"type": "tracks", "id": "1"},
+			},
+		}).
+		Expect()
+
+	e.Status(http.StatusBadRequest)
+	e.JSON(jsonAPIContent).Schema(errorResponse()).Object().
+		Value("errors").Array().Value(0).Object().
+		Value("source").Object().
+		Value("pointer").IsEqual("/data/1")
+}
+
+func (t *TracksTest) TestTracks_DeleteAll_PreconditionRequired() {
+	t.serve(&api.Config{RequireIfMatch: true})
+
+	e := t.expect.DELETE("/").
+		WithJSON(map[string]any{
+			"data": []any{map[string]any{"type": "tracks", "id": "1"}},
+		}).
+		Expect()
+
+	e.Status(http.StatusPreconditionRequired)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
+
+func (t *TracksTest) TestTracks_Restore_Ok() {
+	restored := sampleTracks[0]
+	restored.Revision = 2
+
+	t.store.EXPECT().
+		RestoreTrack(mock.Anything, 1).
+		Return(&restored, nil)
+
+	e := t.expect.POST("/1/restore").
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.Header("ETag").IsEqual(`"2"`)
+	e.JSON(jsonAPIContent).Schema(trackDataResponse()).
+		Object().Value("data").IsEqual(sampleTracks[0])
+}
+
+func (t *TracksTest) TestTracks_Restore_NotFound() {
+	t.store.EXPECT().
+		RestoreTrack(mock.Anything, 3).
+		Return(nil, model.ErrNotFound)
+
+	e := t.expect.POST("/3/restore").
+		Expect()
+
+	e.Status(http.StatusNotFound)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
+
+func (t *TracksTest) TestTracks_Restore_IfMatch() {
+	trashed := sampleTracks[0]
+	trashed.Revision = 2
+	restored := sampleTracks[0]
+	restored.Revision = 3
+
+	t.store.EXPECT().
+		GetTrashedTrack(mock.Anything, 1).
+		Return(&trashed, nil)
+	t.store.EXPECT().
+		RestoreTrack(mock.Anything, 1).
+		Return(&restored, nil)
+
+	e := t.expect.POST("/1/restore").
+		WithHeader("If-Match", `"2"`).
+		Expect()
+
+	e.Status(http.StatusOK)
+	e.Header("ETag").IsEqual(`"3"`)
+}
+
+func (t *TracksTest) TestTracks_Restore_PreconditionFailed() {
+	t.store.EXPECT().
+		GetTrashedTrack(mock.Anything, 1).
+		Return(&model.Track{ID: 1, Revision: 3}, nil)
+
+	e := t.expect.POST("/1/restore").
+		WithHeader("If-Match", `"2"`).
+		Expect()
+
+	e.Status(http.StatusPreconditionFailed)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
+
+func (t *TracksTest) TestTracks_Restore_PreconditionRequired() {
+	t.serve(&api.Config{RequireIfMatch: true})
+
+	e := t.expect.POST("/1/restore").
+		Expect()
+
+	e.Status(http.StatusPreconditionRequired)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
+
+func ptr[T any](v T) *T {
+	return &v
 }
@@ -0,0 +1,70 @@
+package api_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cerfical/muzik/internal/httpserv/api"
+	"github.com/cerfical/muzik/internal/mocks"
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/gavv/httpexpect/v2"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestTrash(t *testing.T) {
+	suite.Run(t, new(TrashTest))
+}
+
+type TrashTest struct {
+	suite.Suite
+
+	store  *mocks.Store
+	expect *httpexpect.Expect
+}
+
+func (t *TrashTest) SetupTest() {
+	t.store = mocks.NewStore(t.T())
+	t.expect = httpexpect.WithConfig(httpexpect.Config{
+		TestName: t.T().Name(),
+		BaseURL:  "/api/trash",
+		Reporter: httpexpect.NewAssertReporter(t.T()),
+		Client: &http.Client{
+			Transport: httpexpect.NewBinder(api.NewHandler(&api.Config{}, t.store, nil, nil)),
+		},
+	})
+}
+
+func (t *TrashTest) TestTrash_GetTracks_Ok() {
+	deletedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	track := sampleTracks[1]
+	track.DeletedAt = &deletedAt
+
+	t.store.EXPECT().
+		GetTracks(mock.Anything, &model.TrackQuery{Limit: 2, Trashed: true}).
+		Return(&model.TrackPage{Tracks: []model.Track{track}, Total: 1}, nil)
+
+	e := t.expect.GET("/tracks").
+		WithQuery("page[size]", 2).
+		Expect()
+
+	e.Status(http.StatusOK)
+
+	response := e.JSON(jsonAPIContent).Schema(schema("TrashedTracksDataResponse")).Object()
+	response.Value("meta").Object().Value("total").IsEqual(1)
+
+	data := response.Value("data").Array()
+	data.Length().IsEqual(1)
+	data.Value(0).Object().Value("id").IsEqual("2")
+	data.Value(0).Object().Value("meta").Object().Value("deletedAt").IsEqual("2024-05-01T12:00:00Z")
+}
+
+func (t *TrashTest) TestTrash_GetTracks_BadQuery() {
+	e := t.expect.GET("/tracks").
+		WithQuery("sort", "genre").
+		Expect()
+
+	e.Status(http.StatusBadRequest)
+	e.JSON(jsonAPIContent).Schema(errorResponse())
+}
@@ -1,6 +1,9 @@
 package httpserv
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 	"time"
@@ -26,11 +29,58 @@ func logRequest(l *log.Logger) func(http.Handler) http.Handler {
 				"path", r.URL.Path,
 				"status", statusLine,
 				"time", elapsed.String(),
+				"request_id", RequestID(r.Context()),
 			).Info("Request complete")
 		})
 	}
 }
 
+const (
+	requestIDHeader    = "X-Request-ID"
+	maxRequestIDLength = 128
+)
+
+type requestIDKey struct{}
+
+// RequestID returns the ID assigned to the request with the context, or an empty string if there is none.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// assignRequestID identifies each request either by the ID supplied by the client, if it looks sane, or by a newly generated one.
+func assignRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !isValidRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	// Only allow visible ASCII characters, so that the ID is safe to log and echo back
+	for i := range len(id) {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
 type loggingResponseWriter struct {
 	http.ResponseWriter
 
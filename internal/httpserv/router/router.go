@@ -4,6 +4,7 @@ package router
 import (
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 )
 
@@ -16,6 +17,9 @@ func New() *Router {
 type Router struct {
 	mux        *http.ServeMux
 	middleware []Middleware
+
+	// routeMiddleware is applied to endpoints only, as opposed to all requests.
+	routeMiddleware []Middleware
 }
 
 // Middleware wraps [http.HandlerFunc] to perform additional actions before and/or after the original handler is called.
@@ -48,11 +52,25 @@ func (r *Router) Routes(path string, endpoints []Endpoint) *Router {
 	}
 
 	for _, endpoint := range endpoints {
-		r.mux.HandleFunc(fmt.Sprintf("%s %s", endpoint.Method, path), endpoint.Handler)
+		h := endpoint.Handler
+		for _, m := range r.routeMiddleware {
+			h = m(h)
+		}
+		r.mux.HandleFunc(fmt.Sprintf("%s %s", endpoint.Method, path), h)
 	}
 	return r
 }
 
+// With creates a [Router] sharing routes with the original one, with the specified [Middleware] applied to all endpoints defined through it.
+//
+// Unlike middleware installed with [Router.Use], it is only called for requests matching one of the endpoints.
+func (r *Router) With(m ...Middleware) *Router {
+	return &Router{
+		mux:             r.mux,
+		routeMiddleware: append(slices.Clone(r.routeMiddleware), m...),
+	}
+}
+
 // Use applies a [Middleware].
 func (r *Router) Use(m Middleware) *Router {
 	r.middleware = append(r.middleware, m)
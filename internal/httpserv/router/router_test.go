@@ -138,3 +138,39 @@ func (t *RouterTest) TestAllowedMethods() {
 		})
 	}
 }
+
+func (t *RouterTest) TestWith() {
+	tests := []struct {
+		name   string
+		path   string
+		header string
+	}{
+		{"with_middleware", "/posts/", "posts"},
+		{"without_middleware", "/users/", ""},
+		{"nonexistent_path", "/posts/1", ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func() {
+			t.router.
+				With(func(next http.HandlerFunc) http.HandlerFunc {
+					return func(w http.ResponseWriter, r *http.Request) {
+						w.Header().Set("X-Route", "posts")
+						next(w, r)
+					}
+				}).
+				Routes("/posts/", []router.Endpoint{
+					{"GET", t.handler.ServeHTTP},
+				})
+
+			t.handler.EXPECT().
+				ServeHTTP(mock.Anything, mock.Anything).
+				Return().
+				Maybe()
+
+			e := t.expect.GET(test.path).
+				Expect()
+			e.Header("X-Route").IsEqual(test.header)
+		})
+	}
+}
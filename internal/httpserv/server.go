@@ -18,7 +18,7 @@ func New(config *Config, h http.Handler, log *log.Logger) *Server {
 			Addr: config.Addr,
 
 			// Log requests before any routing logic applies
-			Handler:  logRequest(log)(h),
+			Handler:  assignRequestID(logRequest(log)(h)),
 			ErrorLog: stdlog.New(&httpErrorLog{log}, "", 0),
 
 			ReadTimeout:  config.Timeout,
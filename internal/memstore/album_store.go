@@ -0,0 +1,90 @@
+package memstore
+
+import (
+	"context"
+
+	"github.com/cerfical/muzik/internal/model"
+)
+
+type albumRow = model.AlbumAttrs
+
+func (s *Store) CreateAlbum(ctx context.Context, attrs *model.AlbumAttrs) (*model.Album, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	id := s.albums.insert(*attrs)
+	return &model.Album{ID: id, Attrs: *attrs}, nil
+}
+
+func (s *Store) GetAlbum(ctx context.Context, id int) (*model.Album, error) {
+	if err := s.rlock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.RUnlock()
+
+	attrs, ok := s.albums.get(id)
+	if !ok {
+		return nil, model.ErrNotFound
+	}
+	return &model.Album{ID: id, Attrs: attrs}, nil
+}
+
+func (s *Store) GetAlbums(ctx context.Context) ([]model.Album, error) {
+	if err := s.rlock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.RUnlock()
+
+	albums := []model.Album{}
+	for _, id := range s.albums.ids() {
+		albums = append(albums, model.Album{ID: id, Attrs: s.albums.rows[id]})
+	}
+	return albums, nil
+}
+
+func (s *Store) GetAlbumsByIDs(ctx context.Context, ids []int) ([]model.Album, error) {
+	if err := s.rlock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.RUnlock()
+
+	albums := []model.Album{}
+	for _, id := range s.albums.existing(ids) {
+		albums = append(albums, model.Album{ID: id, Attrs: s.albums.rows[id]})
+	}
+	return albums, nil
+}
+
+func (s *Store) UpdateAlbum(ctx context.Context, id int, attrs *model.AlbumAttrs) (*model.Album, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	if !s.albums.update(id, *attrs) {
+		return nil, model.ErrNotFound
+	}
+	return &model.Album{ID: id, Attrs: *attrs}, nil
+}
+
+func (s *Store) DeleteAlbum(ctx context.Context, id int) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	if !s.albums.has(id) {
+		return model.ErrNotFound
+	}
+
+	for _, track := range s.tracks.rows {
+		if track.rels.AlbumID == id {
+			return model.ErrConflict
+		}
+	}
+
+	s.albums.delete(id)
+	return nil
+}
@@ -0,0 +1,91 @@
+package memstore
+
+import (
+	"context"
+	"slices"
+
+	"github.com/cerfical/muzik/internal/model"
+)
+
+type artistRow = model.ArtistAttrs
+
+func (s *Store) CreateArtist(ctx context.Context, attrs *model.ArtistAttrs) (*model.Artist, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	id := s.artists.insert(*attrs)
+	return &model.Artist{ID: id, Attrs: *attrs}, nil
+}
+
+func (s *Store) GetArtist(ctx context.Context, id int) (*model.Artist, error) {
+	if err := s.rlock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.RUnlock()
+
+	attrs, ok := s.artists.get(id)
+	if !ok {
+		return nil, model.ErrNotFound
+	}
+	return &model.Artist{ID: id, Attrs: attrs}, nil
+}
+
+func (s *Store) GetArtists(ctx context.Context) ([]model.Artist, error) {
+	if err := s.rlock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.RUnlock()
+
+	artists := []model.Artist{}
+	for _, id := range s.artists.ids() {
+		artists = append(artists, model.Artist{ID: id, Attrs: s.artists.rows[id]})
+	}
+	return artists, nil
+}
+
+func (s *Store) GetArtistsByIDs(ctx context.Context, ids []int) ([]model.Artist, error) {
+	if err := s.rlock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.RUnlock()
+
+	artists := []model.Artist{}
+	for _, id := range s.artists.existing(ids) {
+		artists = append(artists, model.Artist{ID: id, Attrs: s.artists.rows[id]})
+	}
+	return artists, nil
+}
+
+func (s *Store) UpdateArtist(ctx context.Context, id int, attrs *model.ArtistAttrs) (*model.Artist, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	if !s.artists.update(id, *attrs) {
+		return nil, model.ErrNotFound
+	}
+	return &model.Artist{ID: id, Attrs: *attrs}, nil
+}
+
+func (s *Store) DeleteArtist(ctx context.Context, id int) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	if !s.artists.has(id) {
+		return model.ErrNotFound
+	}
+
+	for _, track := range s.tracks.rows {
+		if slices.Contains(track.rels.ArtistIDs, id) {
+			return model.ErrConflict
+		}
+	}
+
+	s.artists.delete(id)
+	return nil
+}
@@ -0,0 +1,90 @@
+package memstore
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/cerfical/muzik/internal/model"
+)
+
+type auditRow = model.AuditAttrs
+
+// cloneAuditAttrs makes a deep copy of the attributes, with changes never nil.
+func cloneAuditAttrs(attrs *model.AuditAttrs) model.AuditAttrs {
+	c := *attrs
+	c.Changes = make(map[string]model.AuditChange, len(attrs.Changes))
+	for k, v := range attrs.Changes {
+		c.Changes[k] = model.AuditChange{Before: bytes.Clone(v.Before), After: bytes.Clone(v.After)}
+	}
+	return c
+}
+
+func (s *Store) AppendAudit(ctx context.Context, entry *model.AuditEntry) (*model.AuditEntry, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	row := cloneAuditAttrs(&entry.Attrs)
+	row.Time = time.Now().UTC()
+
+	id := s.audit.insert(row)
+	return &model.AuditEntry{ID: id, Attrs: cloneAuditAttrs(&row)}, nil
+}
+
+func (s *Store) GetAudit(ctx context.Context, query *model.AuditQuery) (*model.AuditPage, error) {
+	if err := s.rlock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.RUnlock()
+
+	var ids []int
+	for _, id := range s.audit.ids() {
+		if row := s.audit.rows[id]; matchesAuditQuery(&row, query) {
+			ids = append(ids, id)
+		}
+	}
+
+	page := model.AuditPage{Entries: []model.AuditEntry{}, Total: len(ids)}
+	if query.After > 0 {
+		ids = idsAfter(ids, query.After)
+	}
+
+	ids = ids[min(query.Offset, len(ids)):]
+	if query.Limit > 0 {
+		ids = ids[:min(query.Limit, len(ids))]
+	}
+
+	for _, id := range ids {
+		row := s.audit.rows[id]
+		page.Entries = append(page.Entries, model.AuditEntry{ID: id, Attrs: cloneAuditAttrs(&row)})
+	}
+	return &page, nil
+}
+
+// idsAfter selects the sorted IDs greater than the specified one.
+func idsAfter(ids []int, id int) []int {
+	for i, v := range ids {
+		if v > id {
+			return ids[i:]
+		}
+	}
+	return nil
+}
+
+func matchesAuditQuery(row *auditRow, query *model.AuditQuery) bool {
+	if res := query.Resource; res.Type != "" {
+		if row.Resource.Type != res.Type || (res.ID > 0 && row.Resource.ID != res.ID) {
+			return false
+		}
+	}
+
+	if !query.Since.IsZero() && row.Time.Before(query.Since) {
+		return false
+	}
+	if !query.Until.IsZero() && !row.Time.Before(query.Until) {
+		return false
+	}
+	return true
+}
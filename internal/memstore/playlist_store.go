@@ -0,0 +1,167 @@
+package memstore
+
+import (
+	"context"
+	"slices"
+
+	"github.com/cerfical/muzik/internal/model"
+)
+
+type playlistRow struct {
+	attrs    model.PlaylistAttrs
+	trackIDs []int
+}
+
+// playlist makes a copy of the row as a playlist with the specified ID.
+func (s *Store) playlist(id int, r *playlistRow) *model.Playlist {
+	return &model.Playlist{
+		ID:    id,
+		Attrs: r.attrs,
+		Rels: model.PlaylistRels{
+			TrackIDs: s.listedTracks(r.trackIDs),
+		},
+	}
+}
+
+// listedTracks leaves out tracks in the trash from the tracks of a playlist.
+func (s *Store) listedTracks(trackIDs []int) []int {
+	listed := []int{}
+	for _, id := range trackIDs {
+		if _, ok := s.getTrack(id); ok {
+			listed = append(listed, id)
+		}
+	}
+	return listed
+}
+
+func (s *Store) CreatePlaylist(ctx context.Context, playlist *model.Playlist) (*model.Playlist, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	if !s.hasTracks(playlist.Rels.TrackIDs) {
+		return nil, model.ErrRelatedNotFound
+	}
+
+	row := playlistRow{
+		attrs:    playlist.Attrs,
+		trackIDs: append([]int{}, playlist.Rels.TrackIDs...),
+	}
+	id := s.playlists.insert(row)
+	return s.playlist(id, &row), nil
+}
+
+func (s *Store) GetPlaylist(ctx context.Context, id int) (*model.Playlist, error) {
+	if err := s.rlock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.RUnlock()
+
+	row, ok := s.playlists.get(id)
+	if !ok {
+		return nil, model.ErrNotFound
+	}
+	return s.playlist(id, &row), nil
+}
+
+func (s *Store) GetPlaylists(ctx context.Context) ([]model.Playlist, error) {
+	if err := s.rlock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.RUnlock()
+
+	playlists := []model.Playlist{}
+	for _, id := range s.playlists.ids() {
+		row := s.playlists.rows[id]
+		playlists = append(playlists, *s.playlist(id, &row))
+	}
+	return playlists, nil
+}
+
+func (s *Store) UpdatePlaylist(ctx context.Context, playlist *model.Playlist) (*model.Playlist, error) {
+	return s.editPlaylist(ctx, playlist.ID, playlist.Rels.TrackIDs, func(row *playlistRow) {
+		row.attrs = playlist.Attrs
+		row.trackIDs = s.replaceListedTracks(row.trackIDs, playlist.Rels.TrackIDs)
+	})
+}
+
+func (s *Store) DeletePlaylist(ctx context.Context, id int) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	if !s.playlists.delete(id) {
+		return model.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) InsertPlaylistTracks(ctx context.Context, id int, index int, trackIDs []int) (*model.Playlist, error) {
+	return s.editPlaylist(ctx, id, trackIDs, func(row *playlistRow) {
+		// The index counts only the listed tracks, so find where in all tracks the listed one at the index is
+		pos := len(row.trackIDs)
+		if index >= 0 {
+			for i, trackID := range row.trackIDs {
+				if _, ok := s.getTrack(trackID); ok {
+					if index == 0 {
+						pos = i
+						break
+					}
+					index--
+				}
+			}
+		}
+		row.trackIDs = slices.Insert(slices.Clone(row.trackIDs), pos, trackIDs...)
+	})
+}
+
+func (s *Store) SetPlaylistTracks(ctx context.Context, id int, trackIDs []int) (*model.Playlist, error) {
+	return s.editPlaylist(ctx, id, trackIDs, func(row *playlistRow) {
+		row.trackIDs = s.replaceListedTracks(row.trackIDs, trackIDs)
+	})
+}
+
+// replaceListedTracks replaces the listed tracks of a playlist with new ones, keeping tracks in the trash in their places.
+func (s *Store) replaceListedTracks(trackIDs []int, newIDs []int) []int {
+	replaced := []int{}
+	for _, id := range trackIDs {
+		if _, ok := s.getTrack(id); !ok {
+			replaced = append(replaced, id)
+		} else if len(newIDs) > 0 {
+			replaced = append(replaced, newIDs[0])
+			newIDs = newIDs[1:]
+		}
+	}
+	return append(replaced, newIDs...)
+}
+
+func (s *Store) RemovePlaylistTracks(ctx context.Context, id int, trackIDs []int) (*model.Playlist, error) {
+	return s.editPlaylist(ctx, id, nil, func(row *playlistRow) {
+		row.trackIDs = slices.DeleteFunc(slices.Clone(row.trackIDs), func(trackID int) bool {
+			return slices.Contains(trackIDs, trackID)
+		})
+	})
+}
+
+// editPlaylist applies f to the playlist after checking that it exists, and that all of the referenced tracks exist.
+func (s *Store) editPlaylist(ctx context.Context, id int, trackIDs []int, f func(row *playlistRow)) (*model.Playlist, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	row, ok := s.playlists.get(id)
+	if !ok {
+		return nil, model.ErrNotFound
+	}
+
+	if !s.hasTracks(trackIDs) {
+		return nil, model.ErrRelatedNotFound
+	}
+
+	f(&row)
+	s.playlists.update(id, row)
+	return s.playlist(id, &row), nil
+}
@@ -0,0 +1,12 @@
+package memstore
+
+import (
+	"context"
+
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/cerfical/muzik/internal/search"
+)
+
+func (s *Store) Search(ctx context.Context, query *model.SearchQuery) (*model.SearchPage, error) {
+	return search.Scan(ctx, s, query)
+}
@@ -0,0 +1,209 @@
+// Package memstore keeps a music library in memory, so that the API can run without a database.
+package memstore
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/cerfical/muzik/internal/model"
+)
+
+// Store implements all the [model] store interfaces on top of in-memory tables and is safe for concurrent use.
+//
+// It follows the semantics of the database-backed stores: IDs of each resource type are assigned sequentially starting from 1
+// and are never reused, and resources are never shared with callers, which always receive copies.
+type Store struct {
+	mu sync.RWMutex
+
+	tracks    table[trackRow]
+	artists   table[artistRow]
+	albums    table[albumRow]
+	playlists table[playlistRow]
+	audit     table[auditRow]
+}
+
+func New() *Store {
+	return &Store{}
+}
+
+// lock locks the store for writing, failing instead if the context is already done.
+func (s *Store) lock(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	return nil
+}
+
+// rlock locks the store for reading, failing instead if the context is already done.
+func (s *Store) rlock(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.RLock()
+	return nil
+}
+
+// RunInTx runs f against the tables of the store, undoing the changes made by f unless it succeeds.
+// Transactions are run one at a time, with all other access to the store waiting for them to finish.
+func (s *Store) RunInTx(ctx context.Context, f func(model.TxStores) error) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	tx := &Store{
+		tracks:    s.tracks.begin(),
+		artists:   s.artists.begin(),
+		albums:    s.albums.begin(),
+		playlists: s.playlists.begin(),
+		audit:     s.audit.begin(),
+	}
+
+	// The changes are undone if f panics as well
+	ok := false
+	defer func() {
+		if !ok {
+			tx.tracks.rollback()
+			tx.artists.rollback()
+			tx.albums.rollback()
+			tx.playlists.rollback()
+			tx.audit.rollback()
+		}
+
+		// As with database sequences, IDs taken by the transaction are never reused, even if it fails
+		s.tracks = tx.tracks.end()
+		s.artists = tx.artists.end()
+		s.albums = tx.albums.end()
+		s.playlists = tx.playlists.end()
+		s.audit = tx.audit.end()
+	}()
+
+	err := f(tx)
+	ok = err == nil
+	return err
+}
+
+// Close does nothing, as there are no resources to release.
+func (s *Store) Close() error {
+	return nil
+}
+
+// table holds rows of a single resource type keyed by their IDs.
+type table[T any] struct {
+	rows   map[int]T
+	lastID int
+
+	// undo holds the previous states of the rows changed in a transaction, in order of the changes, or is nil outside of transactions.
+	undo []rowState[T]
+}
+
+// rowState is the state of a row before a change, with exists telling whether the row was there at all.
+type rowState[T any] struct {
+	id     int
+	row    T
+	exists bool
+}
+
+// begin starts a transaction on the table, which changes the same rows in place, so that nothing is copied.
+func (t *table[T]) begin() table[T] {
+	if t.rows == nil {
+		t.rows = make(map[int]T)
+	}
+	return table[T]{rows: t.rows, lastID: t.lastID, undo: []rowState[T]{}}
+}
+
+// rollback undoes the changes made to the rows in a transaction, keeping the IDs it took.
+func (t *table[T]) rollback() {
+	for _, s := range slices.Backward(t.undo) {
+		if s.exists {
+			t.rows[s.id] = s.row
+		} else {
+			delete(t.rows, s.id)
+		}
+	}
+	t.undo = t.undo[:0]
+}
+
+// end finishes a transaction on the table, returning the table as it is left by the transaction.
+func (t *table[T]) end() table[T] {
+	return table[T]{rows: t.rows, lastID: t.lastID}
+}
+
+// record remembers the state of a row before it is changed, if the table is changed in a transaction.
+func (t *table[T]) record(id int) {
+	if t.undo != nil {
+		row, exists := t.rows[id]
+		t.undo = append(t.undo, rowState[T]{id, row, exists})
+	}
+}
+
+// insert adds a new row with the next ID in sequence, returning the ID.
+func (t *table[T]) insert(row T) int {
+	if t.rows == nil {
+		t.rows = make(map[int]T)
+	}
+
+	t.lastID++
+	t.record(t.lastID)
+	t.rows[t.lastID] = row
+	return t.lastID
+}
+
+func (t *table[T]) get(id int) (T, bool) {
+	row, ok := t.rows[id]
+	return row, ok
+}
+
+func (t *table[T]) has(id int) bool {
+	_, ok := t.rows[id]
+	return ok
+}
+
+// update replaces an existing row, reporting whether it exists.
+func (t *table[T]) update(id int, row T) bool {
+	if !t.has(id) {
+		return false
+	}
+	t.record(id)
+	t.rows[id] = row
+	return true
+}
+
+// delete removes a row, reporting whether it existed.
+func (t *table[T]) delete(id int) bool {
+	if !t.has(id) {
+		return false
+	}
+	t.record(id)
+	delete(t.rows, id)
+	return true
+}
+
+// ids lists IDs of all rows in ascending order.
+func (t *table[T]) ids() []int {
+	return slices.Sorted(maps.Keys(t.rows))
+}
+
+// hasAll checks that rows with all of the specified IDs exist.
+func (t *table[T]) hasAll(ids []int) bool {
+	for _, id := range ids {
+		if !t.has(id) {
+			return false
+		}
+	}
+	return true
+}
+
+// existing lists the specified IDs that have rows, without repetitions and in ascending order.
+func (t *table[T]) existing(ids []int) []int {
+	var found []int
+	for _, id := range slices.Compact(slices.Sorted(slices.Values(ids))) {
+		if t.has(id) {
+			found = append(found, id)
+		}
+	}
+	return found
+}
@@ -0,0 +1,15 @@
+package memstore_test
+
+import (
+	"testing"
+
+	"github.com/cerfical/muzik/internal/memstore"
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/cerfical/muzik/internal/storetest"
+)
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(*testing.T) model.Store {
+		return memstore.New()
+	})
+}
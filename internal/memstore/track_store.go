@@ -0,0 +1,420 @@
+package memstore
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cerfical/muzik/internal/model"
+)
+
+type trackRow struct {
+	attrs    model.TrackAttrs
+	rels     model.TrackRels
+	revision int
+	audio    *model.Audio
+
+	// deletedAt is when the track was moved to the trash, or nil if it is not there.
+	deletedAt *time.Time
+}
+
+func newTrackRow(track *model.Track, revision int) trackRow {
+	return trackRow{
+		attrs:    cloneTrackAttrs(&track.Attrs),
+		rels:     cloneTrackRels(&track.Rels),
+		revision: revision,
+	}
+}
+
+// track makes a copy of the row as a track with the specified ID.
+func (r *trackRow) track(id int) *model.Track {
+	track := model.Track{
+		ID:        id,
+		Attrs:     cloneTrackAttrs(&r.attrs),
+		Rels:      cloneTrackRels(&r.rels),
+		Revision:  r.revision,
+		DeletedAt: clonePtr(r.deletedAt),
+	}
+
+	if r.audio != nil {
+		track.Meta = &model.TrackMeta{Audio: clonePtr(r.audio)}
+	}
+	return &track
+}
+
+// cloneTrackRels makes a deep copy of the relationships, with artists never nil.
+func cloneTrackRels(rels *model.TrackRels) model.TrackRels {
+	return model.TrackRels{
+		AlbumID:   rels.AlbumID,
+		ArtistIDs: append([]int{}, rels.ArtistIDs...),
+	}
+}
+
+// cloneTrackAttrs makes a deep copy of the attributes, with genres never nil.
+func cloneTrackAttrs(attrs *model.TrackAttrs) model.TrackAttrs {
+	c := *attrs
+	c.Duration = clonePtr(attrs.Duration)
+	c.TrackNumber = clonePtr(attrs.TrackNumber)
+	c.DiscNumber = clonePtr(attrs.DiscNumber)
+	c.Year = clonePtr(attrs.Year)
+	c.BPM = clonePtr(attrs.BPM)
+	c.Genres = append([]string{}, attrs.Genres...)
+	return c
+}
+
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
+
+func (s *Store) CreateTrack(ctx context.Context, track *model.Track) (*model.Track, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	if !s.hasTrackRels(&track.Rels) {
+		return nil, model.ErrRelatedNotFound
+	}
+
+	row := newTrackRow(track, 1)
+	id := s.tracks.insert(row)
+	return row.track(id), nil
+}
+
+func (s *Store) CreateTracks(ctx context.Context, tracks []model.Track) ([]model.Track, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	var errs model.ItemErrors
+	for i := range tracks {
+		if !s.hasTrackRels(&tracks[i].Rels) {
+			errs = append(errs, model.ItemError{Index: i, Err: model.ErrRelatedNotFound})
+		}
+	}
+	if errs != nil {
+		return nil, errs
+	}
+
+	created := make([]model.Track, len(tracks))
+	for i := range tracks {
+		row := newTrackRow(&tracks[i], 1)
+		id := s.tracks.insert(row)
+		created[i] = *row.track(id)
+	}
+	return created, nil
+}
+
+func (s *Store) GetTrack(ctx context.Context, id int) (*model.Track, error) {
+	if err := s.rlock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.RUnlock()
+
+	row, ok := s.getTrack(id)
+	if !ok {
+		return nil, model.ErrNotFound
+	}
+	return row.track(id), nil
+}
+
+func (s *Store) GetTrashedTrack(ctx context.Context, id int) (*model.Track, error) {
+	if err := s.rlock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.RUnlock()
+
+	row, ok := s.tracks.get(id)
+	if !ok || row.deletedAt == nil {
+		return nil, model.ErrNotFound
+	}
+	return row.track(id), nil
+}
+
+func (s *Store) GetTracksByIDs(ctx context.Context, ids []int) ([]model.Track, error) {
+	if err := s.rlock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.RUnlock()
+
+	tracks := []model.Track{}
+	for _, id := range s.tracks.existing(ids) {
+		if row, ok := s.getTrack(id); ok {
+			tracks = append(tracks, *row.track(id))
+		}
+	}
+	return tracks, nil
+}
+
+// getTrack looks up a track that is not in the trash.
+func (s *Store) getTrack(id int) (trackRow, bool) {
+	row, ok := s.tracks.get(id)
+	if !ok || row.deletedAt != nil {
+		return trackRow{}, false
+	}
+	return row, true
+}
+
+// hasTracks checks that all of the specified tracks exist and are not in the trash.
+func (s *Store) hasTracks(ids []int) bool {
+	for _, id := range ids {
+		if _, ok := s.getTrack(id); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Store) GetTracks(ctx context.Context, query *model.TrackQuery) (*model.TrackPage, error) {
+	compare, err := compareTracks(query.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.rlock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.RUnlock()
+
+	var tracks []*model.Track
+	for _, id := range s.tracks.ids() {
+		row := s.tracks.rows[id]
+		if (row.deletedAt != nil) == query.Trashed && matchesFilter(&row.attrs, &query.Filter) {
+			tracks = append(tracks, row.track(id))
+		}
+	}
+	slices.SortFunc(tracks, compare)
+
+	page := model.TrackPage{Tracks: []model.Track{}, Total: len(tracks)}
+	if query.After > 0 {
+		tracks = s.tracksAfter(tracks, query.After, query.Sort, compare)
+	}
+
+	tracks = tracks[min(query.Offset, len(tracks)):]
+	if query.Limit > 0 {
+		tracks = tracks[:min(query.Limit, len(tracks))]
+	}
+
+	for _, track := range tracks {
+		page.Tracks = append(page.Tracks, *track)
+	}
+	return &page, nil
+}
+
+// tracksAfter selects the sorted tracks that come after the track with the specified ID in the sort order.
+func (s *Store) tracksAfter(tracks []*model.Track, id int, order []model.TrackOrder, compare func(a, b *model.Track) int) []*model.Track {
+	cursor := &model.Track{ID: id}
+	if row, ok := s.tracks.get(id); ok {
+		cursor = row.track(id)
+	} else if slices.ContainsFunc(order, func(o model.TrackOrder) bool { return o.Field != model.TrackID }) {
+		// Without the track, there is nothing to compare the other fields against
+		return nil
+	}
+
+	i := slices.IndexFunc(tracks, func(t *model.Track) bool {
+		return compare(t, cursor) > 0
+	})
+	if i < 0 {
+		return nil
+	}
+	return tracks[i:]
+}
+
+func matchesFilter(attrs *model.TrackAttrs, filter *model.TrackFilter) bool {
+	if filter.Title != "" && !strings.Contains(strings.ToLower(attrs.Title), strings.ToLower(filter.Title)) {
+		return false
+	}
+	return true
+}
+
+// compareTracks makes a comparison function for the sort order, making sure the order is total by falling back to track IDs.
+func compareTracks(order []model.TrackOrder) (func(a, b *model.Track) int, error) {
+	var fields []func(a, b *model.Track) int
+	for _, o := range order {
+		var f func(a, b *model.Track) int
+		switch o.Field {
+		case model.TrackID:
+			f = func(a, b *model.Track) int { return cmp.Compare(a.ID, b.ID) }
+		case model.TrackTitle:
+			// Byte by byte, as with the binary collation of the database stores
+			f = func(a, b *model.Track) int { return strings.Compare(a.Attrs.Title, b.Attrs.Title) }
+		default:
+			return nil, fmt.Errorf("unknown track field %q", o.Field)
+		}
+
+		if o.Desc {
+			asc := f
+			f = func(a, b *model.Track) int { return -asc(a, b) }
+		}
+		fields = append(fields, f)
+	}
+
+	fields = append(fields, func(a, b *model.Track) int { return cmp.Compare(a.ID, b.ID) })
+	return func(a, b *model.Track) int {
+		for _, f := range fields {
+			if c := f(a, b); c != 0 {
+				return c
+			}
+		}
+		return 0
+	}, nil
+}
+
+func (s *Store) UpdateTrack(ctx context.Context, track *model.Track) (*model.Track, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	old, ok := s.getTrack(track.ID)
+	if !ok {
+		return nil, model.ErrNotFound
+	}
+
+	if !s.hasTrackRels(&track.Rels) {
+		return nil, model.ErrRelatedNotFound
+	}
+
+	row := newTrackRow(track, old.revision+1)
+	row.audio = old.audio
+	s.tracks.update(track.ID, row)
+	return row.track(track.ID), nil
+}
+
+func (s *Store) SetTrackAudio(ctx context.Context, id int, audio *model.Audio) (*model.Track, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	row, ok := s.getTrack(id)
+	if !ok {
+		return nil, model.ErrNotFound
+	}
+
+	row.revision++
+	row.audio = clonePtr(audio)
+	s.tracks.update(id, row)
+	return row.track(id), nil
+}
+
+func (s *Store) AudioInUse(ctx context.Context, sha256 string) (bool, error) {
+	if err := s.rlock(ctx); err != nil {
+		return false, err
+	}
+	defer s.mu.RUnlock()
+
+	for _, row := range s.tracks.rows {
+		if row.audio != nil && row.audio.SHA256 == sha256 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *Store) DeleteTrack(ctx context.Context, id int) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	if _, ok := s.getTrack(id); !ok {
+		return model.ErrNotFound
+	}
+
+	s.trashTracks([]int{id})
+	return nil
+}
+
+func (s *Store) DeleteTracks(ctx context.Context, ids []int) error {
+	if err := s.lock(ctx); err != nil {
+		return err
+	}
+	defer s.mu.Unlock()
+
+	var errs model.ItemErrors
+	for i, id := range ids {
+		if _, ok := s.getTrack(id); !ok {
+			errs = append(errs, model.ItemError{Index: i, Err: model.ErrNotFound})
+		}
+	}
+	if errs != nil {
+		return errs
+	}
+
+	s.trashTracks(ids)
+	return nil
+}
+
+// trashTracks moves existing tracks to the trash.
+func (s *Store) trashTracks(ids []int) {
+	now := time.Now()
+	for _, id := range ids {
+		row, _ := s.tracks.get(id)
+		row.deletedAt = &now
+		s.tracks.update(id, row)
+	}
+}
+
+func (s *Store) RestoreTrack(ctx context.Context, id int) (*model.Track, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	row, ok := s.tracks.get(id)
+	if !ok || row.deletedAt == nil {
+		return nil, model.ErrNotFound
+	}
+
+	row.revision++
+	row.deletedAt = nil
+	s.tracks.update(id, row)
+	return row.track(id), nil
+}
+
+func (s *Store) PurgeTracks(ctx context.Context, before time.Time, limit int) ([]model.Track, error) {
+	if err := s.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer s.mu.Unlock()
+
+	purged := []model.Track{}
+	for _, id := range s.tracks.ids() {
+		if limit > 0 && len(purged) == limit {
+			break
+		}
+		if row := s.tracks.rows[id]; row.deletedAt != nil && row.deletedAt.Before(before) {
+			purged = append(purged, *row.track(id))
+			s.tracks.delete(id)
+		}
+	}
+
+	// Remove all occurrences of the tracks from playlists
+	for _, playlistID := range s.playlists.ids() {
+		playlist := s.playlists.rows[playlistID]
+		trackIDs := slices.DeleteFunc(slices.Clone(playlist.trackIDs), func(trackID int) bool {
+			return slices.ContainsFunc(purged, func(t model.Track) bool { return t.ID == trackID })
+		})
+		if len(trackIDs) != len(playlist.trackIDs) {
+			playlist.trackIDs = trackIDs
+			s.playlists.update(playlistID, playlist)
+		}
+	}
+	return purged, nil
+}
+
+func (s *Store) hasTrackRels(rels *model.TrackRels) bool {
+	if rels.AlbumID != 0 && !s.albums.has(rels.AlbumID) {
+		return false
+	}
+	return s.artists.hasAll(rels.ArtistIDs)
+}
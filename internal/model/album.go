@@ -0,0 +1,28 @@
+package model
+
+import "context"
+
+type Album struct {
+	Type  TypeMember[albumType] `json:"type"`
+	ID    int                   `json:"id,string"`
+	Attrs AlbumAttrs            `json:"attributes"`
+}
+
+type AlbumAttrs struct {
+	Title string `json:"title"`
+}
+
+type AlbumStore interface {
+	CreateAlbum(context.Context, *AlbumAttrs) (*Album, error)
+	GetAlbum(context.Context, int) (*Album, error)
+	GetAlbums(context.Context) ([]Album, error)
+
+	// GetAlbumsByIDs returns the albums with the IDs in ascending order of their IDs, skipping the ones that do not exist.
+	GetAlbumsByIDs(ctx context.Context, ids []int) ([]Album, error)
+
+	UpdateAlbum(context.Context, int, *AlbumAttrs) (*Album, error)
+
+	// DeleteAlbum deletes an album, failing with [ErrConflict] if there are tracks on the album.
+	// Tracks in the trash count too until purged, as restoring them brings back their album.
+	DeleteAlbum(context.Context, int) error
+}
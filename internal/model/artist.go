@@ -0,0 +1,28 @@
+package model
+
+import "context"
+
+type Artist struct {
+	Type  TypeMember[artistType] `json:"type"`
+	ID    int                    `json:"id,string"`
+	Attrs ArtistAttrs            `json:"attributes"`
+}
+
+type ArtistAttrs struct {
+	Name string `json:"name"`
+}
+
+type ArtistStore interface {
+	CreateArtist(context.Context, *ArtistAttrs) (*Artist, error)
+	GetArtist(context.Context, int) (*Artist, error)
+	GetArtists(context.Context) ([]Artist, error)
+
+	// GetArtistsByIDs returns the artists with the IDs in ascending order of their IDs, skipping the ones that do not exist.
+	GetArtistsByIDs(ctx context.Context, ids []int) ([]Artist, error)
+
+	UpdateArtist(context.Context, int, *ArtistAttrs) (*Artist, error)
+
+	// DeleteArtist deletes an artist, failing with [ErrConflict] if there are tracks by the artist.
+	// Tracks in the trash count too until purged, as restoring them brings back their artists.
+	DeleteArtist(context.Context, int) error
+}
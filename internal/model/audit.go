@@ -0,0 +1,83 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// AuditEntry records a change made to a resource.
+type AuditEntry struct {
+	Type  TypeMember[auditEntryType] `json:"type"`
+	ID    int                        `json:"id,string"`
+	Attrs AuditAttrs                 `json:"attributes"`
+}
+
+type AuditAttrs struct {
+	// Time is when the change was made, which is assigned by stores and ignored in their input.
+	Time time.Time `json:"time"`
+
+	// Actor identifies who made the change, if known.
+	Actor string `json:"actor,omitempty"`
+
+	// RequestID identifies the request that made the change, if any.
+	RequestID string `json:"requestId,omitempty"`
+
+	Action   AuditAction `json:"action"`
+	Resource ResourceID  `json:"resource"`
+
+	// Changes maps JSON pointers to the changed members of the resource object to their values before and after the change.
+	Changes map[string]AuditChange `json:"changes"`
+}
+
+// AuditChange holds the JSON values of a resource member before and after a change, with nil meaning that the member was absent.
+type AuditChange struct {
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+// AuditAction is the kind of change recorded in an [AuditEntry].
+type AuditAction string
+
+const (
+	AuditCreate  AuditAction = "create"
+	AuditUpdate  AuditAction = "update"
+	AuditDelete  AuditAction = "delete"
+	AuditRestore AuditAction = "restore"
+	AuditPurge   AuditAction = "purge"
+)
+
+// AuditQuery selects a subset of audit entries, which are always ordered by their IDs.
+type AuditQuery struct {
+	// Resource, if its type is not empty, selects only entries for resources of the type, and for the resource with the ID if it is positive.
+	Resource ResourceID
+
+	// Since and Until, if not zero, select only entries made at or after and strictly before the respective times.
+	Since time.Time
+	Until time.Time
+
+	// Limit is the maximum number of entries to select, or zero if there is no limit.
+	Limit int
+
+	// Offset is the number of entries to skip.
+	Offset int
+
+	// After, if positive, selects only entries with greater IDs.
+	After int
+}
+
+// AuditPage is the result of an [AuditQuery].
+type AuditPage struct {
+	Entries []AuditEntry
+
+	// Total is the number of entries matching the query regardless of pagination.
+	Total int
+}
+
+// AuditStore manages the audit log, which can only be appended to.
+type AuditStore interface {
+	// AppendAudit appends an entry to the audit log, assigning it a new ID and the current time.
+	AppendAudit(context.Context, *AuditEntry) (*AuditEntry, error)
+
+	GetAudit(context.Context, *AuditQuery) (*AuditPage, error)
+}
@@ -0,0 +1,32 @@
+package model
+
+import "encoding/json"
+
+// DuplicateGroup is a set of tracks that are likely to be copies of the same recording.
+//
+// Groups are computed from the tracks on request and are identified by the lowest ID of their tracks.
+type DuplicateGroup struct {
+	Type  TypeMember[duplicateGroupType] `json:"type"`
+	ID    int                            `json:"id,string"`
+	Attrs DuplicateAttrs                 `json:"attributes"`
+	Rels  DuplicateRels                  `json:"relationships"`
+}
+
+type DuplicateAttrs struct {
+	// Title is the normalized title of the first track of the group.
+	Title string `json:"title"`
+
+	// Similarity is the lowest similarity of titles linking the tracks into the group, from 0 to 1.
+	Similarity float64 `json:"similarity"`
+}
+
+// DuplicateRels describes relationships of a duplicate group with its tracks.
+type DuplicateRels struct {
+	// TrackIDs are IDs of the tracks of the group in ascending order.
+	TrackIDs []int
+}
+
+// MarshalJSON encodes the relationships as JSON:API relationship objects.
+func (r DuplicateRels) MarshalJSON() ([]byte, error) {
+	return json.Marshal(playlistRelsJSON{newToManyRel(TrackType, r.TrackIDs)})
+}
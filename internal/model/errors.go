@@ -1,5 +1,52 @@
 package model
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
-var ErrNotFound = errors.New("resource not found")
+var (
+	ErrNotFound = errors.New("resource not found")
+
+	// ErrRelatedNotFound is returned when a resource refers to another resource that does not exist.
+	ErrRelatedNotFound = errors.New("related resource not found")
+
+	// ErrConflict is returned when a change cannot be made because of the current state of resources,
+	// e.g. when deleting a resource that other resources still refer to.
+	ErrConflict = errors.New("resource conflict")
+)
+
+// ItemError reports the failure of an operation on a single resource out of several processed at once.
+type ItemError struct {
+	// Index is the position of the resource in the input of the operation.
+	Index int
+	Err   error
+}
+
+func (e *ItemError) Error() string {
+	return fmt.Sprintf("item %d: %v", e.Index, e.Err)
+}
+
+func (e *ItemError) Unwrap() error {
+	return e.Err
+}
+
+// ItemErrors lists all resources for which an operation on several resources at once failed, in the order of their indices.
+type ItemErrors []ItemError
+
+func (e ItemErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i := range e {
+		msgs[i] = e[i].Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e ItemErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i := range e {
+		errs[i] = &e[i]
+	}
+	return errs
+}
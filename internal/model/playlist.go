@@ -0,0 +1,43 @@
+package model
+
+import "context"
+
+type Playlist struct {
+	Type  TypeMember[playlistType] `json:"type"`
+	ID    int                      `json:"id,string"`
+	Attrs PlaylistAttrs            `json:"attributes"`
+	Rels  PlaylistRels             `json:"relationships"`
+}
+
+type PlaylistAttrs struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// PlaylistStore manages playlists, which are ordered lists of tracks that may contain the same track more than once.
+//
+// Tracks in the trash are left out of playlists, but keep their places in them to be back there when restored,
+// and are only removed from playlists for good when purged. Tracks in the trash cannot be added to playlists,
+// and replacing the tracks of a playlist leaves them where they are: the new tracks take the places of the listed ones
+// in order, with the ones in excess appended to the end.
+// Methods accepting track IDs return [ErrRelatedNotFound] if any of the tracks do not exist.
+type PlaylistStore interface {
+	CreatePlaylist(context.Context, *Playlist) (*Playlist, error)
+	GetPlaylist(context.Context, int) (*Playlist, error)
+	GetPlaylists(context.Context) ([]Playlist, error)
+
+	// UpdatePlaylist replaces the attributes and tracks of the playlist with the same ID.
+	UpdatePlaylist(context.Context, *Playlist) (*Playlist, error)
+
+	DeletePlaylist(context.Context, int) error
+
+	// InsertPlaylistTracks inserts tracks into a playlist before the track at the specified index.
+	// If the index is negative or past the end of the playlist, the tracks are appended.
+	InsertPlaylistTracks(ctx context.Context, id int, index int, trackIDs []int) (*Playlist, error)
+
+	// SetPlaylistTracks replaces all tracks of a playlist, e.g. to reorder them.
+	SetPlaylistTracks(ctx context.Context, id int, trackIDs []int) (*Playlist, error)
+
+	// RemovePlaylistTracks removes all occurrences of the specified tracks from a playlist.
+	RemovePlaylistTracks(ctx context.Context, id int, trackIDs []int) (*Playlist, error)
+}
@@ -0,0 +1,149 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+// Resource types as they appear in resource identifiers.
+const (
+	TrackType    = "tracks"
+	ArtistType   = "artists"
+	AlbumType    = "albums"
+	PlaylistType = "playlists"
+
+	AuditEntryType     = "auditEntries"
+	DuplicateGroupType = "duplicateGroups"
+)
+
+// ResourceID identifies a resource of some type.
+type ResourceID struct {
+	Type string `json:"type"`
+	ID   int    `json:"id,string"`
+}
+
+type toOneRel struct {
+	Data *ResourceID `json:"data"`
+}
+
+type toManyRel struct {
+	Data []ResourceID `json:"data"`
+}
+
+func newToManyRel(typ string, ids []int) *toManyRel {
+	rel := toManyRel{Data: make([]ResourceID, len(ids))}
+	for i, id := range ids {
+		rel.Data[i] = ResourceID{typ, id}
+	}
+	return &rel
+}
+
+// resourceIDs extracts IDs from resource identifiers, checking that all of them are of the specified type.
+func resourceIDs(typ string, refs []ResourceID) ([]int, error) {
+	ids := make([]int, len(refs))
+	for i, ref := range refs {
+		if ref.Type != typ {
+			return nil, resourceTypeError(ref.Type)
+		}
+		ids[i] = ref.ID
+	}
+	return ids, nil
+}
+
+// TrackRels describes relationships of a track with other resources.
+type TrackRels struct {
+	// AlbumID is the ID of the album the track is on, or zero if there is none.
+	AlbumID int
+
+	// ArtistIDs are IDs of the track artists, in order of appearance.
+	ArtistIDs []int
+}
+
+type trackRelsJSON struct {
+	Album   *toOneRel  `json:"album,omitempty"`
+	Artists *toManyRel `json:"artists,omitempty"`
+}
+
+// MarshalJSON encodes the relationships as JSON:API relationship objects.
+func (r TrackRels) MarshalJSON() ([]byte, error) {
+	album := toOneRel{}
+	if r.AlbumID != 0 {
+		album.Data = &ResourceID{AlbumType, r.AlbumID}
+	}
+	return json.Marshal(trackRelsJSON{&album, newToManyRel(ArtistType, r.ArtistIDs)})
+}
+
+// UnmarshalJSON decodes JSON:API relationship objects, leaving relationships absent from the input untouched.
+func (r *TrackRels) UnmarshalJSON(data []byte) error {
+	var rels trackRelsJSON
+	if err := decodeStrict(data, &rels); err != nil {
+		return err
+	}
+
+	if rels.Album != nil {
+		r.AlbumID = 0
+		if album := rels.Album.Data; album != nil {
+			if album.Type != AlbumType {
+				return resourceTypeError(album.Type)
+			}
+			r.AlbumID = album.ID
+		}
+	}
+
+	if rels.Artists != nil {
+		ids, err := resourceIDs(ArtistType, rels.Artists.Data)
+		if err != nil {
+			return err
+		}
+		r.ArtistIDs = ids
+	}
+
+	return nil
+}
+
+// PlaylistRels describes relationships of a playlist with other resources.
+type PlaylistRels struct {
+	// TrackIDs are IDs of the playlist tracks in playback order, possibly with repetitions.
+	TrackIDs []int
+}
+
+type playlistRelsJSON struct {
+	Tracks *toManyRel `json:"tracks,omitempty"`
+}
+
+// MarshalJSON encodes the relationships as JSON:API relationship objects.
+func (r PlaylistRels) MarshalJSON() ([]byte, error) {
+	return json.Marshal(playlistRelsJSON{newToManyRel(TrackType, r.TrackIDs)})
+}
+
+// UnmarshalJSON decodes JSON:API relationship objects, leaving relationships absent from the input untouched.
+func (r *PlaylistRels) UnmarshalJSON(data []byte) error {
+	var rels playlistRelsJSON
+	if err := decodeStrict(data, &rels); err != nil {
+		return err
+	}
+
+	if rels.Tracks != nil {
+		ids, err := resourceIDs(TrackType, rels.Tracks.Data)
+		if err != nil {
+			return err
+		}
+		r.TrackIDs = ids
+	}
+
+	return nil
+}
+
+func decodeStrict(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+func resourceTypeError(typ string) error {
+	return &json.UnmarshalTypeError{
+		Value: "resource type " + typ,
+		Type:  reflect.TypeFor[ResourceID](),
+	}
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// TypeMember is the type member of a resource object, with the resource type fixed by N.
+//
+// The zero value always encodes as the resource type, and decoding checks that the type matches it.
+type TypeMember[N typeName] struct{}
+
+type typeName interface {
+	typeName() string
+}
+
+type (
+	trackType      struct{}
+	artistType     struct{}
+	albumType      struct{}
+	playlistType   struct{}
+	auditEntryType struct{}
+
+	duplicateGroupType struct{}
+)
+
+func (trackType) typeName() string      { return TrackType }
+func (artistType) typeName() string     { return ArtistType }
+func (albumType) typeName() string      { return AlbumType }
+func (playlistType) typeName() string   { return PlaylistType }
+func (auditEntryType) typeName() string { return AuditEntryType }
+
+func (duplicateGroupType) typeName() string { return DuplicateGroupType }
+
+// String returns the resource type.
+func (TypeMember[N]) String() string {
+	var n N
+	return n.typeName()
+}
+
+func (t TypeMember[N]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// UnmarshalJSON checks that the resource type matches the expected one, reporting a [TypeMismatchError] otherwise.
+func (t *TypeMember[N]) UnmarshalJSON(data []byte) error {
+	var typ string
+	if err := json.Unmarshal(data, &typ); err != nil {
+		return err
+	}
+
+	if typ != t.String() {
+		return &TypeMismatchError{Want: t.String(), Got: typ}
+	}
+	return nil
+}
+
+// TypeMismatchError is returned when decoding a resource object of an unexpected type.
+type TypeMismatchError struct {
+	Want string
+	Got  string
+}
+
+func (e *TypeMismatchError) Error() string {
+	return fmt.Sprintf("expected a resource of type %q, got %q", e.Want, e.Got)
+}
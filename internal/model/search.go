@@ -0,0 +1,47 @@
+package model
+
+import "context"
+
+// SearchQuery selects the resources matching a full-text query, ordered by relevance.
+type SearchQuery struct {
+	// Text is the query, every word of which must be a prefix of some word of a resource, ignoring case and accents.
+	Text string
+
+	// Types, if not empty, lists the only resource types to search for.
+	Types []string
+
+	// Limit is the maximum number of resources to select, or zero if there is no limit.
+	Limit int
+
+	// Offset is the number of resources to skip.
+	Offset int
+}
+
+// SearchableTypes lists the types of resources that can be searched for, in the order they are ranked among equally relevant ones.
+var SearchableTypes = []string{TrackType, ArtistType, AlbumType}
+
+// SearchHit is a resource matching a [SearchQuery].
+type SearchHit struct {
+	// Resource is a *[Track], *[Artist] or *[Album].
+	Resource any
+
+	// Score ranks the relevance of the resource, with greater values for more relevant ones.
+	// Scores are only comparable within the results of a single store.
+	Score float64
+}
+
+// SearchPage is the result of a [SearchQuery].
+type SearchPage struct {
+	Hits []SearchHit
+
+	// Total is the number of resources matching the query regardless of pagination.
+	Total int
+}
+
+// SearchStore searches for tracks, artists and albums by their text.
+//
+// Tracks are matched by their titles, genres and comments, ignoring tracks in the trash.
+// Artists are matched by their names, and albums by their titles.
+type SearchStore interface {
+	Search(context.Context, *SearchQuery) (*SearchPage, error)
+}
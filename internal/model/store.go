@@ -0,0 +1,31 @@
+package model
+
+import (
+	"context"
+	"io"
+)
+
+// Store provides access to all resources of a music library.
+type Store interface {
+	io.Closer
+
+	TrackStore
+	ArtistStore
+	AlbumStore
+	PlaylistStore
+	AuditStore
+	SearchStore
+
+	// RunInTx runs f in a transaction, committing all changes made through the stores passed to f only if f succeeds.
+	// Transactions that conflict with concurrent ones may be retried, so f may be run more than once and should have no other side effects.
+	RunInTx(ctx context.Context, f func(TxStores) error) error
+}
+
+// TxStores provides access to all resources of a music library within a transaction.
+type TxStores interface {
+	TrackStore
+	ArtistStore
+	AlbumStore
+	PlaylistStore
+	AuditStore
+}
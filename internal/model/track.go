@@ -2,23 +2,163 @@ package model
 
 import (
 	"context"
-	"io"
+	"time"
 )
 
 type Track struct {
-	ID    int        `json:"id,string"`
-	Attrs TrackAttrs `json:"attributes"`
+	Type  TypeMember[trackType] `json:"type"`
+	ID    int                   `json:"id,string"`
+	Attrs TrackAttrs            `json:"attributes"`
+	Rels  TrackRels             `json:"relationships"`
+
+	// Revision counts the versions of the track, starting from 1 and increasing with every update.
+	// It is assigned by stores and ignored in their input.
+	Revision int `json:"-"`
+
+	// DeletedAt is when the track was moved to the trash, or nil if it is not there.
+	// It is assigned by stores and ignored in their input.
+	DeletedAt *time.Time `json:"-"`
+
+	// Meta describes the track beyond its attributes, or is nil if there is nothing to describe.
+	// It is assigned by stores and ignored in their input.
+	Meta *TrackMeta `json:"meta,omitempty"`
+}
+
+type TrackMeta struct {
+	// Audio describes the audio file of the track, if it has one.
+	Audio *Audio `json:"audio,omitempty"`
+}
+
+// Audio describes an audio file stored for a track.
+type Audio struct {
+	ContentType string `json:"contentType"`
+
+	// Size is the length of the file in bytes.
+	Size int64 `json:"size"`
+
+	// SHA256 is the hex-encoded SHA-256 checksum of the file, which also identifies it in blob storage.
+	SHA256 string `json:"sha256"`
 }
 
 type TrackAttrs struct {
 	Title string `json:"title"`
+
+	// Duration is the length of the track in milliseconds.
+	Duration *int `json:"duration,omitempty"`
+
+	TrackNumber *int `json:"trackNumber,omitempty"`
+	DiscNumber  *int `json:"discNumber,omitempty"`
+	Year        *int `json:"year,omitempty"`
+
+	Genres []string `json:"genres,omitempty"`
+
+	// ISRC is the International Standard Recording Code of the track, without hyphens.
+	ISRC string `json:"isrc,omitempty"`
+
+	// BPM is the tempo of the track in beats per minute.
+	BPM *float64 `json:"bpm,omitempty"`
+
+	Explicit bool   `json:"explicit"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// TrackQuery selects a subset of tracks.
+type TrackQuery struct {
+	Filter TrackFilter
+
+	// Sort specifies the order of the selected tracks, which are always finally ordered by their IDs.
+	Sort []TrackOrder
+
+	// Limit is the maximum number of tracks to select, or zero if there is no limit.
+	Limit int
+
+	// Offset is the number of tracks to skip.
+	Offset int
+
+	// After, if positive, selects only tracks that come after the track with the specified ID in the sort order.
+	After int
+
+	// Trashed selects the tracks in the trash instead of all other tracks.
+	Trashed bool
+}
+
+// TrackFilter restricts tracks to those matching all of the specified criteria.
+type TrackFilter struct {
+	// Title, if not empty, matches tracks with titles containing the string, ignoring the case of ASCII letters.
+	// Whether the case of other letters is ignored depends on the store.
+	Title string
+}
+
+// TrackOrder specifies a field to sort tracks by.
+type TrackOrder struct {
+	Field TrackField
+	Desc  bool
+}
+
+// TrackField identifies a sortable track field.
+//
+// Text fields are sorted by the bytes of their values, with uppercase ASCII letters before lowercase ones,
+// so that tracks are in the same order in every store, whatever the collation of its database.
+type TrackField string
+
+const (
+	TrackID    TrackField = "id"
+	TrackTitle TrackField = "title"
+)
+
+// TrackPage is the result of a [TrackQuery].
+type TrackPage struct {
+	Tracks []Track
+
+	// Total is the number of tracks matching the filter regardless of pagination.
+	Total int
 }
 
+// TrackStore manages tracks, which are moved to the trash on deletion and can be restored from there until purged.
+//
+// Tracks in the trash are hidden from all methods except [TrackStore.RestoreTrack] and [TrackStore.PurgeTracks],
+// and from queries other than those for trashed tracks, but they still refer to their artists and album.
 type TrackStore interface {
-	io.Closer
+	// CreateTrack creates a new track with a newly assigned ID.
+	// If the track refers to artists or an album that do not exist, [ErrRelatedNotFound] is returned.
+	CreateTrack(context.Context, *Track) (*Track, error)
+
+	// CreateTracks creates several tracks at once, either all of them or none, returning them in the same order.
+	// If some of the tracks refer to artists or an album that do not exist, [ItemErrors] wrapping [ErrRelatedNotFound] are returned.
+	CreateTracks(context.Context, []Track) ([]Track, error)
 
-	CreateTrack(context.Context, *TrackAttrs) (*Track, error)
 	GetTrack(context.Context, int) (*Track, error)
-	GetTracks(context.Context) ([]Track, error)
+	GetTracks(context.Context, *TrackQuery) (*TrackPage, error)
+
+	// GetTracksByIDs returns the tracks with the IDs in ascending order of their IDs, skipping the ones that do not exist or are in the trash.
+	GetTracksByIDs(ctx context.Context, ids []int) ([]Track, error)
+
+	// UpdateTrack replaces the attributes and relationships of the track with the same ID.
+	// If the track refers to artists or an album that do not exist, [ErrRelatedNotFound] is returned.
+	UpdateTrack(context.Context, *Track) (*Track, error)
+
+	// SetTrackAudio attaches an audio file to the track, replacing any previous one, and makes a new revision of the track.
+	SetTrackAudio(ctx context.Context, id int, audio *Audio) (*Track, error)
+
+	// AudioInUse reports whether any track, including ones in the trash, has the audio file with the checksum.
+	AudioInUse(ctx context.Context, sha256 string) (bool, error)
+
+	// DeleteTrack moves the track to the trash.
 	DeleteTrack(context.Context, int) error
+
+	// DeleteTracks moves several tracks to the trash at once, either all of them or none.
+	// If some of the tracks do not exist, [ItemErrors] wrapping [ErrNotFound] are returned.
+	DeleteTracks(context.Context, []int) error
+
+	// GetTrashedTrack returns the track with the ID if it is in the trash, or [ErrNotFound] otherwise.
+	GetTrashedTrack(context.Context, int) (*Track, error)
+
+	// RestoreTrack moves the track out of the trash, making a new revision of it.
+	// If the track is not in the trash, [ErrNotFound] is returned.
+	RestoreTrack(context.Context, int) (*Track, error)
+
+	// PurgeTracks permanently deletes the tracks moved to the trash before the specified time, returning them as they were.
+	// At most limit tracks with the lowest IDs are purged at once, or all of them if the limit is zero.
+	// Anything the tracks refer to outside of the store, such as their audio files, is left for the caller to clean up.
+	PurgeTracks(ctx context.Context, before time.Time, limit int) ([]Track, error)
 }
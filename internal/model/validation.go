@@ -0,0 +1,97 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	MinYear = 1000
+	MaxYear = 9999
+
+	MaxBPM           = 1000
+	MaxCommentLength = 4096
+)
+
+var isrcPattern = regexp.MustCompile(`^[A-Z]{2}[A-Z0-9]{3}[0-9]{7}$`)
+
+// FieldError describes an invalid value of a resource field.
+type FieldError struct {
+	// Field is the name of the field as it appears in the resource attributes.
+	Field string
+	Msg   string
+}
+
+// ValidationError lists all invalid fields of a resource.
+type ValidationError []FieldError
+
+func (e ValidationError) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fmt.Sprintf("%s: %s", fe.Field, fe.Msg)
+	}
+	return "invalid fields: " + strings.Join(msgs, "; ")
+}
+
+// Validate checks that the track attributes have sensible values, returning a [ValidationError] if they do not.
+func (a *TrackAttrs) Validate() error {
+	var errs ValidationError
+	check := func(ok bool, field, msg string) {
+		if !ok {
+			errs = append(errs, FieldError{field, msg})
+		}
+	}
+
+	check(strings.TrimSpace(a.Title) != "", "title", "The title must not be empty")
+
+	if a.Duration != nil {
+		check(*a.Duration >= 0, "duration", "The duration must not be negative")
+	}
+
+	if a.TrackNumber != nil {
+		check(*a.TrackNumber >= 1, "trackNumber", "The track number must be a positive integer")
+	}
+
+	if a.DiscNumber != nil {
+		check(*a.DiscNumber >= 1, "discNumber", "The disc number must be a positive integer")
+	}
+
+	if a.Year != nil {
+		check(*a.Year >= MinYear && *a.Year <= MaxYear, "year",
+			fmt.Sprintf("The year must be between %d and %d", MinYear, MaxYear),
+		)
+	}
+
+	seen := make(map[string]bool)
+	for _, genre := range a.Genres {
+		key := strings.ToLower(strings.TrimSpace(genre))
+		if key == "" {
+			check(false, "genres", "Genres must not be empty")
+			break
+		}
+
+		if seen[key] {
+			check(false, "genres", fmt.Sprintf("The genre '%s' is listed more than once", genre))
+			break
+		}
+		seen[key] = true
+	}
+
+	if a.ISRC != "" {
+		check(isrcPattern.MatchString(a.ISRC), "isrc", "The ISRC must consist of 12 uppercase letters and digits, e.g. 'USRC17607839'")
+	}
+
+	if a.BPM != nil {
+		check(*a.BPM > 0 && *a.BPM <= MaxBPM, "bpm", fmt.Sprintf("The BPM must be greater than 0 and not exceed %d", MaxBPM))
+	}
+
+	check(len(a.Comment) <= MaxCommentLength, "comment",
+		fmt.Sprintf("The comment must not be longer than %d bytes", MaxCommentLength),
+	)
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package playlistfmt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// M3U8 is the extended M3U format with UTF-8 encoding.
+var M3U8 = &Format{
+	Name:      "M3U8",
+	MediaType: "application/vnd.apple.mpegurl",
+	Aliases:   []string{"audio/mpegurl", "audio/x-mpegurl", "application/x-mpegurl"},
+	Encode:    encodeM3U8,
+	Decode:    decodeM3U8,
+}
+
+func encodeM3U8(w io.Writer, p *Playlist) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "#EXTM3U")
+	if p.Title != "" {
+		fmt.Fprintf(bw, "#PLAYLIST:%s\n", oneLine(p.Title))
+	}
+
+	for i := range p.Entries {
+		e := &p.Entries[i]
+		fmt.Fprintf(bw, "#EXTINF:%d,%s\n", seconds(e.Duration), oneLine(displayTitle(e)))
+		if e.Album != "" {
+			fmt.Fprintf(bw, "#EXTALB:%s\n", oneLine(e.Album))
+		}
+		fmt.Fprintln(bw, oneLine(e.Location))
+	}
+
+	return bw.Flush()
+}
+
+func decodeM3U8(r io.Reader) (*Playlist, error) {
+	var p Playlist
+	var e Entry
+
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if n == 1 {
+			line = strings.TrimPrefix(line, "\uFEFF")
+		}
+
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#EXTINF:"):
+			info, title, ok := strings.Cut(strings.TrimPrefix(line, "#EXTINF:"), ",")
+			if !ok {
+				return nil, fmt.Errorf("line %d: malformed #EXTINF directive", n)
+			}
+
+			// Skip optional attributes following the duration
+			info, _, _ = strings.Cut(info, " ")
+			secs, err := strconv.ParseFloat(info, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid duration %q", n, info)
+			}
+
+			e.Duration = fromSeconds(secs)
+			parseDisplayTitle(title, &e)
+		case strings.HasPrefix(line, "#PLAYLIST:"):
+			p.Title = strings.TrimSpace(strings.TrimPrefix(line, "#PLAYLIST:"))
+		case strings.HasPrefix(line, "#EXTALB:"):
+			e.Album = strings.TrimSpace(strings.TrimPrefix(line, "#EXTALB:"))
+		case strings.HasPrefix(line, "#"):
+			// Ignore comments and unsupported directives
+		default:
+			e.Location = line
+			p.Entries = append(p.Entries, e)
+			e = Entry{}
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// oneLine prevents a value from breaking the line-oriented structure of the file.
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
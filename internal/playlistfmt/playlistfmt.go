@@ -0,0 +1,97 @@
+// Package playlistfmt implements reading and writing of common playlist file formats.
+package playlistfmt
+
+import (
+	"io"
+	"strings"
+	"time"
+)
+
+// Playlist is a format-neutral representation of a playlist file.
+type Playlist struct {
+	Title   string
+	Entries []Entry
+}
+
+// Entry describes a single media item of a playlist.
+type Entry struct {
+	// Location is a URI or a file path referring to the media.
+	Location string
+
+	Title   string
+	Creator string
+	Album   string
+
+	// Duration is the playback length of the media, or zero if unknown.
+	Duration time.Duration
+
+	// TrackNumber is the position of the media on its album, or zero if unknown.
+	TrackNumber int
+}
+
+// Format describes how to read and write playlists of a specific format.
+type Format struct {
+	// Name is a short human-readable name of the format.
+	Name string
+
+	// MediaType is the preferred media type of the format.
+	MediaType string
+
+	// Aliases are other media types the format is known under.
+	Aliases []string
+
+	Encode func(w io.Writer, p *Playlist) error
+	Decode func(r io.Reader) (*Playlist, error)
+}
+
+// Formats lists all supported playlist formats.
+var Formats = []*Format{M3U8, XSPF, PLS}
+
+// ByMediaType looks up a format by one of its media types.
+func ByMediaType(mediaType string) (*Format, bool) {
+	for _, f := range Formats {
+		if strings.EqualFold(f.MediaType, mediaType) {
+			return f, true
+		}
+
+		for _, alias := range f.Aliases {
+			if strings.EqualFold(alias, mediaType) {
+				return f, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// displayTitle combines the creator and the title of an entry in the form commonly used by players.
+func displayTitle(e *Entry) string {
+	if e.Creator == "" {
+		return e.Title
+	}
+	return e.Creator + " - " + e.Title
+}
+
+// parseDisplayTitle is the inverse of [displayTitle].
+func parseDisplayTitle(s string, e *Entry) {
+	if creator, title, ok := strings.Cut(s, " - "); ok {
+		e.Creator, e.Title = strings.TrimSpace(creator), strings.TrimSpace(title)
+	} else {
+		e.Title = strings.TrimSpace(s)
+	}
+}
+
+// seconds converts the duration to whole seconds, with -1 denoting an unknown duration.
+func seconds(d time.Duration) int64 {
+	if d <= 0 {
+		return -1
+	}
+	return int64(d.Round(time.Second) / time.Second)
+}
+
+// fromSeconds is the inverse of [seconds].
+func fromSeconds(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+	return time.Duration(s * float64(time.Second)).Round(time.Millisecond)
+}
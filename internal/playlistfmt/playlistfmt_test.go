@@ -0,0 +1,113 @@
+package playlistfmt_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cerfical/muzik/internal/playlistfmt"
+	"github.com/stretchr/testify/suite"
+)
+
+var samplePlaylist = playlistfmt.Playlist{
+	Title: "Example Playlist",
+	Entries: []playlistfmt.Entry{
+		{
+			Location: "http://127.0.0.1:8080/api/tracks/1",
+			Title:    "Example Track #1",
+			Creator:  "Example Artist",
+			Duration: 253 * time.Second,
+		},
+		{
+			Location: "/music/track2.mp3",
+			Title:    "Example Track #2",
+		},
+	},
+}
+
+func TestPlaylistFormats(t *testing.T) {
+	suite.Run(t, new(PlaylistFormatsTest))
+}
+
+type PlaylistFormatsTest struct {
+	suite.Suite
+}
+
+func (t *PlaylistFormatsTest) TestRoundTrip() {
+	for _, f := range playlistfmt.Formats {
+		t.Run(f.Name, func() {
+			var buf bytes.Buffer
+			t.Require().NoError(f.Encode(&buf, &samplePlaylist))
+
+			p, err := f.Decode(&buf)
+			t.Require().NoError(err)
+			t.Equal(samplePlaylist.Entries, p.Entries)
+		})
+	}
+}
+
+func (t *PlaylistFormatsTest) TestDecode_Ok() {
+	tests := []struct {
+		name   string
+		format *playlistfmt.Format
+		input  string
+		want   []playlistfmt.Entry
+	}{
+		{"m3u8", playlistfmt.M3U8, "\uFEFF#EXTM3U\n#EXTINF:-1,Title\r\n#EXTALB:Album\ntrack.mp3\n\n# comment\nother.ogg\n", []playlistfmt.Entry{
+			{Location: "track.mp3", Title: "Title", Album: "Album"},
+			{Location: "other.ogg"},
+		}},
+		{"m3u8_without_header", playlistfmt.M3U8, "#EXTINF:12.5 tvg-id=\"x\",A - B\ntrack.mp3\n", []playlistfmt.Entry{
+			{Location: "track.mp3", Title: "B", Creator: "A", Duration: 12500 * time.Millisecond},
+		}},
+		{"pls", playlistfmt.PLS, "[playlist]\nFile2=b.mp3\nfile1=a.mp3\nTitle1=A\nLength1=-1\nNumberOfEntries=2\n", []playlistfmt.Entry{
+			{Location: "a.mp3", Title: "A"},
+			{Location: "b.mp3"},
+		}},
+		{"xspf", playlistfmt.XSPF, `<playlist version="1" xmlns="http://xspf.org/ns/0/"><trackList>
+			<track><location>a.mp3</location><title>A</title><trackNum>3</trackNum><duration>1500</duration></track>
+		</trackList></playlist>`, []playlistfmt.Entry{
+			{Location: "a.mp3", Title: "A", TrackNumber: 3, Duration: 1500 * time.Millisecond},
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func() {
+			p, err := test.format.Decode(strings.NewReader(test.input))
+			t.Require().NoError(err)
+			t.Equal(test.want, p.Entries)
+		})
+	}
+}
+
+func (t *PlaylistFormatsTest) TestDecode_Fail() {
+	tests := []struct {
+		name   string
+		format *playlistfmt.Format
+		input  string
+	}{
+		{"m3u8_bad_extinf", playlistfmt.M3U8, "#EXTINF:abc\ntrack.mp3\n"},
+		{"m3u8_bad_duration", playlistfmt.M3U8, "#EXTINF:abc,Title\ntrack.mp3\n"},
+		{"pls_no_section", playlistfmt.PLS, "File1=a.mp3\n"},
+		{"pls_missing_file", playlistfmt.PLS, "[playlist]\nTitle1=A\n"},
+		{"pls_bad_length", playlistfmt.PLS, "[playlist]\nFile1=a.mp3\nLength1=abc\n"},
+		{"xspf_wrong_namespace", playlistfmt.XSPF, `<playlist version="1"><trackList/></playlist>`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func() {
+			_, err := test.format.Decode(strings.NewReader(test.input))
+			t.Error(err)
+		})
+	}
+}
+
+func (t *PlaylistFormatsTest) TestByMediaType() {
+	f, ok := playlistfmt.ByMediaType("audio/x-mpegurl")
+	t.True(ok)
+	t.Equal(playlistfmt.M3U8, f)
+
+	_, ok = playlistfmt.ByMediaType("application/json")
+	t.False(ok)
+}
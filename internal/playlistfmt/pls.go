@@ -0,0 +1,114 @@
+package playlistfmt
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// PLS is the INI-based playlist format originally introduced by Winamp.
+var PLS = &Format{
+	Name:      "PLS",
+	MediaType: "audio/x-scpls",
+	Encode:    encodePLS,
+	Decode:    decodePLS,
+}
+
+func encodePLS(w io.Writer, p *Playlist) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "[playlist]")
+
+	for i := range p.Entries {
+		e := &p.Entries[i]
+		fmt.Fprintf(bw, "File%d=%s\n", i+1, oneLine(e.Location))
+		if title := displayTitle(e); title != "" {
+			fmt.Fprintf(bw, "Title%d=%s\n", i+1, oneLine(title))
+		}
+		fmt.Fprintf(bw, "Length%d=%d\n", i+1, seconds(e.Duration))
+	}
+
+	fmt.Fprintf(bw, "NumberOfEntries=%d\n", len(p.Entries))
+	fmt.Fprintln(bw, "Version=2")
+	return bw.Flush()
+}
+
+func decodePLS(r io.Reader) (*Playlist, error) {
+	entries := make(map[int]*Entry)
+	maxIndex := 0
+	inPlaylist := false
+
+	s := bufio.NewScanner(r)
+	for n := 1; s.Scan(); n++ {
+		line := strings.TrimSpace(s.Text())
+		if n == 1 {
+			line = strings.TrimPrefix(line, "\uFEFF")
+		}
+
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			inPlaylist = strings.EqualFold(line, "[playlist]")
+			continue
+		}
+
+		if !inPlaylist {
+			return nil, fmt.Errorf("line %d: expected the [playlist] section", n)
+		}
+
+		key, val, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected a key=value pair", n)
+		}
+		key, val = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(val)
+
+		name := strings.TrimRight(key, "0123456789")
+		if name == key {
+			// Global keys, such as NumberOfEntries and Version, carry no useful information
+			continue
+		}
+
+		i, err := strconv.Atoi(key[len(name):])
+		if err != nil || i < 1 {
+			return nil, fmt.Errorf("line %d: invalid entry number in %q", n, key)
+		}
+
+		e := entries[i]
+		if e == nil {
+			e = &Entry{}
+			entries[i] = e
+			maxIndex = max(maxIndex, i)
+		}
+
+		switch name {
+		case "file":
+			e.Location = val
+		case "title":
+			parseDisplayTitle(val, e)
+		case "length":
+			secs, err := strconv.ParseFloat(val, 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid length %q", n, val)
+			}
+			e.Duration = fromSeconds(secs)
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	var p Playlist
+	for i := 1; i <= maxIndex; i++ {
+		if e := entries[i]; e != nil {
+			if e.Location == "" {
+				return nil, fmt.Errorf("entry %d: missing the File%d key", i, i)
+			}
+			p.Entries = append(p.Entries, *e)
+		}
+	}
+	return &p, nil
+}
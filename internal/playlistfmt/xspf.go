@@ -0,0 +1,87 @@
+package playlistfmt
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// XSPF is the XML Shareable Playlist Format.
+var XSPF = &Format{
+	Name:      "XSPF",
+	MediaType: "application/xspf+xml",
+	Encode:    encodeXSPF,
+	Decode:    decodeXSPF,
+}
+
+type xspfPlaylist struct {
+	XMLName xml.Name    `xml:"http://xspf.org/ns/0/ playlist"`
+	Version string      `xml:"version,attr"`
+	Title   string      `xml:"title,omitempty"`
+	Tracks  []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location string `xml:"location,omitempty"`
+	Title    string `xml:"title,omitempty"`
+	Creator  string `xml:"creator,omitempty"`
+	Album    string `xml:"album,omitempty"`
+	TrackNum int    `xml:"trackNum,omitempty"`
+
+	// Duration is the track length in milliseconds.
+	Duration int64 `xml:"duration,omitempty"`
+}
+
+func encodeXSPF(w io.Writer, p *Playlist) error {
+	doc := xspfPlaylist{
+		Version: "1",
+		Title:   p.Title,
+		Tracks:  make([]xspfTrack, len(p.Entries)),
+	}
+
+	for i, e := range p.Entries {
+		doc.Tracks[i] = xspfTrack{
+			Location: e.Location,
+			Title:    e.Title,
+			Creator:  e.Creator,
+			Album:    e.Album,
+			TrackNum: e.TrackNumber,
+			Duration: e.Duration.Milliseconds(),
+		}
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func decodeXSPF(r io.Reader) (*Playlist, error) {
+	var doc xspfPlaylist
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	p := Playlist{
+		Title:   doc.Title,
+		Entries: make([]Entry, len(doc.Tracks)),
+	}
+
+	for i, t := range doc.Tracks {
+		p.Entries[i] = Entry{
+			Location:    t.Location,
+			Title:       t.Title,
+			Creator:     t.Creator,
+			Album:       t.Album,
+			TrackNumber: t.TrackNum,
+			Duration:    time.Duration(max(t.Duration, 0)) * time.Millisecond,
+		}
+	}
+	return &p, nil
+}
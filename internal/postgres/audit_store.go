@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/cerfical/muzik/internal/sqlstore"
+)
+
+// selectAudit selects audit log columns in the order expected by [scanAuditEntry].
+const selectAudit = `
+	SELECT id, logged_at, actor, request_id, action, resource_type, resource_id, changes
+	FROM audit_log`
+
+func scanAuditEntry(row scanner, entry *model.AuditEntry) error {
+	var changes []byte
+
+	attrs := &entry.Attrs
+	if err := row.Scan(
+		&entry.ID, &attrs.Time, &attrs.Actor, &attrs.RequestID, &attrs.Action, &attrs.Resource.Type, &attrs.Resource.ID, &changes,
+	); err != nil {
+		return err
+	}
+	return json.Unmarshal(changes, &attrs.Changes)
+}
+
+func (s *Store) AppendAudit(ctx context.Context, entry *model.AuditEntry) (*model.AuditEntry, error) {
+	changes, err := sqlstore.MarshalChanges(entry.Attrs.Changes)
+	if err != nil {
+		return nil, err
+	}
+
+	var appended model.AuditEntry
+	err = s.withTimeout(ctx, func(ctx context.Context) error {
+		attrs := &entry.Attrs
+		row := s.db.QueryRow(ctx, `
+			INSERT INTO audit_log(actor, request_id, action, resource_type, resource_id, changes)
+			VALUES($1, $2, $3, $4, $5, $6)
+			RETURNING id, logged_at, actor, request_id, action, resource_type, resource_id, changes`,
+			attrs.Actor, attrs.RequestID, attrs.Action, attrs.Resource.Type, attrs.Resource.ID, changes,
+		)
+		return scanAuditEntry(row, &appended)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return &appended, nil
+}
+
+func (s *Store) GetAudit(ctx context.Context, query *model.AuditQuery) (*model.AuditPage, error) {
+	filter := filterAudit(query)
+	sel := filter.Clone()
+	if query.After > 0 {
+		sel.AddCond("id > " + sel.Arg(query.After))
+	}
+
+	var limit *int
+	if query.Limit > 0 {
+		limit = &query.Limit
+	}
+
+	selQuery := fmt.Sprintf("%s %s ORDER BY id LIMIT %s OFFSET %s",
+		selectAudit, sel.Where(), sel.Arg(limit), sel.Arg(query.Offset),
+	)
+
+	page := model.AuditPage{Entries: []model.AuditEntry{}}
+	err := s.withTimeout(ctx, func(ctx context.Context) error {
+		row := s.db.QueryRow(ctx, "SELECT count(*) FROM audit_log "+filter.Where(), filter.Args()...)
+		if err := row.Scan(&page.Total); err != nil {
+			return err
+		}
+
+		rows, err := s.db.Query(ctx, selQuery, sel.Args()...)
+		if err != nil {
+			return err
+		}
+
+		defer rows.Close()
+
+		for rows.Next() {
+			var entry model.AuditEntry
+			if err := scanAuditEntry(rows, &entry); err != nil {
+				return err
+			}
+			page.Entries = append(page.Entries, entry)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func filterAudit(query *model.AuditQuery) *sqlstore.Query {
+	q := sqlstore.NewQuery(dialect)
+	if res := query.Resource; res.Type != "" {
+		q.AddCond("resource_type = " + q.Arg(res.Type))
+		if res.ID > 0 {
+			q.AddCond("resource_id = " + q.Arg(res.ID))
+		}
+	}
+
+	if !query.Since.IsZero() {
+		q.AddCond("logged_at >= " + q.Arg(query.Since))
+	}
+	if !query.Until.IsZero() {
+		q.AddCond("logged_at < " + q.Arg(query.Until))
+	}
+	return q
+}
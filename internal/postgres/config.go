@@ -9,6 +9,36 @@ type Config struct {
 	User     string
 	Password string
 
+	// SSLMode is one of the libpq sslmode values, with TLS disabled by default.
+	SSLMode string
+
+	// SSLRootCert is the path to the root CA certificate the server certificate is verified against.
+	SSLRootCert string
+
+	// SSLCert and SSLKey are the paths to the client certificate and its private key, for authentication with certificates.
+	SSLCert string
+	SSLKey  string
+
+	// DSN is a connection string or URL in the libpq format, which overrides all of the connection settings above.
+	DSN string
+
 	Timeout     time.Duration
 	IdleTimeout time.Duration
+
+	// MaxConns and MinConns bound the number of connections in the pool, with zero meaning the pgx defaults.
+	MaxConns int32
+	MinConns int32
+
+	// MaxConnLifetime is how long a connection is used before it is replaced, with zero meaning the pgx default.
+	MaxConnLifetime time.Duration
+
+	// HealthCheckPeriod is how often idle connections are checked, with zero meaning the pgx default.
+	HealthCheckPeriod time.Duration
+
+	// StatementCacheCapacity is the number of prepared statements cached per connection, with zero meaning the pgx default.
+	// Negative values disable the cache, which is required behind connection poolers such as PgBouncer.
+	StatementCacheCapacity int
+
+	// StatsInterval is how often the pool statistics are logged, with zero disabling the logging.
+	StatsInterval time.Duration
 }
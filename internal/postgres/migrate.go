@@ -0,0 +1,238 @@
+package postgres
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrationLockID identifies the advisory lock held while migrating, so that only one server migrates the database at a time.
+const migrationLockID = 0x6d757a696b
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a versioned change to the database schema.
+type Migration struct {
+	Version int
+	Name    string
+
+	// AppliedAt is when the migration was applied, or zero if it is pending.
+	AppliedAt time.Time
+
+	up, down string
+}
+
+func (m *Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// loadMigrations reads the embedded migrations ordered by version.
+func loadMigrations() ([]*Migration, error) {
+	files, err := fs.Glob(migrationFiles, "migrations/*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, file := range files {
+		name := file[len("migrations/"):]
+		match := migrationFileName.FindStringSubmatch(name)
+		if match == nil {
+			return nil, fmt.Errorf("malformed migration file name %q", name)
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migrations %q and %q share a version", m, name)
+		}
+
+		content, err := migrationFiles.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if match[3] == "up" {
+			m.up = string(content)
+		} else {
+			m.down = string(content)
+		}
+	}
+
+	var migrations []*Migration
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %q must have both up and down scripts", m)
+		}
+		migrations = append(migrations, m)
+	}
+
+	slices.SortFunc(migrations, func(a, b *Migration) int {
+		return a.Version - b.Version
+	})
+	return migrations, nil
+}
+
+// Migrator applies and reverts schema migrations embedded into the application.
+type Migrator struct {
+	db *pgxpool.Pool
+}
+
+// OpenMigrator connects to the database without changing its schema.
+func OpenMigrator(cfg *Config) (*Migrator, error) {
+	db, err := connect(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db}, nil
+}
+
+func (m *Migrator) Close() error {
+	m.db.Close()
+	return nil
+}
+
+// Up applies all pending migrations in order, returning the applied ones.
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	var applied []*Migration
+	err := m.locked(ctx, func(conn *pgxpool.Conn, migrations []*Migration) error {
+		for _, mig := range migrations {
+			if !mig.AppliedAt.IsZero() {
+				continue
+			}
+
+			if err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, mig.up); err != nil {
+					return err
+				}
+				return tx.QueryRow(ctx,
+					"INSERT INTO schema_migrations(version, name) VALUES ($1, $2) RETURNING applied_at",
+					mig.Version, mig.Name,
+				).Scan(&mig.AppliedAt)
+			}); err != nil {
+				return fmt.Errorf("migration %v: %w", mig, err)
+			}
+			applied = append(applied, mig)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the most recently applied migration, returning it, or nil if there is nothing to revert.
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var reverted *Migration
+	err := m.locked(ctx, func(conn *pgxpool.Conn, migrations []*Migration) error {
+		for _, mig := range slices.Backward(migrations) {
+			if mig.AppliedAt.IsZero() {
+				continue
+			}
+
+			if err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, mig.down); err != nil {
+					return err
+				}
+				_, err := tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version=$1", mig.Version)
+				return err
+			}); err != nil {
+				return fmt.Errorf("migration %v: %w", mig, err)
+			}
+
+			mig.AppliedAt = time.Time{}
+			reverted = mig
+			return nil
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status lists all known migrations, reporting which of them have been applied.
+func (m *Migrator) Status(ctx context.Context) ([]*Migration, error) {
+	var status []*Migration
+	err := m.locked(ctx, func(_ *pgxpool.Conn, migrations []*Migration) error {
+		status = migrations
+		return nil
+	})
+	return status, err
+}
+
+// locked runs f with the migration lock held, passing it the known migrations along with their state in the database.
+func (m *Migrator) locked(ctx context.Context, f func(conn *pgxpool.Conn, migrations []*Migration) error) (err error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return err
+	}
+
+	// Advisory locks belong to a session, so all statements must go through the same connection
+	conn, err := m.db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return err
+	}
+	defer func() {
+		// Use a fresh context, so that the lock is released even if the original one is done
+		if _, unlockErr := conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID); unlockErr != nil {
+			err = errors.Join(err, unlockErr)
+		}
+	}()
+
+	if _, err := conn.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations(
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		)
+	`); err != nil {
+		return err
+	}
+
+	if err := loadAppliedAt(ctx, conn, migrations); err != nil {
+		return err
+	}
+	return f(conn, migrations)
+}
+
+// loadAppliedAt fills in when each of the migrations was applied, failing if the database has migrations unknown to the application.
+func loadAppliedAt(ctx context.Context, conn *pgxpool.Conn, migrations []*Migration) error {
+	rows, err := conn.Query(ctx, "SELECT version, applied_at FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			version   int
+			appliedAt time.Time
+		)
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return err
+		}
+
+		i := slices.IndexFunc(migrations, func(m *Migration) bool { return m.Version == version })
+		if i < 0 {
+			return fmt.Errorf("the database has an unknown migration %d applied, it may be newer than the application", version)
+		}
+		migrations[i].AppliedAt = appliedAt
+	}
+	return rows.Err()
+}
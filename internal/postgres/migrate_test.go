@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	for i, m := range migrations {
+		assert.Equal(t, i+1, m.Version, "versions must be consecutive")
+		assert.True(t, m.AppliedAt.IsZero())
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	cfg := testConfig(t)
+	ctx := context.Background()
+
+	migrator, err := OpenMigrator(cfg)
+	require.NoError(t, err)
+	defer migrator.Close()
+
+	migrations, err := loadMigrations()
+	require.NoError(t, err)
+
+	_, err = migrator.Up(ctx)
+	require.NoError(t, err)
+
+	// Revert everything, one migration at a time
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m, err := migrator.Down(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, m)
+		assert.Equal(t, migrations[i].Version, m.Version)
+	}
+
+	m, err := migrator.Down(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, m)
+
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Len(t, applied, len(migrations))
+
+	status, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	for _, m := range status {
+		assert.False(t, m.AppliedAt.IsZero(), "migration %v is not applied", m)
+	}
+}
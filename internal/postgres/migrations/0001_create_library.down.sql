@@ -0,0 +1,4 @@
+DROP TABLE track_artists;
+DROP TABLE tracks;
+DROP TABLE albums;
+DROP TABLE artists;
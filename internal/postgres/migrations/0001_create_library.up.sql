@@ -0,0 +1,36 @@
+-- Databases created before migrations were introduced already have some of the tables and columns
+CREATE TABLE IF NOT EXISTS artists(
+	id SERIAL PRIMARY KEY,
+	name TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS albums(
+	id SERIAL PRIMARY KEY,
+	title TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS tracks(
+	id SERIAL PRIMARY KEY,
+	title TEXT NOT NULL
+);
+
+ALTER TABLE tracks
+	ADD COLUMN IF NOT EXISTS album_id INTEGER REFERENCES albums(id) ON DELETE RESTRICT,
+	ADD COLUMN IF NOT EXISTS duration_ms INTEGER CHECK (duration_ms >= 0),
+	ADD COLUMN IF NOT EXISTS track_number INTEGER CHECK (track_number >= 1),
+	ADD COLUMN IF NOT EXISTS disc_number INTEGER CHECK (disc_number >= 1),
+	ADD COLUMN IF NOT EXISTS release_year INTEGER,
+	ADD COLUMN IF NOT EXISTS genres JSONB NOT NULL DEFAULT '[]',
+	ADD COLUMN IF NOT EXISTS isrc TEXT NOT NULL DEFAULT '',
+	ADD COLUMN IF NOT EXISTS bpm DOUBLE PRECISION CHECK (bpm > 0),
+	ADD COLUMN IF NOT EXISTS explicit BOOLEAN NOT NULL DEFAULT FALSE,
+	ADD COLUMN IF NOT EXISTS comment TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS track_artists(
+	track_id INTEGER NOT NULL REFERENCES tracks(id) ON DELETE CASCADE,
+	artist_id INTEGER NOT NULL REFERENCES artists(id) ON DELETE RESTRICT,
+	position INTEGER NOT NULL,
+	PRIMARY KEY (track_id, artist_id)
+);
+
+CREATE INDEX IF NOT EXISTS track_artists_artist_id_idx ON track_artists(artist_id);
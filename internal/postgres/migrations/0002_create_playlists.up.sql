@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS playlists(
+	id SERIAL PRIMARY KEY,
+	name TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS playlist_tracks(
+	playlist_id INTEGER NOT NULL REFERENCES playlists(id) ON DELETE CASCADE,
+	track_id INTEGER NOT NULL REFERENCES tracks(id) ON DELETE CASCADE,
+	position INTEGER NOT NULL,
+	PRIMARY KEY (playlist_id, position) DEFERRABLE INITIALLY DEFERRED
+);
+
+CREATE INDEX IF NOT EXISTS playlist_tracks_track_id_idx ON playlist_tracks(track_id);
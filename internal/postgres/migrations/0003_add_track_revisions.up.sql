@@ -0,0 +1 @@
+ALTER TABLE tracks ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
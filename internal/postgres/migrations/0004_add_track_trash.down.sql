@@ -0,0 +1,4 @@
+DELETE FROM tracks WHERE deleted_at IS NOT NULL;
+
+DROP INDEX tracks_deleted_at_idx;
+ALTER TABLE tracks DROP COLUMN deleted_at;
@@ -0,0 +1,3 @@
+ALTER TABLE tracks ADD COLUMN deleted_at TIMESTAMPTZ;
+
+CREATE INDEX tracks_deleted_at_idx ON tracks(deleted_at) WHERE deleted_at IS NOT NULL;
@@ -0,0 +1,24 @@
+CREATE TABLE audit_log(
+	id SERIAL PRIMARY KEY,
+	logged_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	actor TEXT NOT NULL DEFAULT '',
+	request_id TEXT NOT NULL DEFAULT '',
+	action TEXT NOT NULL,
+	resource_type TEXT NOT NULL,
+	resource_id INTEGER NOT NULL,
+	changes JSONB NOT NULL DEFAULT '{}'
+);
+
+CREATE INDEX audit_log_resource_idx ON audit_log(resource_type, resource_id);
+CREATE INDEX audit_log_logged_at_idx ON audit_log(logged_at);
+
+-- The audit log can only be appended to
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'the audit log is append-only';
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only
+	BEFORE UPDATE OR DELETE ON audit_log
+	FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
@@ -0,0 +1,6 @@
+ALTER TABLE tracks DROP COLUMN search_vector;
+ALTER TABLE artists DROP COLUMN search_vector;
+ALTER TABLE albums DROP COLUMN search_vector;
+
+-- The extension is left in place, since it may have been installed for other uses
+DROP FUNCTION search_unaccent(text);
@@ -0,0 +1,24 @@
+CREATE EXTENSION IF NOT EXISTS unaccent WITH SCHEMA public;
+
+-- unaccent() is only stable because its dictionary can be changed, so it cannot be used in generated columns directly
+CREATE FUNCTION search_unaccent(text) RETURNS text
+	LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT
+	AS $$ SELECT public.unaccent('public.unaccent'::regdictionary, $1) $$;
+
+ALTER TABLE tracks ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+	setweight(to_tsvector('simple', search_unaccent(title)), 'A') ||
+	setweight(to_tsvector('simple', search_unaccent(genres::text)), 'B') ||
+	setweight(to_tsvector('simple', search_unaccent(comment)), 'D')
+) STORED;
+
+ALTER TABLE artists ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+	setweight(to_tsvector('simple', search_unaccent(name)), 'A')
+) STORED;
+
+ALTER TABLE albums ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+	setweight(to_tsvector('simple', search_unaccent(title)), 'A')
+) STORED;
+
+CREATE INDEX tracks_search_idx ON tracks USING GIN (search_vector);
+CREATE INDEX artists_search_idx ON artists USING GIN (search_vector);
+CREATE INDEX albums_search_idx ON albums USING GIN (search_vector);
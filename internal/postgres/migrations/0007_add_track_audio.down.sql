@@ -0,0 +1,4 @@
+ALTER TABLE tracks
+	DROP COLUMN audio_content_type,
+	DROP COLUMN audio_size,
+	DROP COLUMN audio_sha256;
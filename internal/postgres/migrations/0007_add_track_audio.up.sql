@@ -0,0 +1,8 @@
+ALTER TABLE tracks
+	ADD COLUMN audio_content_type TEXT,
+	ADD COLUMN audio_size BIGINT,
+	ADD COLUMN audio_sha256 TEXT,
+	ADD CONSTRAINT tracks_audio_check CHECK (
+		(audio_sha256 IS NULL) = (audio_content_type IS NULL) AND
+		(audio_sha256 IS NULL) = (audio_size IS NULL)
+	);
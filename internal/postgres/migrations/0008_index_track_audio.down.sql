@@ -0,0 +1 @@
+DROP INDEX tracks_audio_sha256_idx;
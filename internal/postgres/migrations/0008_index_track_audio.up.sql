@@ -0,0 +1 @@
+CREATE INDEX tracks_audio_sha256_idx ON tracks(audio_sha256) WHERE audio_sha256 IS NOT NULL;
@@ -0,0 +1,175 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/cerfical/muzik/internal/search"
+	"github.com/jackc/pgx/v5"
+)
+
+// searchTables maps searchable resource types to the tables holding them, along with the conditions for their rows to be visible.
+var searchTables = map[string]string{
+	model.TrackType:  "tracks WHERE deleted_at IS NULL AND",
+	model.ArtistType: "artists WHERE",
+	model.AlbumType:  "albums WHERE",
+}
+
+func (s *Store) Search(ctx context.Context, query *model.SearchQuery) (*model.SearchPage, error) {
+	page := model.SearchPage{Hits: []model.SearchHit{}}
+
+	terms := search.Terms(query.Text)
+	if len(terms) == 0 {
+		return &page, nil
+	}
+
+	types := query.Types
+	if len(types) == 0 {
+		types = model.SearchableTypes
+	}
+
+	// Match each term as a prefix, which is safe to put into a query since terms consist of letters and digits only
+	for i, term := range terms {
+		terms[i] = term + ":*"
+	}
+	tsQuery := strings.Join(terms, " & ")
+
+	var branches []string
+	for _, typ := range model.SearchableTypes {
+		if !slices.Contains(types, typ) {
+			continue
+		}
+		branches = append(branches, fmt.Sprintf(
+			"SELECT %d AS type_order, '%s' AS type, id, ts_rank(search_vector, q) AS score FROM %s search_vector @@ q",
+			slices.Index(model.SearchableTypes, typ), typ, searchTables[typ],
+		))
+	}
+
+	hits := fmt.Sprintf(
+		"WITH query AS (SELECT to_tsquery('simple', search_unaccent($1)) AS q) SELECT type, id, score FROM query, LATERAL (%s) hits",
+		strings.Join(branches, " UNION ALL "),
+	)
+
+	var limit *int
+	if query.Limit > 0 {
+		limit = &query.Limit
+	}
+
+	err := s.withTimeout(ctx, func(ctx context.Context) error {
+		return s.inTx(ctx, func(tx pgx.Tx) error {
+			if err := tx.QueryRow(ctx, "SELECT count(*) FROM ("+hits+") counted", tsQuery).Scan(&page.Total); err != nil {
+				return err
+			}
+
+			rows, err := tx.Query(ctx,
+				hits+" ORDER BY score DESC, type_order, id LIMIT $2 OFFSET $3",
+				tsQuery, limit, query.Offset,
+			)
+			if err != nil {
+				return err
+			}
+
+			type hit struct {
+				typ   string
+				id    int
+				score float64
+			}
+
+			found, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (hit, error) {
+				var h hit
+				err := row.Scan(&h.typ, &h.id, &h.score)
+				return h, err
+			})
+			if err != nil {
+				return err
+			}
+
+			ids := make(map[string][]int)
+			for _, h := range found {
+				ids[h.typ] = append(ids[h.typ], h.id)
+			}
+
+			resources, err := fetchResources(ctx, tx, ids)
+			if err != nil {
+				return err
+			}
+
+			page.Hits = page.Hits[:0]
+			for _, h := range found {
+				page.Hits = append(page.Hits, model.SearchHit{
+					Resource: resources[model.ResourceID{Type: h.typ, ID: h.id}],
+					Score:    h.score,
+				})
+			}
+			return nil
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// fetchResources loads the resources of each type with the specified IDs.
+func fetchResources(ctx context.Context, tx pgx.Tx, ids map[string][]int) (map[model.ResourceID]any, error) {
+	resources := make(map[model.ResourceID]any)
+
+	rows, err := tx.Query(ctx, selectTracks+" WHERE id = ANY($1)", ids[model.TrackType])
+	if err != nil {
+		return nil, err
+	}
+
+	tracks, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*model.Track, error) {
+		var track model.Track
+		err := scanTrack(row, &track)
+		return &track, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, track := range tracks {
+		resources[model.ResourceID{Type: model.TrackType, ID: track.ID}] = track
+	}
+
+	rows, err = tx.Query(ctx, "SELECT id, name FROM artists WHERE id = ANY($1)", ids[model.ArtistType])
+	if err != nil {
+		return nil, err
+	}
+
+	artists, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*model.Artist, error) {
+		var artist model.Artist
+		err := row.Scan(&artist.ID, &artist.Attrs.Name)
+		return &artist, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, artist := range artists {
+		resources[model.ResourceID{Type: model.ArtistType, ID: artist.ID}] = artist
+	}
+
+	rows, err = tx.Query(ctx, "SELECT id, title FROM albums WHERE id = ANY($1)", ids[model.AlbumType])
+	if err != nil {
+		return nil, err
+	}
+
+	albums, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*model.Album, error) {
+		var album model.Album
+		err := row.Scan(&album.ID, &album.Attrs.Title)
+		return &album, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, album := range albums {
+		resources[model.ResourceID{Type: model.AlbumType, ID: album.ID}] = album
+	}
+	return resources, nil
+}
@@ -0,0 +1,279 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/cerfical/muzik/internal/sqlstore"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	foreignKeyViolation  = "23503"
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+)
+
+// maxTxAttempts limits how many times a transaction is run before giving up on conflicts with concurrent transactions.
+const maxTxAttempts = 5
+
+// Open connects to the database, bringing its schema up to date with all pending migrations.
+func Open(cfg *Config) (*Store, error) {
+	db, err := connect(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := (&Migrator{db}).Up(context.Background()); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrate: %w", err)
+	}
+	return &Store{db: db, pool: db, timeout: cfg.Timeout}, nil
+}
+
+// connect makes a connection pool, which connects to the database lazily.
+func connect(cfg *Config) (*pgxpool.Pool, error) {
+	poolCfg, err := makePoolConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return pgxpool.NewWithConfig(context.Background(), poolCfg)
+}
+
+func makePoolConfig(cfg *Config) (*pgxpool.Config, error) {
+	connStr, err := makeConnString(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	poolCfg, err := pgxpool.ParseConfig(connStr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Zero values keep the defaults
+	if cfg.MaxConns > 0 {
+		poolCfg.MaxConns = cfg.MaxConns
+	}
+	if cfg.MinConns > 0 {
+		poolCfg.MinConns = cfg.MinConns
+	}
+	if cfg.IdleTimeout > 0 {
+		poolCfg.MaxConnIdleTime = cfg.IdleTimeout
+	}
+	if cfg.MaxConnLifetime > 0 {
+		poolCfg.MaxConnLifetime = cfg.MaxConnLifetime
+	}
+	if cfg.HealthCheckPeriod > 0 {
+		poolCfg.HealthCheckPeriod = cfg.HealthCheckPeriod
+	}
+
+	connCfg := poolCfg.ConnConfig
+	switch {
+	case cfg.StatementCacheCapacity > 0:
+		connCfg.StatementCacheCapacity = cfg.StatementCacheCapacity
+	case cfg.StatementCacheCapacity < 0:
+		// Statements are still prepared, but without names that would have to persist on the server
+		connCfg.DefaultQueryExecMode = pgx.QueryExecModeDescribeExec
+		connCfg.StatementCacheCapacity = 0
+	}
+
+	if poolCfg.MinConns > poolCfg.MaxConns {
+		return nil, fmt.Errorf("the minimum number of connections %d exceeds the maximum %d", poolCfg.MinConns, poolCfg.MaxConns)
+	}
+	return poolCfg, nil
+}
+
+func makeConnString(cfg *Config) (string, error) {
+	if cfg.DSN != "" {
+		return cfg.DSN, nil
+	}
+
+	host, port, err := net.SplitHostPort(cfg.Addr)
+	if cfg.Addr != "" && err != nil {
+		return "", err
+	}
+
+	sslMode := cfg.SSLMode
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+
+	c := []struct {
+		key, val string
+	}{
+		{"host", host},
+		{"port", port},
+		{"user", cfg.User},
+		{"password", cfg.Password},
+		{"database", cfg.Name},
+		{"sslmode", sslMode},
+		{"sslrootcert", cfg.SSLRootCert},
+		{"sslcert", cfg.SSLCert},
+		{"sslkey", cfg.SSLKey},
+	}
+
+	quote := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+	var options []string
+	for _, cc := range c {
+		if cc.val == "" {
+			continue
+		}
+		options = append(options, fmt.Sprintf("%v='%v'", cc.key, quote.Replace(cc.val)))
+	}
+
+	connStr := strings.Join(options, " ")
+	return connStr, nil
+}
+
+// ConnParams are the connection settings in effect, with defaults and environment variables applied and secrets left out.
+type ConnParams struct {
+	Host     string
+	Port     uint16
+	Database string
+	User     string
+	SSLMode  string
+}
+
+// Params resolves the connection settings in effect.
+func (cfg *Config) Params() (*ConnParams, error) {
+	connStr, err := makeConnString(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	connCfg, err := pgconn.ParseConfig(connStr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ConnParams{
+		Host:     connCfg.Host,
+		Port:     connCfg.Port,
+		Database: connCfg.Database,
+		User:     connCfg.User,
+		SSLMode:  sslMode(connCfg),
+	}, nil
+}
+
+// sslMode recovers the sslmode from the TLS settings of the first host, which is all that remains of it after parsing.
+func sslMode(cfg *pgconn.Config) string {
+	tlsCfg := cfg.TLSConfig
+	var fallback *pgconn.FallbackConfig
+	if len(cfg.Fallbacks) > 0 && cfg.Fallbacks[0].Host == cfg.Host && cfg.Fallbacks[0].Port == cfg.Port {
+		fallback = cfg.Fallbacks[0]
+	}
+
+	switch {
+	case tlsCfg == nil && fallback == nil:
+		return "disable"
+	case tlsCfg == nil:
+		return "allow"
+	case fallback != nil && fallback.TLSConfig == nil:
+		return "prefer"
+	case !tlsCfg.InsecureSkipVerify:
+		return "verify-full"
+	case tlsCfg.VerifyPeerCertificate != nil:
+		return "verify-ca"
+	default:
+		return "require"
+	}
+}
+
+// Store implements all the [model] store interfaces on top of a single database.
+type Store struct {
+	// db is either the pool itself, or a transaction for stores passed to [Store.RunInTx]
+	db      querier
+	pool    *pgxpool.Pool
+	timeout time.Duration
+}
+
+// dialect writes queries in the SQL of PostgreSQL.
+var dialect = &sqlstore.Dialect{
+	Placeholder: func(n int) string {
+		return fmt.Sprintf("$%d", n)
+	},
+	ContainsFold: func(column, placeholder string) string {
+		return fmt.Sprintf("%s ILIKE '%%' || %s || '%%'", column, placeholder)
+	},
+	Binary: func(column string) string {
+		return column + ` COLLATE "C"`
+	},
+}
+
+// Stats reports the current state of the connection pool.
+func (s *Store) Stats() *pgxpool.Stat {
+	return s.pool.Stat()
+}
+
+func (s *Store) withTimeout(ctx context.Context, f func(ctx context.Context) error) error {
+	return sqlstore.WithTimeout(ctx, s.timeout, f)
+}
+
+// inTx runs f in a transaction, committing it if f succeeds.
+// Within [Store.RunInTx], the transaction is nested, so that a failing f leaves the enclosing transaction intact.
+func (s *Store) inTx(ctx context.Context, f func(tx pgx.Tx) error) error {
+	return pgx.BeginFunc(ctx, s.db, f)
+}
+
+// RunInTx runs f in a serializable transaction, retrying it on serialization failures and deadlocks.
+func (s *Store) RunInTx(ctx context.Context, f func(model.TxStores) error) error {
+	for attempt := 1; ; attempt++ {
+		err := pgx.BeginTxFunc(ctx, s.pool, pgx.TxOptions{IsoLevel: pgx.Serializable}, func(tx pgx.Tx) error {
+			return f(&Store{db: tx, timeout: s.timeout})
+		})
+		if attempt == maxTxAttempts || !isTxConflict(err) {
+			return err
+		}
+
+		// Give the conflicting transactions some time to finish, with jitter to keep the retries from colliding again
+		backoff := time.Duration(attempt) * 10 * time.Millisecond
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff + rand.N(backoff)):
+		}
+	}
+}
+
+func (s *Store) Close() error {
+	s.pool.Close()
+	return nil
+}
+
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation
+}
+
+// isTxConflict checks whether the transaction failed only because of concurrent transactions, and can be retried.
+func isTxConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == serializationFailure || pgErr.Code == deadlockDetected)
+}
+
+// execOne executes a statement expected to affect exactly one row, reporting [model.ErrNotFound] otherwise.
+func execOne(ctx context.Context, db querier, query string, args ...any) error {
+	tag, err := db.Exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	return sqlstore.ExpectOne(tag.RowsAffected())
+}
+
+// querier is implemented by [pgxpool.Pool], [pgxpool.Conn] and [pgx.Tx].
+type querier interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, query string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, query string, args ...any) pgx.Row
+}
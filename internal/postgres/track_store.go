@@ -2,101 +2,275 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"net"
-	"strings"
 	"time"
 
 	"github.com/cerfical/muzik/internal/model"
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/cerfical/muzik/internal/sqlstore"
+	"github.com/jackc/pgx/v5"
 )
 
-func OpenTrackStore(cfg *Config) (model.TrackStore, error) {
-	connStr, err := makeConnString(cfg)
+// selectTracks selects track columns in the order expected by [scanTrack].
+const selectTracks = `
+	SELECT
+		id, title, duration_ms, track_number, disc_number, release_year,
+		genres, isrc, bpm, explicit, comment, revision, deleted_at,
+		audio_content_type, audio_size, audio_sha256, album_id, (
+			SELECT string_agg(artist_id::text, ',' ORDER BY position)
+			FROM track_artists
+			WHERE track_id = tracks.id
+		)
+	FROM tracks`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTrack(row scanner, track *model.Track) error {
+	var genres []byte
+	var albumID *int
+	var artistIDs *string
+	var audio audioColumns
+
+	attrs := &track.Attrs
+	if err := row.Scan(
+		&track.ID, &attrs.Title, &attrs.Duration, &attrs.TrackNumber, &attrs.DiscNumber, &attrs.Year,
+		&genres, &attrs.ISRC, &attrs.BPM, &attrs.Explicit, &attrs.Comment, &track.Revision, &track.DeletedAt,
+		&audio.contentType, &audio.size, &audio.sha256, &albumID, &artistIDs,
+	); err != nil {
+		return err
+	}
+	track.Meta = audio.meta()
+
+	if err := json.Unmarshal(genres, &attrs.Genres); err != nil {
+		return err
+	}
+
+	ids, err := sqlstore.SplitIDs(artistIDs)
 	if err != nil {
-		return nil, fmt.Errorf("invalid config: %w", err)
+		return err
+	}
+
+	track.Rels = model.TrackRels{ArtistIDs: ids}
+	if albumID != nil {
+		track.Rels.AlbumID = *albumID
+	}
+	return nil
+}
+
+// audioColumns holds the audio columns of a track, which are all NULL if the track has no audio.
+type audioColumns struct {
+	contentType *string
+	size        *int64
+	sha256      *string
+}
+
+// meta makes the meta of a track from its audio columns, returning nil if the track has no audio.
+func (c *audioColumns) meta() *model.TrackMeta {
+	if c.sha256 == nil {
+		return nil
 	}
 
-	db, err := sql.Open("pgx", connStr)
+	audio := model.Audio{SHA256: *c.sha256}
+	if c.contentType != nil {
+		audio.ContentType = *c.contentType
+	}
+	if c.size != nil {
+		audio.Size = *c.size
+	}
+	return &model.TrackMeta{Audio: &audio}
+}
+
+func (s *Store) CreateTrack(ctx context.Context, track *model.Track) (*model.Track, error) {
+	var id, revision int
+	err := s.withTimeout(ctx, func(ctx context.Context) error {
+		return s.inTx(ctx, func(tx pgx.Tx) error {
+			genres, err := sqlstore.MarshalGenres(track.Attrs.Genres)
+			if err != nil {
+				return err
+			}
+
+			row := tx.QueryRow(ctx, `
+				INSERT INTO tracks(
+					title, duration_ms, track_number, disc_number, release_year,
+					genres, isrc, bpm, explicit, comment, album_id
+				) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+				RETURNING id, revision`,
+				track.Attrs.Title, track.Attrs.Duration, track.Attrs.TrackNumber, track.Attrs.DiscNumber, track.Attrs.Year,
+				genres, track.Attrs.ISRC, track.Attrs.BPM, track.Attrs.Explicit, track.Attrs.Comment, nullID(track.Rels.AlbumID),
+			)
+			if err := row.Scan(&id, &revision); err != nil {
+				return err
+			}
+			return setTrackArtists(ctx, tx, id, track.Rels.ArtistIDs)
+		})
+	})
+
 	if err != nil {
+		if isForeignKeyViolation(err) {
+			return nil, model.ErrRelatedNotFound
+		}
 		return nil, err
 	}
-	db.SetConnMaxIdleTime(cfg.IdleTimeout)
 
-	if _, err := db.Exec(`
-		CREATE TABLE IF NOT EXISTS tracks(
-			id SERIAL PRIMARY KEY,
-			title TEXT NOT NULL
-		)
-	`); err != nil {
+	return sqlstore.NewTrack(id, revision, track), nil
+}
+
+// copyTrackColumns lists the columns of the tracks table set when creating tracks, in the order of [trackValues].
+var copyTrackColumns = []string{
+	"id", "title", "duration_ms", "track_number", "disc_number", "release_year",
+	"genres", "isrc", "bpm", "explicit", "comment", "album_id",
+}
+
+func trackValues(id int, track *model.Track) ([]any, error) {
+	genres, err := sqlstore.MarshalGenres(track.Attrs.Genres)
+	if err != nil {
+		return nil, err
+	}
+
+	return []any{
+		id, track.Attrs.Title, track.Attrs.Duration, track.Attrs.TrackNumber, track.Attrs.DiscNumber, track.Attrs.Year,
+		genres, track.Attrs.ISRC, track.Attrs.BPM, track.Attrs.Explicit, track.Attrs.Comment, nullID(track.Rels.AlbumID),
+	}, nil
+}
+
+func (s *Store) CreateTracks(ctx context.Context, tracks []model.Track) ([]model.Track, error) {
+	created := make([]model.Track, len(tracks))
+	err := s.withTimeout(ctx, func(ctx context.Context) error {
+		return s.inTx(ctx, func(tx pgx.Tx) error {
+			if err := checkTrackRels(ctx, tx, tracks); err != nil {
+				return err
+			}
+
+			// Reserve the IDs up front, so that all tracks can be copied in at once
+			rows, err := tx.Query(ctx, "SELECT nextval(pg_get_serial_sequence('tracks', 'id')) FROM generate_series(1, $1)", len(tracks))
+			if err != nil {
+				return err
+			}
+
+			ids, err := pgx.CollectRows(rows, pgx.RowTo[int])
+			if err != nil {
+				return err
+			}
+
+			trackRows := make([][]any, len(tracks))
+			var artistRows [][]any
+			for i := range tracks {
+				if trackRows[i], err = trackValues(ids[i], &tracks[i]); err != nil {
+					return err
+				}
+
+				for pos, artistID := range tracks[i].Rels.ArtistIDs {
+					artistRows = append(artistRows, []any{ids[i], artistID, pos})
+				}
+				created[i] = *sqlstore.NewTrack(ids[i], 1, &tracks[i])
+			}
+
+			if _, err := tx.CopyFrom(ctx, pgx.Identifier{"tracks"}, copyTrackColumns, pgx.CopyFromRows(trackRows)); err != nil {
+				return err
+			}
+
+			_, err = tx.CopyFrom(ctx, pgx.Identifier{"track_artists"}, []string{"track_id", "artist_id", "position"}, pgx.CopyFromRows(artistRows))
+			return err
+		})
+	})
+
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return nil, model.ErrRelatedNotFound
+		}
 		return nil, err
 	}
 
-	return &TrackStore{db, cfg.Timeout}, nil
+	return created, nil
 }
 
-func makeConnString(cfg *Config) (string, error) {
-	host, port, err := net.SplitHostPort(cfg.Addr)
-	if cfg.Addr != "" && err != nil {
-		return "", err
+// checkTrackRels reports [model.ItemErrors] for the tracks that refer to artists or albums that do not exist.
+func checkTrackRels(ctx context.Context, tx pgx.Tx, tracks []model.Track) error {
+	var albumIDs, artistIDs []int
+	for i := range tracks {
+		if id := tracks[i].Rels.AlbumID; id != 0 {
+			albumIDs = append(albumIDs, id)
+		}
+		artistIDs = append(artistIDs, tracks[i].Rels.ArtistIDs...)
+	}
+
+	albums, err := existingIDs(ctx, tx, "albums", albumIDs)
+	if err != nil {
+		return err
 	}
 
-	c := []struct {
-		key, val string
-	}{
-		{"host", host},
-		{"port", port},
-		{"user", cfg.User},
-		{"password", cfg.Password},
-		{"database", cfg.Name},
-		{"sslmode", "disable"},
+	artists, err := existingIDs(ctx, tx, "artists", artistIDs)
+	if err != nil {
+		return err
 	}
 
-	var options []string
-	for _, cc := range c {
-		if cc.val == "" {
-			continue
+	var errs model.ItemErrors
+	for i := range tracks {
+		rels := &tracks[i].Rels
+		ok := rels.AlbumID == 0 || albums[rels.AlbumID]
+		for _, id := range rels.ArtistIDs {
+			ok = ok && artists[id]
+		}
+
+		if !ok {
+			errs = append(errs, model.ItemError{Index: i, Err: model.ErrRelatedNotFound})
 		}
-		options = append(options, fmt.Sprintf("%v='%v'", cc.key, cc.val))
 	}
 
-	connStr := strings.Join(options, " ")
-	return connStr, nil
+	if errs != nil {
+		return errs
+	}
+	return nil
 }
 
-type TrackStore struct {
-	db      *sql.DB
-	timeout time.Duration
+// existingIDs selects the IDs out of the specified ones that refer to rows of the table.
+func existingIDs(ctx context.Context, tx pgx.Tx, table string, ids []int) (map[int]bool, error) {
+	rows, err := tx.Query(ctx, fmt.Sprintf("SELECT id FROM %s WHERE id = ANY($1)", table), ids)
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return nil, err
+	}
+
+	exists := make(map[int]bool, len(found))
+	for _, id := range found {
+		exists[id] = true
+	}
+	return exists, nil
 }
 
-func (s *TrackStore) CreateTrack(ctx context.Context, attrs *model.TrackAttrs) (*model.Track, error) {
-	var id int
+func (s *Store) GetTrack(ctx context.Context, id int) (*model.Track, error) {
+	var track model.Track
 	err := s.withTimeout(ctx, func(ctx context.Context) error {
-		row := s.db.QueryRowContext(ctx,
-			"INSERT INTO tracks(title) VALUES($1) RETURNING id",
-			attrs.Title,
-		)
-		return row.Scan(&id)
+		row := s.db.QueryRow(ctx, selectTracks+" WHERE id=$1 AND deleted_at IS NULL", id)
+		return scanTrack(row, &track)
 	})
 
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
 		return nil, err
 	}
 
-	return &model.Track{ID: id, Attrs: *attrs}, nil
+	return &track, nil
 }
 
-func (s *TrackStore) GetTrack(ctx context.Context, id int) (*model.Track, error) {
+func (s *Store) GetTrashedTrack(ctx context.Context, id int) (*model.Track, error) {
 	var track model.Track
 	err := s.withTimeout(ctx, func(ctx context.Context) error {
-		row := s.db.QueryRowContext(ctx, "SELECT id, title FROM tracks WHERE id=$1", id)
-		return row.Scan(&track.ID, &track.Attrs.Title)
+		row := s.db.QueryRow(ctx, selectTracks+" WHERE id=$1 AND deleted_at IS NOT NULL", id)
+		return scanTrack(row, &track)
 	})
 
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
+		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, model.ErrNotFound
 		}
 		return nil, err
@@ -105,63 +279,270 @@ func (s *TrackStore) GetTrack(ctx context.Context, id int) (*model.Track, error)
 	return &track, nil
 }
 
-func (s *TrackStore) GetTracks(ctx context.Context) ([]model.Track, error) {
-	var tracks []model.Track
-	err := s.withTimeout(ctx, func(ctx context.Context) (err error) {
-		rows, err := s.db.QueryContext(ctx, "SELECT id, title FROM tracks")
+func (s *Store) GetTracks(ctx context.Context, query *model.TrackQuery) (*model.TrackPage, error) {
+	cols, err := sqlstore.SortTracks(dialect, query.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := sqlstore.FilterTracks(dialect, &query.Filter, query.Trashed)
+	sel := filter.Clone()
+	if query.After > 0 {
+		sel.AfterTrack(cols, query.After)
+	}
+
+	var limit *int
+	if query.Limit > 0 {
+		limit = &query.Limit
+	}
+
+	selQuery := fmt.Sprintf("%s %s %s LIMIT %s OFFSET %s",
+		selectTracks, sel.Where(), sqlstore.OrderBy(cols), sel.Arg(limit), sel.Arg(query.Offset),
+	)
+
+	page := model.TrackPage{Tracks: []model.Track{}}
+	err = s.withTimeout(ctx, func(ctx context.Context) (err error) {
+		row := s.db.QueryRow(ctx, "SELECT count(*) FROM tracks "+filter.Where(), filter.Args()...)
+		if err := row.Scan(&page.Total); err != nil {
+			return err
+		}
+
+		rows, err := s.db.Query(ctx, selQuery, sel.Args()...)
 		if err != nil {
 			return err
 		}
 
-		defer func() {
-			if closeErr := rows.Close(); closeErr != nil && err == nil {
-				err = closeErr
-			}
-		}()
+		defer rows.Close()
 
 		for rows.Next() {
 			var track model.Track
-			if err = rows.Scan(&track.ID, &track.Attrs.Title); err != nil {
+			if err = scanTrack(rows, &track); err != nil {
 				return err
 			}
-			tracks = append(tracks, track)
+			page.Tracks = append(page.Tracks, track)
 		}
 		return rows.Err()
 	})
 
-	return tracks, err
+	if err != nil {
+		return nil, err
+	}
+
+	return &page, nil
 }
 
-func (s *TrackStore) DeleteTrack(ctx context.Context, id int) error {
-	return s.withTimeout(ctx, func(ctx context.Context) error {
-		res, err := s.db.ExecContext(ctx, "DELETE FROM tracks WHERE id=$1", id)
+func (s *Store) GetTracksByIDs(ctx context.Context, ids []int) ([]model.Track, error) {
+	tracks := []model.Track{}
+	err := s.withTimeout(ctx, func(ctx context.Context) (err error) {
+		rows, err := s.db.Query(ctx, selectTracks+" WHERE id = ANY($1) AND deleted_at IS NULL ORDER BY id", ids)
 		if err != nil {
 			return err
 		}
 
-		n, err := res.RowsAffected()
-		if err != nil {
-			return err
+		defer rows.Close()
+
+		for rows.Next() {
+			var track model.Track
+			if err = scanTrack(rows, &track); err != nil {
+				return err
+			}
+			tracks = append(tracks, track)
 		}
+		return rows.Err()
+	})
 
-		if n != 1 {
-			return model.ErrNotFound
+	if err != nil {
+		return nil, err
+	}
+
+	return tracks, nil
+}
+
+func (s *Store) UpdateTrack(ctx context.Context, track *model.Track) (*model.Track, error) {
+	var revision int
+	var audio audioColumns
+	err := s.withTimeout(ctx, func(ctx context.Context) error {
+		return s.inTx(ctx, func(tx pgx.Tx) error {
+			genres, err := sqlstore.MarshalGenres(track.Attrs.Genres)
+			if err != nil {
+				return err
+			}
+
+			row := tx.QueryRow(ctx, `
+				UPDATE tracks SET
+					title=$2, duration_ms=$3, track_number=$4, disc_number=$5, release_year=$6,
+					genres=$7, isrc=$8, bpm=$9, explicit=$10, comment=$11, album_id=$12,
+					revision=revision + 1
+				WHERE id=$1 AND deleted_at IS NULL
+				RETURNING revision, audio_content_type, audio_size, audio_sha256`,
+				track.ID, track.Attrs.Title, track.Attrs.Duration, track.Attrs.TrackNumber, track.Attrs.DiscNumber, track.Attrs.Year,
+				genres, track.Attrs.ISRC, track.Attrs.BPM, track.Attrs.Explicit, track.Attrs.Comment, nullID(track.Rels.AlbumID),
+			)
+			if err := row.Scan(&revision, &audio.contentType, &audio.size, &audio.sha256); err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return model.ErrNotFound
+				}
+				return err
+			}
+			return setTrackArtists(ctx, tx, track.ID, track.Rels.ArtistIDs)
+		})
+	})
+
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return nil, model.ErrRelatedNotFound
 		}
-		return nil
+		return nil, err
+	}
+
+	updated := sqlstore.NewTrack(track.ID, revision, track)
+	updated.Meta = audio.meta()
+	return updated, nil
+}
+
+func (s *Store) SetTrackAudio(ctx context.Context, id int, audio *model.Audio) (*model.Track, error) {
+	var track model.Track
+	err := s.withTimeout(ctx, func(ctx context.Context) error {
+		return s.inTx(ctx, func(tx pgx.Tx) error {
+			if err := execOne(ctx, tx, `
+				UPDATE tracks
+				SET audio_content_type=$2, audio_size=$3, audio_sha256=$4, revision=revision + 1
+				WHERE id=$1 AND deleted_at IS NULL`,
+				id, audio.ContentType, audio.Size, audio.SHA256,
+			); err != nil {
+				return err
+			}
+			return scanTrack(tx.QueryRow(ctx, selectTracks+" WHERE id=$1", id), &track)
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return &track, nil
+}
+
+func (s *Store) AudioInUse(ctx context.Context, sha256 string) (bool, error) {
+	var inUse bool
+	err := s.withTimeout(ctx, func(ctx context.Context) error {
+		row := s.db.QueryRow(ctx, "SELECT EXISTS(SELECT 1 FROM tracks WHERE audio_sha256=$1)", sha256)
+		return row.Scan(&inUse)
+	})
+
+	if err != nil {
+		return false, err
+	}
+	return inUse, nil
+}
+
+func (s *Store) DeleteTrack(ctx context.Context, id int) error {
+	return s.withTimeout(ctx, func(ctx context.Context) error {
+		return execOne(ctx, s.db, "UPDATE tracks SET deleted_at=now() WHERE id=$1 AND deleted_at IS NULL", id)
+	})
+}
+
+func (s *Store) DeleteTracks(ctx context.Context, ids []int) error {
+	return s.withTimeout(ctx, func(ctx context.Context) error {
+		return s.inTx(ctx, func(tx pgx.Tx) error {
+			rows, err := tx.Query(ctx, "UPDATE tracks SET deleted_at=now() WHERE id = ANY($1) AND deleted_at IS NULL RETURNING id", ids)
+			if err != nil {
+				return err
+			}
+
+			deleted, err := pgx.CollectRows(rows, pgx.RowTo[int])
+			if err != nil {
+				return err
+			}
+
+			if errs := sqlstore.MissingItems(ids, deleted); errs != nil {
+				return errs
+			}
+			return nil
+		})
 	})
 }
 
-func (s *TrackStore) withTimeout(ctx context.Context, f func(ctx context.Context) error) error {
-	timedCtx := ctx
-	if s.timeout > 0 {
-		var cancel context.CancelFunc
-		timedCtx, cancel = context.WithTimeout(timedCtx, s.timeout)
-		defer cancel()
+func (s *Store) RestoreTrack(ctx context.Context, id int) (*model.Track, error) {
+	var track model.Track
+	err := s.withTimeout(ctx, func(ctx context.Context) error {
+		return s.inTx(ctx, func(tx pgx.Tx) error {
+			if err := execOne(ctx, tx,
+				"UPDATE tracks SET deleted_at=NULL, revision=revision + 1 WHERE id=$1 AND deleted_at IS NOT NULL", id,
+			); err != nil {
+				return err
+			}
+			return scanTrack(tx.QueryRow(ctx, selectTracks+" WHERE id=$1", id), &track)
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return &track, nil
+}
+
+func (s *Store) PurgeTracks(ctx context.Context, before time.Time, limit int) ([]model.Track, error) {
+	var limitArg *int
+	if limit > 0 {
+		limitArg = &limit
 	}
 
-	return f(timedCtx)
+	purged := []model.Track{}
+	err := s.withTimeout(ctx, func(ctx context.Context) error {
+		return s.inTx(ctx, func(tx pgx.Tx) error {
+			// Read the tracks before deleting them, as their artists are deleted along with them
+			rows, err := tx.Query(ctx, selectTracks+" WHERE deleted_at < $1 ORDER BY id LIMIT $2 FOR UPDATE", before, limitArg)
+			if err != nil {
+				return err
+			}
+
+			defer rows.Close()
+
+			var ids []int
+			for rows.Next() {
+				var track model.Track
+				if err := scanTrack(rows, &track); err != nil {
+					return err
+				}
+				purged = append(purged, track)
+				ids = append(ids, track.ID)
+			}
+
+			if err := rows.Err(); err != nil {
+				return err
+			}
+
+			_, err = tx.Exec(ctx, "DELETE FROM tracks WHERE id = ANY($1)", ids)
+			return err
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return purged, nil
+}
+
+func setTrackArtists(ctx context.Context, tx pgx.Tx, trackID int, artistIDs []int) error {
+	if _, err := tx.Exec(ctx, "DELETE FROM track_artists WHERE track_id=$1", trackID); err != nil {
+		return err
+	}
+
+	for i, artistID := range artistIDs {
+		if _, err := tx.Exec(ctx,
+			"INSERT INTO track_artists(track_id, artist_id, position) VALUES($1, $2, $3)",
+			trackID, artistID, i,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (s *TrackStore) Close() error {
-	return s.db.Close()
+// nullID maps zero IDs to NULL.
+func nullID(id int) *int {
+	if id == 0 {
+		return nil
+	}
+	return &id
 }
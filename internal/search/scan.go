@@ -0,0 +1,129 @@
+package search
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/cerfical/muzik/internal/model"
+)
+
+// Source provides all resources to search through.
+type Source interface {
+	GetTracks(context.Context, *model.TrackQuery) (*model.TrackPage, error)
+	GetArtists(context.Context) ([]model.Artist, error)
+	GetAlbums(context.Context) ([]model.Album, error)
+}
+
+// scanPageSize is the number of tracks read from the source at once by [Scan].
+const scanPageSize = 1000
+
+// Scan implements [model.SearchStore] by matching the query against every resource of the source.
+//
+// It is meant for stores without a search index of their own, such as the in-memory and SQLite ones used for development
+// and small libraries, as every query reads through the whole library. Only the hits up to the end of the requested page
+// are kept in memory while reading.
+func Scan(ctx context.Context, src Source, query *model.SearchQuery) (*model.SearchPage, error) {
+	terms := Terms(query.Text)
+	types := query.Types
+	if len(types) == 0 {
+		types = model.SearchableTypes
+	}
+
+	// keep is the number of best hits to keep, or zero to keep all of them
+	keep := 0
+	if query.Limit > 0 {
+		keep = query.Offset + query.Limit
+	}
+
+	var hits []model.SearchHit
+	total := 0
+	add := func(res any, fields ...Field) {
+		if score, ok := Score(terms, fields...); ok {
+			hits = append(hits, model.SearchHit{Resource: res, Score: score})
+			total++
+
+			// Drop the worst hits once there are enough of them, so that this happens rarely
+			if keep > 0 && len(hits) >= 2*keep {
+				sortHits(hits)
+				hits = slices.Clip(hits[:keep])
+			}
+		}
+	}
+
+	if slices.Contains(types, model.TrackType) {
+		trackQuery := model.TrackQuery{Limit: scanPageSize}
+		for {
+			page, err := src.GetTracks(ctx, &trackQuery)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, track := range page.Tracks {
+				add(&track, TrackFields(&track.Attrs)...)
+			}
+
+			if len(page.Tracks) < trackQuery.Limit {
+				break
+			}
+			trackQuery.After = page.Tracks[len(page.Tracks)-1].ID
+		}
+	}
+
+	if slices.Contains(types, model.ArtistType) {
+		artists, err := src.GetArtists(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range artists {
+			add(&artists[i], Field{artists[i].Attrs.Name, WeightTitle})
+		}
+	}
+
+	if slices.Contains(types, model.AlbumType) {
+		albums, err := src.GetAlbums(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range albums {
+			add(&albums[i], Field{albums[i].Attrs.Title, WeightTitle})
+		}
+	}
+
+	sortHits(hits)
+
+	page := model.SearchPage{Hits: []model.SearchHit{}, Total: total}
+	if query.Offset < len(hits) {
+		hits = hits[query.Offset:]
+		if query.Limit > 0 && query.Limit < len(hits) {
+			hits = hits[:query.Limit]
+		}
+		page.Hits = hits
+	}
+	return &page, nil
+}
+
+// sortHits orders hits by their scores, with stable sorting keeping hits of the same score ordered by type and ID.
+func sortHits(hits []model.SearchHit) {
+	slices.SortStableFunc(hits, func(a, b model.SearchHit) int {
+		switch {
+		case a.Score > b.Score:
+			return -1
+		case a.Score < b.Score:
+			return 1
+		default:
+			return 0
+		}
+	})
+}
+
+// TrackFields lists the searchable fields of a track.
+func TrackFields(attrs *model.TrackAttrs) []Field {
+	return []Field{
+		{attrs.Title, WeightTitle},
+		{strings.Join(attrs.Genres, " "), WeightGenre},
+		{attrs.Comment, WeightComment},
+	}
+}
@@ -0,0 +1,180 @@
+// Package search matches full-text queries against resources of a music library.
+//
+// It provides the text processing shared by all stores, along with a naive implementation of [model.SearchStore] for stores without native full-text search.
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Terms splits a query into words folded with [Fold], dropping all punctuation.
+func Terms(query string) []string {
+	return strings.FieldsFunc(Fold(query), isSeparator)
+}
+
+// Fold converts text to lower case and strips it of accents.
+func Fold(text string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(t, text)
+	if err != nil {
+		folded = text
+	}
+	return strings.ToLower(folded)
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+}
+
+// matches checks whether every term is a prefix of some word of the text.
+func matches(terms []string, text string) bool {
+	words := Terms(text)
+	for _, term := range terms {
+		if !hasPrefixed(words, term) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasPrefixed(words []string, prefix string) bool {
+	for _, w := range words {
+		if strings.HasPrefix(w, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Field is a piece of searchable text with the weight of its matches.
+type Field struct {
+	Text   string
+	Weight float64
+}
+
+// Weights of fields, ranking matches in titles and names above other ones.
+const (
+	WeightTitle   = 1.0
+	WeightGenre   = 0.4
+	WeightComment = 0.1
+)
+
+// Score checks that each term is a prefix of some word of the fields, and ranks the match by the weights of the matched fields.
+func Score(terms []string, fields ...Field) (float64, bool) {
+	if len(terms) == 0 {
+		return 0, false
+	}
+
+	var all strings.Builder
+	score := 0.0
+	for _, f := range fields {
+		all.WriteString(f.Text)
+		all.WriteByte(' ')
+
+		words := Terms(f.Text)
+		for _, term := range terms {
+			if hasPrefixed(words, term) {
+				score += f.Weight
+			}
+		}
+	}
+
+	// Terms may match in different fields, but all of them must match somewhere
+	if !matches(terms, all.String()) {
+		return 0, false
+	}
+	return score / float64(len(terms)), true
+}
+
+// Marks surround the words matching the terms in highlighted snippets.
+const (
+	MarkStart = "<mark>"
+	MarkEnd   = "</mark>"
+)
+
+// maxSnippetWords is the number of words of context kept around the first match in snippets of long texts.
+const maxSnippetWords = 8
+
+// Highlight escapes the text for HTML and marks the words that start with any of the terms, reporting whether there were any.
+//
+// Long texts are cut down to the context of the first match, with the cuts marked by ellipses.
+func Highlight(text string, terms []string) (string, bool) {
+	type word struct {
+		start, end int
+		match      bool
+	}
+
+	var words []word
+	first := -1
+	for start := 0; start < len(text); {
+		r, size := utf8.DecodeRuneInString(text[start:])
+		if isSeparator(r) {
+			start += size
+			continue
+		}
+
+		end := start + strings.IndexFunc(text[start:], isSeparator)
+		if end < start {
+			end = len(text)
+		}
+
+		folded := Fold(text[start:end])
+		match := false
+		for _, term := range terms {
+			if strings.HasPrefix(folded, term) {
+				match = true
+				break
+			}
+		}
+
+		if match && first < 0 {
+			first = len(words)
+		}
+		words = append(words, word{start, end, match})
+		start = end
+	}
+
+	if first < 0 {
+		return "", false
+	}
+
+	from, to := 0, len(text)
+	if len(words) > 2*maxSnippetWords+1 {
+		if i := first - maxSnippetWords; i > 0 {
+			from = words[i].start
+		}
+		if i := first + maxSnippetWords; i < len(words)-1 {
+			to = words[i].end
+		}
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+
+	pos := from
+	for _, w := range words {
+		if !w.match || w.start < from || w.end > to {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[pos:w.start]))
+		b.WriteString(MarkStart)
+		b.WriteString(html.EscapeString(text[w.start:w.end]))
+		b.WriteString(MarkEnd)
+		pos = w.end
+	}
+	b.WriteString(html.EscapeString(text[pos:to]))
+
+	if to < len(text) {
+		b.WriteString("…")
+	}
+	return b.String(), true
+}
@@ -0,0 +1,104 @@
+package search_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cerfical/muzik/internal/memstore"
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/cerfical/muzik/internal/search"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSearch(t *testing.T) {
+	suite.Run(t, new(SearchTest))
+}
+
+type SearchTest struct {
+	suite.Suite
+}
+
+func (t *SearchTest) TestTerms() {
+	t.Equal([]string{"cafe", "del", "mar", "2"}, search.Terms("  Café del-Mar (#2) "))
+	t.Empty(search.Terms(" ?! "))
+}
+
+func (t *SearchTest) TestScore() {
+	terms := search.Terms("caf mid")
+
+	_, ok := search.Score(terms, search.Field{Text: "Café", Weight: search.WeightTitle})
+	t.False(ok)
+
+	title, ok := search.Score(terms,
+		search.Field{Text: "Midnight Café", Weight: search.WeightTitle},
+		search.Field{Text: "jazz", Weight: search.WeightGenre},
+	)
+	t.True(ok)
+
+	mixed, ok := search.Score(terms,
+		search.Field{Text: "Café", Weight: search.WeightTitle},
+		search.Field{Text: "at midnight", Weight: search.WeightComment},
+	)
+	t.True(ok)
+	t.Greater(title, mixed)
+
+	_, ok = search.Score(nil, search.Field{Text: "Café", Weight: search.WeightTitle})
+	t.False(ok)
+}
+
+func (t *SearchTest) TestHighlight() {
+	snippet, ok := search.Highlight("Rock & Roll, rocking", []string{"rock"})
+	t.True(ok)
+	t.Equal("<mark>Rock</mark> &amp; Roll, <mark>rocking</mark>", snippet)
+
+	snippet, ok = search.Highlight("Café <b>", []string{"cafe"})
+	t.True(ok)
+	t.Equal("<mark>Café</mark> &lt;b&gt;", snippet)
+
+	_, ok = search.Highlight("Midnight", []string{"cafe"})
+	t.False(ok)
+}
+
+func (t *SearchTest) TestHighlight_LongText() {
+	words := strings.Fields(strings.Repeat("la ", 20) + "café" + strings.Repeat(" la", 20))
+	snippet, ok := search.Highlight(strings.Join(words, " "), []string{"caf"})
+	t.True(ok)
+
+	want := "…" + strings.Repeat("la ", 8) + "<mark>café</mark>" + strings.Repeat(" la", 8) + "…"
+	t.Equal(want, snippet)
+}
+
+func (t *SearchTest) TestScan_Paginated() {
+	ctx := context.Background()
+	store := memstore.New()
+
+	// More tracks than are read at once, with the best match far from the start
+	tracks := make([]model.Track, 2500)
+	for i := range tracks {
+		tracks[i] = model.Track{Attrs: model.TrackAttrs{Title: "Song"}}
+	}
+	tracks[2199].Attrs.Genres = []string{"song"}
+	_, err := store.CreateTracks(ctx, tracks)
+	t.Require().NoError(err)
+
+	ids := func(page *model.SearchPage) []int {
+		var ids []int
+		for _, hit := range page.Hits {
+			ids = append(ids, hit.Resource.(*model.Track).ID)
+		}
+		return ids
+	}
+
+	query := model.SearchQuery{Text: "song", Types: []string{model.TrackType}, Limit: 2}
+	page, err := search.Scan(ctx, store, &query)
+	t.Require().NoError(err)
+	t.Equal(2500, page.Total)
+	t.Equal([]int{2200, 1}, ids(page))
+
+	query.Offset = 2400
+	page, err = search.Scan(ctx, store, &query)
+	t.Require().NoError(err)
+	t.Equal(2500, page.Total)
+	t.Equal([]int{2401, 2402}, ids(page))
+}
@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/cerfical/muzik/internal/model"
+)
+
+func (s *Store) CreateAlbum(ctx context.Context, attrs *model.AlbumAttrs) (*model.Album, error) {
+	id, err := s.createNamed(ctx, albumsTable, attrs.Title)
+	if err != nil {
+		return nil, err
+	}
+	return &model.Album{ID: id, Attrs: *attrs}, nil
+}
+
+func (s *Store) GetAlbum(ctx context.Context, id int) (*model.Album, error) {
+	title, err := s.getNamed(ctx, albumsTable, id)
+	if err != nil {
+		return nil, err
+	}
+	return &model.Album{ID: id, Attrs: model.AlbumAttrs{Title: title}}, nil
+}
+
+func (s *Store) GetAlbums(ctx context.Context) ([]model.Album, error) {
+	rows, err := s.getAllNamed(ctx, albumsTable)
+	if err != nil {
+		return nil, err
+	}
+	return albums(rows), nil
+}
+
+func (s *Store) GetAlbumsByIDs(ctx context.Context, ids []int) ([]model.Album, error) {
+	rows, err := s.getNamedByIDs(ctx, albumsTable, ids)
+	if err != nil {
+		return nil, err
+	}
+	return albums(rows), nil
+}
+
+func (s *Store) UpdateAlbum(ctx context.Context, id int, attrs *model.AlbumAttrs) (*model.Album, error) {
+	if err := s.updateNamed(ctx, albumsTable, id, attrs.Title); err != nil {
+		return nil, err
+	}
+	return &model.Album{ID: id, Attrs: *attrs}, nil
+}
+
+func (s *Store) DeleteAlbum(ctx context.Context, id int) error {
+	return s.deleteNamed(ctx, albumsTable, id)
+}
+
+func albums(rows []namedRow) []model.Album {
+	albums := make([]model.Album, 0, len(rows))
+	for _, row := range rows {
+		albums = append(albums, model.Album{ID: row.id, Attrs: model.AlbumAttrs{Title: row.value}})
+	}
+	return albums
+}
@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/cerfical/muzik/internal/model"
+)
+
+func (s *Store) CreateArtist(ctx context.Context, attrs *model.ArtistAttrs) (*model.Artist, error) {
+	id, err := s.createNamed(ctx, artistsTable, attrs.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &model.Artist{ID: id, Attrs: *attrs}, nil
+}
+
+func (s *Store) GetArtist(ctx context.Context, id int) (*model.Artist, error) {
+	name, err := s.getNamed(ctx, artistsTable, id)
+	if err != nil {
+		return nil, err
+	}
+	return &model.Artist{ID: id, Attrs: model.ArtistAttrs{Name: name}}, nil
+}
+
+func (s *Store) GetArtists(ctx context.Context) ([]model.Artist, error) {
+	rows, err := s.getAllNamed(ctx, artistsTable)
+	if err != nil {
+		return nil, err
+	}
+	return artists(rows), nil
+}
+
+func (s *Store) GetArtistsByIDs(ctx context.Context, ids []int) ([]model.Artist, error) {
+	rows, err := s.getNamedByIDs(ctx, artistsTable, ids)
+	if err != nil {
+		return nil, err
+	}
+	return artists(rows), nil
+}
+
+func (s *Store) UpdateArtist(ctx context.Context, id int, attrs *model.ArtistAttrs) (*model.Artist, error) {
+	if err := s.updateNamed(ctx, artistsTable, id, attrs.Name); err != nil {
+		return nil, err
+	}
+	return &model.Artist{ID: id, Attrs: *attrs}, nil
+}
+
+func (s *Store) DeleteArtist(ctx context.Context, id int) error {
+	return s.deleteNamed(ctx, artistsTable, id)
+}
+
+func artists(rows []namedRow) []model.Artist {
+	artists := make([]model.Artist, 0, len(rows))
+	for _, row := range rows {
+		artists = append(artists, model.Artist{ID: row.id, Attrs: model.ArtistAttrs{Name: row.value}})
+	}
+	return artists
+}
@@ -0,0 +1,91 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/cerfical/muzik/internal/sqlite"
+	"github.com/stretchr/testify/suite"
+	driver "modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+func TestConstraints(t *testing.T) {
+	suite.Run(t, new(ConstraintTest))
+}
+
+type ConstraintTest struct {
+	suite.Suite
+
+	ctx   context.Context
+	store *sqlite.Store
+
+	// db is a connection to the database of the store bypassing it
+	db *sql.DB
+}
+
+func (t *ConstraintTest) SetupTest() {
+	path := filepath.Join(t.T().TempDir(), "muzik.db")
+
+	var err error
+	t.ctx = context.Background()
+	t.store, err = sqlite.Open(&sqlite.Config{Path: path})
+	t.Require().NoError(err)
+	t.db, err = sql.Open("sqlite", "file:"+path+"?_pragma=foreign_keys(1)")
+	t.Require().NoError(err)
+
+	t.T().Cleanup(func() {
+		t.NoError(t.db.Close())
+		t.NoError(t.store.Close())
+	})
+}
+
+// errorCode returns the SQLite result code of the error, or zero if it is not an SQLite error.
+func errorCode(err error) int {
+	var sqliteErr *driver.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code()
+	}
+	return 0
+}
+
+func (t *ConstraintTest) TestRestrictedDelete() {
+	artist, err := t.store.CreateArtist(t.ctx, &model.ArtistAttrs{Name: "A"})
+	t.Require().NoError(err)
+	_, err = t.store.CreateTrack(t.ctx, &model.Track{
+		Attrs: model.TrackAttrs{Title: "T"},
+		Rels:  model.TrackRels{ArtistIDs: []int{artist.ID}},
+	})
+	t.Require().NoError(err)
+
+	// Restricted deletes fail with the code of triggers rather than that of foreign keys
+	_, err = t.db.ExecContext(t.ctx, "DELETE FROM artists WHERE id=?1", artist.ID)
+	t.Equal(sqlite3.SQLITE_CONSTRAINT_TRIGGER, errorCode(err))
+
+	t.ErrorIs(t.store.DeleteArtist(t.ctx, artist.ID), model.ErrConflict)
+}
+
+func (t *ConstraintTest) TestMissingReference() {
+	_, err := t.db.ExecContext(t.ctx, "INSERT INTO track_artists(track_id, artist_id, position) VALUES (100, 100, 0)")
+	t.Equal(sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY, errorCode(err))
+
+	_, err = t.store.CreateTrack(t.ctx, &model.Track{
+		Attrs: model.TrackAttrs{Title: "T"},
+		Rels:  model.TrackRels{ArtistIDs: []int{100}},
+	})
+	t.ErrorIs(err, model.ErrRelatedNotFound)
+}
+
+func (t *ConstraintTest) TestAuditLog_AppendOnly() {
+	_, err := t.store.AppendAudit(t.ctx, &model.AuditEntry{})
+	t.Require().NoError(err)
+
+	for _, stmt := range []string{"UPDATE audit_log SET actor='x'", "DELETE FROM audit_log"} {
+		_, err = t.db.ExecContext(t.ctx, stmt)
+		t.Equal(sqlite3.SQLITE_CONSTRAINT_TRIGGER, errorCode(err), stmt)
+	}
+}
@@ -0,0 +1,122 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/cerfical/muzik/internal/model"
+)
+
+// namedTable is a table of resources described by a single text column, such as the name of an artist.
+type namedTable struct {
+	table  string
+	column string
+}
+
+var (
+	artistsTable = namedTable{"artists", "name"}
+	albumsTable  = namedTable{"albums", "title"}
+)
+
+// namedRow is a row of a [namedTable].
+type namedRow struct {
+	id    int
+	value string
+}
+
+func (s *Store) createNamed(ctx context.Context, t namedTable, value string) (int, error) {
+	var id int
+	err := s.withTimeout(ctx, func(ctx context.Context) error {
+		row := s.db.QueryRowContext(ctx,
+			fmt.Sprintf("INSERT INTO %s(%s) VALUES(?1) RETURNING id", t.table, t.column),
+			value,
+		)
+		return row.Scan(&id)
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (s *Store) getNamed(ctx context.Context, t namedTable, id int) (string, error) {
+	var value string
+	err := s.withTimeout(ctx, func(ctx context.Context) error {
+		row := s.db.QueryRowContext(ctx, fmt.Sprintf("SELECT %s FROM %s WHERE id=?1", t.column, t.table), id)
+		return row.Scan(&value)
+	})
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", model.ErrNotFound
+		}
+		return "", err
+	}
+
+	return value, nil
+}
+
+func (s *Store) getAllNamed(ctx context.Context, t namedTable) ([]namedRow, error) {
+	return s.queryNamed(ctx, fmt.Sprintf("SELECT id, %s FROM %s ORDER BY id", t.column, t.table))
+}
+
+func (s *Store) getNamedByIDs(ctx context.Context, t namedTable, ids []int) ([]namedRow, error) {
+	list, err := idList(ids)
+	if err != nil {
+		return nil, err
+	}
+	return s.queryNamed(ctx, fmt.Sprintf("SELECT id, %s FROM %s WHERE id IN (SELECT value FROM json_each(?1)) ORDER BY id", t.column, t.table), list)
+}
+
+// queryNamed reads the rows of a [namedTable] selected by the query.
+func (s *Store) queryNamed(ctx context.Context, query string, args ...any) ([]namedRow, error) {
+	var all []namedRow
+	err := s.withTimeout(ctx, func(ctx context.Context) (err error) {
+		rows, err := s.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}()
+
+		for rows.Next() {
+			var row namedRow
+			if err = rows.Scan(&row.id, &row.value); err != nil {
+				return err
+			}
+			all = append(all, row)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return all, nil
+}
+
+func (s *Store) updateNamed(ctx context.Context, t namedTable, id int, value string) error {
+	return s.withTimeout(ctx, func(ctx context.Context) error {
+		return execOne(ctx, s.db, fmt.Sprintf("UPDATE %s SET %s=?2 WHERE id=?1", t.table, t.column), id, value)
+	})
+}
+
+func (s *Store) deleteNamed(ctx context.Context, t namedTable, id int) error {
+	err := s.withTimeout(ctx, func(ctx context.Context) error {
+		return execOne(ctx, s.db, fmt.Sprintf("DELETE FROM %s WHERE id=?1", t.table), id)
+	})
+
+	if isRestrictViolation(err) {
+		return model.ErrConflict
+	}
+	return err
+}
@@ -0,0 +1,327 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/cerfical/muzik/internal/sqlstore"
+)
+
+func (s *Store) CreatePlaylist(ctx context.Context, playlist *model.Playlist) (*model.Playlist, error) {
+	var created *model.Playlist
+	err := s.withTimeout(ctx, func(ctx context.Context) error {
+		return s.inTx(ctx, func(tx *sql.Tx) error {
+			var id int
+			row := tx.QueryRowContext(ctx,
+				"INSERT INTO playlists(name, description) VALUES(?1, ?2) RETURNING id",
+				playlist.Attrs.Name, playlist.Attrs.Description,
+			)
+			if err := row.Scan(&id); err != nil {
+				return err
+			}
+
+			if err := insertPlaylistTracks(ctx, tx, id, 0, playlist.Rels.TrackIDs); err != nil {
+				return err
+			}
+
+			var err error
+			created, err = getPlaylist(ctx, tx, id)
+			return err
+		})
+	})
+
+	if err != nil {
+		return nil, playlistError(err)
+	}
+	return created, nil
+}
+
+func (s *Store) GetPlaylist(ctx context.Context, id int) (*model.Playlist, error) {
+	var playlist *model.Playlist
+	err := s.withTimeout(ctx, func(ctx context.Context) (err error) {
+		playlist, err = getPlaylist(ctx, s.db, id)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return playlist, nil
+}
+
+func (s *Store) GetPlaylists(ctx context.Context) ([]model.Playlist, error) {
+	playlists := []model.Playlist{}
+	err := s.withTimeout(ctx, func(ctx context.Context) (err error) {
+		rows, err := s.db.QueryContext(ctx, selectPlaylists+" ORDER BY id")
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}()
+
+		for rows.Next() {
+			var playlist model.Playlist
+			if err = scanPlaylist(rows, &playlist); err != nil {
+				return err
+			}
+			playlists = append(playlists, playlist)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return playlists, nil
+}
+
+func (s *Store) UpdatePlaylist(ctx context.Context, playlist *model.Playlist) (*model.Playlist, error) {
+	return s.editPlaylist(ctx, playlist.ID, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE playlists SET name=?2, description=?3 WHERE id=?1",
+			playlist.ID, playlist.Attrs.Name, playlist.Attrs.Description,
+		); err != nil {
+			return err
+		}
+		return replacePlaylistTracks(ctx, tx, playlist.ID, playlist.Rels.TrackIDs)
+	})
+}
+
+func (s *Store) DeletePlaylist(ctx context.Context, id int) error {
+	return s.withTimeout(ctx, func(ctx context.Context) error {
+		return execOne(ctx, s.db, "DELETE FROM playlists WHERE id=?1", id)
+	})
+}
+
+func (s *Store) InsertPlaylistTracks(ctx context.Context, id int, index int, trackIDs []int) (*model.Playlist, error) {
+	return s.editPlaylist(ctx, id, func(tx *sql.Tx) error {
+		if index < 0 {
+			return appendPlaylistTracks(ctx, tx, id, trackIDs)
+		}
+
+		var pos int
+		err := tx.QueryRowContext(ctx,
+			"SELECT position FROM "+listedPlaylistTracks+" WHERE playlist_id=?1 ORDER BY position LIMIT 1 OFFSET ?2",
+			id, index,
+		).Scan(&pos)
+
+		if errors.Is(err, sql.ErrNoRows) {
+			// The index is past the end of the playlist
+			return appendPlaylistTracks(ctx, tx, id, trackIDs)
+		}
+		if err != nil {
+			return err
+		}
+
+		// Make room for the new tracks, going through negative positions,
+		// as the uniqueness of positions is checked for each row and cannot be deferred to commit
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE playlist_tracks SET position = -(position + ?3) - 1 WHERE playlist_id=?1 AND position >= ?2",
+			id, pos, len(trackIDs),
+		); err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE playlist_tracks SET position = -position - 1 WHERE playlist_id=?1 AND position < 0",
+			id,
+		); err != nil {
+			return err
+		}
+		return insertPlaylistTracks(ctx, tx, id, pos, trackIDs)
+	})
+}
+
+func (s *Store) SetPlaylistTracks(ctx context.Context, id int, trackIDs []int) (*model.Playlist, error) {
+	return s.editPlaylist(ctx, id, func(tx *sql.Tx) error {
+		return replacePlaylistTracks(ctx, tx, id, trackIDs)
+	})
+}
+
+func (s *Store) RemovePlaylistTracks(ctx context.Context, id int, trackIDs []int) (*model.Playlist, error) {
+	return s.editPlaylist(ctx, id, func(tx *sql.Tx) error {
+		for _, trackID := range trackIDs {
+			if _, err := tx.ExecContext(ctx,
+				"DELETE FROM playlist_tracks WHERE playlist_id=?1 AND track_id=?2",
+				id, trackID,
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// editPlaylist runs f in a transaction after checking that the playlist exists.
+//
+// Concurrent edits are applied one after another, as all access to the database goes through a single connection.
+func (s *Store) editPlaylist(ctx context.Context, id int, f func(tx *sql.Tx) error) (*model.Playlist, error) {
+	var playlist *model.Playlist
+	err := s.withTimeout(ctx, func(ctx context.Context) error {
+		return s.inTx(ctx, func(tx *sql.Tx) error {
+			row := tx.QueryRowContext(ctx, "SELECT id FROM playlists WHERE id=?1", id)
+			if err := row.Scan(&id); err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return model.ErrNotFound
+				}
+				return err
+			}
+
+			if err := f(tx); err != nil {
+				return err
+			}
+
+			var err error
+			playlist, err = getPlaylist(ctx, tx, id)
+			return err
+		})
+	})
+
+	if err != nil {
+		return nil, playlistError(err)
+	}
+	return playlist, nil
+}
+
+// listedPlaylistTracks selects the tracks listed in playlists, leaving out those in the trash.
+const listedPlaylistTracks = `(
+	SELECT playlist_tracks.*
+	FROM playlist_tracks JOIN tracks ON tracks.id = playlist_tracks.track_id
+	WHERE tracks.deleted_at IS NULL
+) AS playlist_tracks`
+
+// selectPlaylists selects playlist columns in the order expected by [scanPlaylist].
+const selectPlaylists = `
+	SELECT id, name, description, (
+		SELECT group_concat(track_id, ',' ORDER BY position)
+		FROM ` + listedPlaylistTracks + `
+		WHERE playlist_id = playlists.id
+	)
+	FROM playlists`
+
+func scanPlaylist(row scanner, playlist *model.Playlist) error {
+	var trackIDs *string
+	if err := row.Scan(&playlist.ID, &playlist.Attrs.Name, &playlist.Attrs.Description, &trackIDs); err != nil {
+		return err
+	}
+
+	ids, err := sqlstore.SplitIDs(trackIDs)
+	if err != nil {
+		return err
+	}
+
+	playlist.Rels.TrackIDs = ids
+	return nil
+}
+
+func getPlaylist(ctx context.Context, q querier, id int) (*model.Playlist, error) {
+	var playlist model.Playlist
+	if err := scanPlaylist(q.QueryRowContext(ctx, selectPlaylists+" WHERE id=?1", id), &playlist); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+		return nil, err
+	}
+	return &playlist, nil
+}
+
+func appendPlaylistTracks(ctx context.Context, tx *sql.Tx, id int, trackIDs []int) error {
+	var pos int
+	row := tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(position) + 1, 0) FROM playlist_tracks WHERE playlist_id=?1", id)
+	if err := row.Scan(&pos); err != nil {
+		return err
+	}
+	return insertPlaylistTracks(ctx, tx, id, pos, trackIDs)
+}
+
+// replacePlaylistTracks replaces the listed tracks of a playlist, putting the new ones in their places.
+func replacePlaylistTracks(ctx context.Context, tx *sql.Tx, id int, trackIDs []int) error {
+	positions, err := listedPositions(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	var next int
+	row := tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(position) + 1, 0) FROM playlist_tracks WHERE playlist_id=?1", id)
+	if err := row.Scan(&next); err != nil {
+		return err
+	}
+
+	// Rows of tracks in the trash are kept
+	if _, err := tx.ExecContext(ctx,
+		"DELETE FROM playlist_tracks WHERE playlist_id=?1 AND track_id IN (SELECT id FROM tracks WHERE deleted_at IS NULL)",
+		id,
+	); err != nil {
+		return err
+	}
+
+	for i, trackID := range trackIDs {
+		pos := next + i - len(positions)
+		if i < len(positions) {
+			pos = positions[i]
+		}
+		if err := insertPlaylistTrack(ctx, tx, id, pos, trackID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listedPositions returns the positions of the tracks listed in a playlist in ascending order.
+func listedPositions(ctx context.Context, tx *sql.Tx, id int) (positions []int, err error) {
+	rows, err := tx.QueryContext(ctx, "SELECT position FROM "+listedPlaylistTracks+" WHERE playlist_id=?1 ORDER BY position", id)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if closeErr := rows.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	for rows.Next() {
+		var pos int
+		if err := rows.Scan(&pos); err != nil {
+			return nil, err
+		}
+		positions = append(positions, pos)
+	}
+	return positions, rows.Err()
+}
+
+// insertPlaylistTracks inserts tracks at consecutive positions starting from pos.
+func insertPlaylistTracks(ctx context.Context, tx *sql.Tx, id int, pos int, trackIDs []int) error {
+	for i, trackID := range trackIDs {
+		if err := insertPlaylistTrack(ctx, tx, id, pos+i, trackID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func insertPlaylistTrack(ctx context.Context, tx *sql.Tx, id int, pos int, trackID int) error {
+	// Tracks in the trash satisfy the foreign key, so check for them explicitly
+	if err := execOne(ctx, tx,
+		"INSERT INTO playlist_tracks(playlist_id, track_id, position) SELECT ?1, id, ?3 FROM tracks WHERE id=?2 AND deleted_at IS NULL",
+		id, trackID, pos,
+	); err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return model.ErrRelatedNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func playlistError(err error) error {
+	if isForeignKeyViolation(err) {
+		return model.ErrRelatedNotFound
+	}
+	return err
+}
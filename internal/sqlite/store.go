@@ -0,0 +1,289 @@
+// Package sqlite stores a music library in a single SQLite database file, for deployments without a database server.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/cerfical/muzik/internal/sqlstore"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+type Config struct {
+	// Path is the path to the database file, or :memory: for a private in-memory database.
+	Path string
+
+	Timeout time.Duration
+}
+
+func Open(cfg *Config) (*Store, error) {
+	if cfg.Path == "" {
+		return nil, errors.New("invalid config: the database path is empty")
+	}
+
+	db, err := sql.Open("sqlite", makeConnString(cfg.Path))
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite allows only a single writer at a time, so serialize all access through a single connection,
+	// which is also what keeps in-memory databases from being private to each connection
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Store{db: db, pool: db, timeout: cfg.Timeout}, nil
+}
+
+// schema lists the changes to the database schema in order, with the index of the last applied one plus 1 kept as the user_version of the database.
+var schema = []string{`
+	CREATE TABLE IF NOT EXISTS artists(
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS albums(
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		title TEXT NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS tracks(
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		title TEXT NOT NULL,
+		album_id INTEGER REFERENCES albums(id) ON DELETE RESTRICT,
+		duration_ms INTEGER CHECK (duration_ms >= 0),
+		track_number INTEGER CHECK (track_number >= 1),
+		disc_number INTEGER CHECK (disc_number >= 1),
+		release_year INTEGER,
+		genres TEXT NOT NULL DEFAULT '[]' CHECK (json_valid(genres)),
+		isrc TEXT NOT NULL DEFAULT '',
+		bpm REAL CHECK (bpm > 0),
+		explicit INTEGER NOT NULL DEFAULT FALSE,
+		comment TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE TABLE IF NOT EXISTS track_artists(
+		track_id INTEGER NOT NULL REFERENCES tracks(id) ON DELETE CASCADE,
+		artist_id INTEGER NOT NULL REFERENCES artists(id) ON DELETE RESTRICT,
+		position INTEGER NOT NULL,
+		PRIMARY KEY (track_id, artist_id)
+	);
+
+	CREATE INDEX IF NOT EXISTS track_artists_artist_id_idx ON track_artists(artist_id);
+
+	CREATE TABLE IF NOT EXISTS playlists(
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		description TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE TABLE IF NOT EXISTS playlist_tracks(
+		playlist_id INTEGER NOT NULL REFERENCES playlists(id) ON DELETE CASCADE,
+		track_id INTEGER NOT NULL REFERENCES tracks(id) ON DELETE CASCADE,
+		position INTEGER NOT NULL,
+		PRIMARY KEY (playlist_id, position)
+	);
+
+	CREATE INDEX IF NOT EXISTS playlist_tracks_track_id_idx ON playlist_tracks(track_id);
+`,
+
+	`ALTER TABLE tracks ADD COLUMN revision INTEGER NOT NULL DEFAULT 1`,
+
+	`ALTER TABLE tracks ADD COLUMN deleted_at TIMESTAMP;
+	CREATE INDEX tracks_deleted_at_idx ON tracks(deleted_at) WHERE deleted_at IS NOT NULL`,
+
+	`CREATE TABLE audit_log(
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		logged_at TIMESTAMP NOT NULL,
+		actor TEXT NOT NULL DEFAULT '',
+		request_id TEXT NOT NULL DEFAULT '',
+		action TEXT NOT NULL,
+		resource_type TEXT NOT NULL,
+		resource_id INTEGER NOT NULL,
+		changes TEXT NOT NULL DEFAULT '{}' CHECK (json_valid(changes))
+	);
+
+	CREATE INDEX audit_log_resource_idx ON audit_log(resource_type, resource_id);
+	CREATE INDEX audit_log_logged_at_idx ON audit_log(logged_at);
+
+	-- The audit log can only be appended to
+	CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+	BEGIN
+		SELECT RAISE(ABORT, 'the audit log is append-only');
+	END;
+
+	CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log
+	BEGIN
+		SELECT RAISE(ABORT, 'the audit log is append-only');
+	END`,
+
+	`ALTER TABLE tracks ADD COLUMN audio_content_type TEXT;
+	ALTER TABLE tracks ADD COLUMN audio_size INTEGER;
+	ALTER TABLE tracks ADD COLUMN audio_sha256 TEXT`,
+
+	`CREATE INDEX tracks_audio_sha256_idx ON tracks(audio_sha256) WHERE audio_sha256 IS NOT NULL`,
+}
+
+// migrate brings the database schema up to date.
+func migrate(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+
+	for ; version < len(schema); version++ {
+		if err := inTx(context.Background(), db, func(tx *sql.Tx) error {
+			if _, err := tx.Exec(schema[version]); err != nil {
+				return err
+			}
+			_, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", version+1))
+			return err
+		}); err != nil {
+			return fmt.Errorf("migrate to version %d: %w", version+1, err)
+		}
+	}
+	return nil
+}
+
+func makeConnString(path string) string {
+	query := url.Values{}
+	query.Add("_pragma", "foreign_keys(1)")
+	query.Add("_pragma", "busy_timeout(5000)")
+	// Store times in a format that sorts in chronological order, as long as they are all in UTC
+	query.Add("_time_format", "sqlite")
+	return fmt.Sprintf("file:%s?%s", path, query.Encode())
+}
+
+// Store implements all the [model] store interfaces on top of a single database.
+type Store struct {
+	// db is either the database itself, or a transaction for stores passed to [Store.RunInTx]
+	db      querier
+	pool    *sql.DB
+	timeout time.Duration
+}
+
+// dialect writes queries in the SQL of SQLite, which compares ASCII letters ignoring case with LIKE.
+var dialect = &sqlstore.Dialect{
+	Placeholder: func(n int) string {
+		return fmt.Sprintf("?%d", n)
+	},
+	ContainsFold: func(column, placeholder string) string {
+		return fmt.Sprintf(`%s LIKE '%%' || %s || '%%' ESCAPE '\'`, column, placeholder)
+	},
+	Binary: func(column string) string {
+		// Text is compared byte by byte by default
+		return column
+	},
+}
+
+func (s *Store) withTimeout(ctx context.Context, f func(ctx context.Context) error) error {
+	return sqlstore.WithTimeout(ctx, s.timeout, f)
+}
+
+// inTx runs f in a transaction, committing it if f succeeds.
+// Within [Store.RunInTx], the transaction is nested, so that a failing f leaves the enclosing transaction intact.
+func (s *Store) inTx(ctx context.Context, f func(tx *sql.Tx) error) error {
+	if tx, ok := s.db.(*sql.Tx); ok {
+		return inSavepoint(ctx, tx, f)
+	}
+	return inTx(ctx, s.pool, f)
+}
+
+func inTx(ctx context.Context, db *sql.DB, f func(tx *sql.Tx) error) (err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := f(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func inSavepoint(ctx context.Context, tx *sql.Tx, f func(tx *sql.Tx) error) error {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT nested"); err != nil {
+		return err
+	}
+
+	if err := f(tx); err != nil {
+		// Roll back even if the context is done, as the transaction is still in use
+		ctx := context.WithoutCancel(ctx)
+		if _, rbErr := tx.ExecContext(ctx, "ROLLBACK TO nested; RELEASE nested"); rbErr != nil {
+			return errors.Join(err, rbErr)
+		}
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, "RELEASE nested")
+	return err
+}
+
+// RunInTx runs f in a transaction, which never has to be retried, as all transactions go through a single connection one after another.
+func (s *Store) RunInTx(ctx context.Context, f func(model.TxStores) error) error {
+	return inTx(ctx, s.pool, func(tx *sql.Tx) error {
+		return f(&Store{db: tx, timeout: s.timeout})
+	})
+}
+
+func (s *Store) Close() error {
+	return s.pool.Close()
+}
+
+// isForeignKeyViolation reports whether a row refers to a row that does not exist.
+func isForeignKeyViolation(err error) bool {
+	return hasErrorCode(err, sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY)
+}
+
+// isRestrictViolation reports whether a row is still referred to by rows restricting its deletion.
+//
+// ON DELETE RESTRICT actions are implemented as triggers and fail with the same code as other triggers,
+// such as the ones keeping the audit log append-only, so this only tells them apart for tables without triggers of their own.
+func isRestrictViolation(err error) bool {
+	return hasErrorCode(err, sqlite3.SQLITE_CONSTRAINT_TRIGGER)
+}
+
+func hasErrorCode(err error, code int) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == code
+}
+
+// execOne executes a statement expected to affect exactly one row, reporting [model.ErrNotFound] otherwise.
+func execOne(ctx context.Context, db execer, query string, args ...any) error {
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	return sqlstore.ExpectOne(n)
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// querier is implemented by both [sql.DB] and [sql.Tx].
+type querier interface {
+	execer
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
@@ -0,0 +1,67 @@
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/cerfical/muzik/internal/sqlite"
+	"github.com/cerfical/muzik/internal/storetest"
+)
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) model.Store {
+		store, err := sqlite.Open(&sqlite.Config{Path: ":memory:"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return store
+	})
+}
+
+func TestOpen_Upgrade(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "muzik.db")
+	store, err := sqlite.Open(&sqlite.Config{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	// Roll the database back to the first version of the schema, which lacks everything added later
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		"DROP TABLE audit_log",
+		"DROP INDEX tracks_deleted_at_idx",
+		"ALTER TABLE tracks DROP COLUMN deleted_at",
+		"ALTER TABLE tracks DROP COLUMN revision",
+		"DROP INDEX tracks_audio_sha256_idx",
+		"ALTER TABLE tracks DROP COLUMN audio_content_type",
+		"ALTER TABLE tracks DROP COLUMN audio_size",
+		"ALTER TABLE tracks DROP COLUMN audio_sha256",
+		"PRAGMA user_version = 1",
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	store, err = sqlite.Open(&sqlite.Config{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	track, err := store.CreateTrack(context.Background(), &model.Track{Attrs: model.TrackAttrs{Title: "T"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if track.Revision != 1 {
+		t.Errorf("got revision %d, want 1", track.Revision)
+	}
+}
@@ -0,0 +1,586 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/cerfical/muzik/internal/sqlstore"
+)
+
+// selectTracks selects track columns in the order expected by [scanTrack].
+const selectTracks = `
+	SELECT
+		id, title, duration_ms, track_number, disc_number, release_year,
+		genres, isrc, bpm, explicit, comment, revision, deleted_at,
+		audio_content_type, audio_size, audio_sha256, album_id, (
+			SELECT group_concat(artist_id, ',' ORDER BY position)
+			FROM track_artists
+			WHERE track_id = tracks.id
+		)
+	FROM tracks`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanTrack(row scanner, track *model.Track) error {
+	var genres []byte
+	var albumID sql.NullInt64
+	var artistIDs *string
+	var audioType, audioSHA256 sql.NullString
+	var audioSize sql.NullInt64
+
+	attrs := &track.Attrs
+	if err := row.Scan(
+		&track.ID, &attrs.Title, &attrs.Duration, &attrs.TrackNumber, &attrs.DiscNumber, &attrs.Year,
+		&genres, &attrs.ISRC, &attrs.BPM, &attrs.Explicit, &attrs.Comment, &track.Revision, &track.DeletedAt,
+		&audioType, &audioSize, &audioSHA256, &albumID, &artistIDs,
+	); err != nil {
+		return err
+	}
+
+	track.Meta = trackMeta(audioType, audioSize, audioSHA256)
+
+	if err := json.Unmarshal(genres, &attrs.Genres); err != nil {
+		return err
+	}
+
+	ids, err := sqlstore.SplitIDs(artistIDs)
+	if err != nil {
+		return err
+	}
+
+	track.Rels = model.TrackRels{
+		AlbumID:   int(albumID.Int64),
+		ArtistIDs: ids,
+	}
+	return nil
+}
+
+// trackMeta makes the meta of a track from its audio columns, returning nil if the track has no audio.
+func trackMeta(audioType sql.NullString, audioSize sql.NullInt64, audioSHA256 sql.NullString) *model.TrackMeta {
+	if !audioSHA256.Valid {
+		return nil
+	}
+
+	return &model.TrackMeta{Audio: &model.Audio{
+		ContentType: audioType.String,
+		Size:        audioSize.Int64,
+		SHA256:      audioSHA256.String,
+	}}
+}
+
+func (s *Store) CreateTrack(ctx context.Context, track *model.Track) (*model.Track, error) {
+	var id, revision int
+	err := s.withTimeout(ctx, func(ctx context.Context) error {
+		return s.inTx(ctx, func(tx *sql.Tx) error {
+			genres, err := sqlstore.MarshalGenres(track.Attrs.Genres)
+			if err != nil {
+				return err
+			}
+
+			row := tx.QueryRowContext(ctx, `
+				INSERT INTO tracks(
+					title, duration_ms, track_number, disc_number, release_year,
+					genres, isrc, bpm, explicit, comment, album_id
+				) VALUES(?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11)
+				RETURNING id, revision`,
+				track.Attrs.Title, track.Attrs.Duration, track.Attrs.TrackNumber, track.Attrs.DiscNumber, track.Attrs.Year,
+				genres, track.Attrs.ISRC, track.Attrs.BPM, track.Attrs.Explicit, track.Attrs.Comment, nullID(track.Rels.AlbumID),
+			)
+			if err := row.Scan(&id, &revision); err != nil {
+				return err
+			}
+			return setTrackArtists(ctx, tx, id, track.Rels.ArtistIDs)
+		})
+	})
+
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return nil, model.ErrRelatedNotFound
+		}
+		return nil, err
+	}
+
+	return sqlstore.NewTrack(id, revision, track), nil
+}
+
+func (s *Store) CreateTracks(ctx context.Context, tracks []model.Track) ([]model.Track, error) {
+	created := make([]model.Track, len(tracks))
+	err := s.withTimeout(ctx, func(ctx context.Context) error {
+		return s.inTx(ctx, func(tx *sql.Tx) error {
+			if err := checkTrackRels(ctx, tx, tracks); err != nil {
+				return err
+			}
+
+			stmt, err := tx.PrepareContext(ctx, `
+				INSERT INTO tracks(
+					title, duration_ms, track_number, disc_number, release_year,
+					genres, isrc, bpm, explicit, comment, album_id
+				) VALUES(?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11)
+				RETURNING id, revision`,
+			)
+			if err != nil {
+				return err
+			}
+			defer stmt.Close()
+
+			for i := range tracks {
+				track := &tracks[i]
+				genres, err := sqlstore.MarshalGenres(track.Attrs.Genres)
+				if err != nil {
+					return err
+				}
+
+				var id, revision int
+				row := stmt.QueryRowContext(ctx,
+					track.Attrs.Title, track.Attrs.Duration, track.Attrs.TrackNumber, track.Attrs.DiscNumber, track.Attrs.Year,
+					genres, track.Attrs.ISRC, track.Attrs.BPM, track.Attrs.Explicit, track.Attrs.Comment, nullID(track.Rels.AlbumID),
+				)
+				if err := row.Scan(&id, &revision); err != nil {
+					return err
+				}
+
+				if err := setTrackArtists(ctx, tx, id, track.Rels.ArtistIDs); err != nil {
+					return err
+				}
+				created[i] = *sqlstore.NewTrack(id, revision, track)
+			}
+			return nil
+		})
+	})
+
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return nil, model.ErrRelatedNotFound
+		}
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// checkTrackRels reports [model.ItemErrors] for the tracks that refer to artists or albums that do not exist.
+func checkTrackRels(ctx context.Context, tx *sql.Tx, tracks []model.Track) error {
+	var albumIDs, artistIDs []int
+	for i := range tracks {
+		if id := tracks[i].Rels.AlbumID; id != 0 {
+			albumIDs = append(albumIDs, id)
+		}
+		artistIDs = append(artistIDs, tracks[i].Rels.ArtistIDs...)
+	}
+
+	albums, err := existingIDs(ctx, tx, "albums", albumIDs)
+	if err != nil {
+		return err
+	}
+
+	artists, err := existingIDs(ctx, tx, "artists", artistIDs)
+	if err != nil {
+		return err
+	}
+
+	var errs model.ItemErrors
+	for i := range tracks {
+		rels := &tracks[i].Rels
+		ok := rels.AlbumID == 0 || albums[rels.AlbumID]
+		for _, id := range rels.ArtistIDs {
+			ok = ok && artists[id]
+		}
+
+		if !ok {
+			errs = append(errs, model.ItemError{Index: i, Err: model.ErrRelatedNotFound})
+		}
+	}
+
+	if errs != nil {
+		return errs
+	}
+	return nil
+}
+
+// existingIDs selects the IDs out of the specified ones that refer to rows of the table.
+func existingIDs(ctx context.Context, tx *sql.Tx, table string, ids []int) (map[int]bool, error) {
+	list, err := idList(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT id FROM %s WHERE id IN (SELECT value FROM json_each(?1))", table), list)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	exists := make(map[int]bool)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		exists[id] = true
+	}
+	return exists, rows.Err()
+}
+
+// idList encodes IDs as a JSON array, to be expanded with json_each.
+func idList(ids []int) (string, error) {
+	if ids == nil {
+		ids = []int{}
+	}
+
+	b, err := json.Marshal(ids)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func (s *Store) GetTrack(ctx context.Context, id int) (*model.Track, error) {
+	var track model.Track
+	err := s.withTimeout(ctx, func(ctx context.Context) error {
+		row := s.db.QueryRowContext(ctx, selectTracks+" WHERE id=?1 AND deleted_at IS NULL", id)
+		return scanTrack(row, &track)
+	})
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &track, nil
+}
+
+func (s *Store) GetTrashedTrack(ctx context.Context, id int) (*model.Track, error) {
+	var track model.Track
+	err := s.withTimeout(ctx, func(ctx context.Context) error {
+		row := s.db.QueryRowContext(ctx, selectTracks+" WHERE id=?1 AND deleted_at IS NOT NULL", id)
+		return scanTrack(row, &track)
+	})
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, model.ErrNotFound
+		}
+		return nil, err
+	}
+
+	return &track, nil
+}
+
+func (s *Store) GetTracks(ctx context.Context, query *model.TrackQuery) (*model.TrackPage, error) {
+	cols, err := sqlstore.SortTracks(dialect, query.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := sqlstore.FilterTracks(dialect, &query.Filter, query.Trashed)
+	sel := filter.Clone()
+	if query.After > 0 {
+		sel.AfterTrack(cols, query.After)
+	}
+
+	// Negative limits mean no limit at all
+	limit := -1
+	if query.Limit > 0 {
+		limit = query.Limit
+	}
+
+	selQuery := fmt.Sprintf("%s %s %s LIMIT %s OFFSET %s",
+		selectTracks, sel.Where(), sqlstore.OrderBy(cols), sel.Arg(limit), sel.Arg(query.Offset),
+	)
+
+	page := model.TrackPage{Tracks: []model.Track{}}
+	err = s.withTimeout(ctx, func(ctx context.Context) (err error) {
+		row := s.db.QueryRowContext(ctx, "SELECT count(*) FROM tracks "+filter.Where(), filter.Args()...)
+		if err := row.Scan(&page.Total); err != nil {
+			return err
+		}
+
+		rows, err := s.db.QueryContext(ctx, selQuery, sel.Args()...)
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}()
+
+		for rows.Next() {
+			var track model.Track
+			if err = scanTrack(rows, &track); err != nil {
+				return err
+			}
+			page.Tracks = append(page.Tracks, track)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &page, nil
+}
+
+func (s *Store) GetTracksByIDs(ctx context.Context, ids []int) ([]model.Track, error) {
+	list, err := idList(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	tracks := []model.Track{}
+	err = s.withTimeout(ctx, func(ctx context.Context) (err error) {
+		rows, err := s.db.QueryContext(ctx,
+			selectTracks+" WHERE id IN (SELECT value FROM json_each(?1)) AND deleted_at IS NULL ORDER BY id", list,
+		)
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			if closeErr := rows.Close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}()
+
+		for rows.Next() {
+			var track model.Track
+			if err = scanTrack(rows, &track); err != nil {
+				return err
+			}
+			tracks = append(tracks, track)
+		}
+		return rows.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tracks, nil
+}
+
+func (s *Store) UpdateTrack(ctx context.Context, track *model.Track) (*model.Track, error) {
+	var revision int
+	var audioType, audioSHA256 sql.NullString
+	var audioSize sql.NullInt64
+	err := s.withTimeout(ctx, func(ctx context.Context) error {
+		return s.inTx(ctx, func(tx *sql.Tx) error {
+			genres, err := sqlstore.MarshalGenres(track.Attrs.Genres)
+			if err != nil {
+				return err
+			}
+
+			row := tx.QueryRowContext(ctx, `
+				UPDATE tracks SET
+					title=?2, duration_ms=?3, track_number=?4, disc_number=?5, release_year=?6,
+					genres=?7, isrc=?8, bpm=?9, explicit=?10, comment=?11, album_id=?12,
+					revision=revision + 1
+				WHERE id=?1 AND deleted_at IS NULL
+				RETURNING revision, audio_content_type, audio_size, audio_sha256`,
+				track.ID, track.Attrs.Title, track.Attrs.Duration, track.Attrs.TrackNumber, track.Attrs.DiscNumber, track.Attrs.Year,
+				genres, track.Attrs.ISRC, track.Attrs.BPM, track.Attrs.Explicit, track.Attrs.Comment, nullID(track.Rels.AlbumID),
+			)
+			if err := row.Scan(&revision, &audioType, &audioSize, &audioSHA256); err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return model.ErrNotFound
+				}
+				return err
+			}
+			return setTrackArtists(ctx, tx, track.ID, track.Rels.ArtistIDs)
+		})
+	})
+
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return nil, model.ErrRelatedNotFound
+		}
+		return nil, err
+	}
+
+	updated := sqlstore.NewTrack(track.ID, revision, track)
+	updated.Meta = trackMeta(audioType, audioSize, audioSHA256)
+	return updated, nil
+}
+
+func (s *Store) AudioInUse(ctx context.Context, sha256 string) (bool, error) {
+	var inUse bool
+	err := s.withTimeout(ctx, func(ctx context.Context) error {
+		row := s.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM tracks WHERE audio_sha256=?1)", sha256)
+		return row.Scan(&inUse)
+	})
+
+	if err != nil {
+		return false, err
+	}
+	return inUse, nil
+}
+
+func (s *Store) DeleteTrack(ctx context.Context, id int) error {
+	return s.withTimeout(ctx, func(ctx context.Context) error {
+		return execOne(ctx, s.db,
+			"UPDATE tracks SET deleted_at=?2 WHERE id=?1 AND deleted_at IS NULL", id, time.Now().UTC(),
+		)
+	})
+}
+
+func (s *Store) DeleteTracks(ctx context.Context, ids []int) error {
+	list, err := idList(ids)
+	if err != nil {
+		return err
+	}
+
+	return s.withTimeout(ctx, func(ctx context.Context) error {
+		return s.inTx(ctx, func(tx *sql.Tx) error {
+			rows, err := tx.QueryContext(ctx,
+				"UPDATE tracks SET deleted_at=?2 WHERE id IN (SELECT value FROM json_each(?1)) AND deleted_at IS NULL RETURNING id",
+				list, time.Now().UTC(),
+			)
+			if err != nil {
+				return err
+			}
+			defer rows.Close()
+
+			var deleted []int
+			for rows.Next() {
+				var id int
+				if err := rows.Scan(&id); err != nil {
+					return err
+				}
+				deleted = append(deleted, id)
+			}
+
+			if err := rows.Err(); err != nil {
+				return err
+			}
+
+			if errs := sqlstore.MissingItems(ids, deleted); errs != nil {
+				return errs
+			}
+			return nil
+		})
+	})
+}
+
+func (s *Store) SetTrackAudio(ctx context.Context, id int, audio *model.Audio) (*model.Track, error) {
+	var track model.Track
+	err := s.withTimeout(ctx, func(ctx context.Context) error {
+		return s.inTx(ctx, func(tx *sql.Tx) error {
+			if err := execOne(ctx, tx, `
+				UPDATE tracks
+				SET audio_content_type=?2, audio_size=?3, audio_sha256=?4, revision=revision + 1
+				WHERE id=?1 AND deleted_at IS NULL`,
+				id, audio.ContentType, audio.Size, audio.SHA256,
+			); err != nil {
+				return err
+			}
+			return scanTrack(tx.QueryRowContext(ctx, selectTracks+" WHERE id=?1", id), &track)
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return &track, nil
+}
+
+func (s *Store) RestoreTrack(ctx context.Context, id int) (*model.Track, error) {
+	var track model.Track
+	err := s.withTimeout(ctx, func(ctx context.Context) error {
+		return s.inTx(ctx, func(tx *sql.Tx) error {
+			if err := execOne(ctx, tx,
+				"UPDATE tracks SET deleted_at=NULL, revision=revision + 1 WHERE id=?1 AND deleted_at IS NOT NULL", id,
+			); err != nil {
+				return err
+			}
+			return scanTrack(tx.QueryRowContext(ctx, selectTracks+" WHERE id=?1", id), &track)
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return &track, nil
+}
+
+func (s *Store) PurgeTracks(ctx context.Context, before time.Time, limit int) ([]model.Track, error) {
+	// Negative limits mean no limit at all
+	if limit <= 0 {
+		limit = -1
+	}
+
+	purged := []model.Track{}
+	err := s.withTimeout(ctx, func(ctx context.Context) error {
+		return s.inTx(ctx, func(tx *sql.Tx) (err error) {
+			// Read the tracks before deleting them, as their artists are deleted along with them
+			rows, err := tx.QueryContext(ctx, selectTracks+" WHERE deleted_at < ?1 ORDER BY id LIMIT ?2", before.UTC(), limit)
+			if err != nil {
+				return err
+			}
+
+			defer func() {
+				if closeErr := rows.Close(); closeErr != nil && err == nil {
+					err = closeErr
+				}
+			}()
+
+			var ids []int
+			for rows.Next() {
+				var track model.Track
+				if err := scanTrack(rows, &track); err != nil {
+					return err
+				}
+				purged = append(purged, track)
+				ids = append(ids, track.ID)
+			}
+
+			if err := rows.Err(); err != nil {
+				return err
+			}
+
+			list, err := idList(ids)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.ExecContext(ctx, "DELETE FROM tracks WHERE id IN (SELECT value FROM json_each(?1))", list)
+			return err
+		})
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return purged, nil
+}
+
+func setTrackArtists(ctx context.Context, tx *sql.Tx, trackID int, artistIDs []int) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM track_artists WHERE track_id=?1", trackID); err != nil {
+		return err
+	}
+
+	for i, artistID := range artistIDs {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO track_artists(track_id, artist_id, position) VALUES(?1, ?2, ?3)",
+			trackID, artistID, i,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nullID maps zero IDs to NULL.
+func nullID(id int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: id != 0}
+}
@@ -0,0 +1,69 @@
+package sqlstore
+
+import (
+	"slices"
+	"strings"
+)
+
+// Dialect describes how the SQL of a database differs from the SQL of others.
+type Dialect struct {
+	// Placeholder makes the placeholder of the query parameter with the specified index, counting from 1.
+	Placeholder func(n int) string
+
+	// ContainsFold makes a condition matching values of the column containing the pattern given by the placeholder, ignoring case.
+	// Wildcards in the pattern are escaped with backslashes.
+	ContainsFold func(column, placeholder string) string
+
+	// Binary makes an expression of the text column that compares its values byte by byte, whatever the collation of the database,
+	// so that text is sorted the same by all stores.
+	Binary func(column string) string
+}
+
+// Query accumulates conditions of a WHERE clause along with their parameters.
+type Query struct {
+	dialect *Dialect
+	conds   []string
+	args    []any
+}
+
+// NewQuery makes an empty query in the dialect.
+func NewQuery(d *Dialect) *Query {
+	return &Query{dialect: d}
+}
+
+func (q *Query) Clone() *Query {
+	return &Query{
+		dialect: q.dialect,
+		conds:   slices.Clone(q.conds),
+		args:    slices.Clone(q.args),
+	}
+}
+
+// Arg adds a new parameter to the query and returns its placeholder.
+func (q *Query) Arg(v any) string {
+	q.args = append(q.args, v)
+	return q.dialect.Placeholder(len(q.args))
+}
+
+// Args returns the parameters of the query in the order of their placeholders.
+func (q *Query) Args() []any {
+	return q.args
+}
+
+// AddCond adds a condition for all the selected rows to satisfy.
+func (q *Query) AddCond(cond string) {
+	q.conds = append(q.conds, cond)
+}
+
+// Where makes the WHERE clause of the query, which is empty if there are no conditions.
+func (q *Query) Where() string {
+	if len(q.conds) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(q.conds, " AND ")
+}
+
+// EscapeLike escapes wildcards of a LIKE pattern with backslashes.
+func EscapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,100 @@
+// Package sqlstore provides the parts shared by the stores built on SQL databases,
+// with queries written for the dialect of each database.
+package sqlstore
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cerfical/muzik/internal/model"
+)
+
+// WithTimeout runs f with a context that expires after the timeout, or with the context itself if the timeout is zero.
+func WithTimeout(ctx context.Context, timeout time.Duration, f func(ctx context.Context) error) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return f(ctx)
+}
+
+// ExpectOne checks the number of rows affected by a statement expected to affect exactly one row, reporting [model.ErrNotFound] otherwise.
+func ExpectOne(rowsAffected int64) error {
+	if rowsAffected != 1 {
+		return model.ErrNotFound
+	}
+	return nil
+}
+
+// MissingItems reports [model.ItemErrors] wrapping [model.ErrNotFound] for the IDs that were not found.
+func MissingItems(ids []int, found []int) model.ItemErrors {
+	var errs model.ItemErrors
+	for i, id := range ids {
+		if !slices.Contains(found, id) {
+			errs = append(errs, model.ItemError{Index: i, Err: model.ErrNotFound})
+		}
+	}
+	return errs
+}
+
+// SplitIDs parses a comma-separated list of IDs, treating NULL as an empty list.
+func SplitIDs(s *string) ([]int, error) {
+	ids := []int{}
+	if s == nil {
+		return ids, nil
+	}
+
+	for _, idStr := range strings.Split(*s, ",") {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// NewTrack makes a copy of the track data with the specified ID and revision.
+func NewTrack(id int, revision int, data *model.Track) *model.Track {
+	track := model.Track{
+		ID:    id,
+		Attrs: data.Attrs,
+		Rels: model.TrackRels{
+			AlbumID:   data.Rels.AlbumID,
+			ArtistIDs: append([]int{}, data.Rels.ArtistIDs...),
+		},
+		Revision: revision,
+	}
+	return &track
+}
+
+// MarshalGenres encodes genres as a JSON array, which is never null.
+func MarshalGenres(genres []string) (string, error) {
+	if genres == nil {
+		genres = []string{}
+	}
+
+	b, err := json.Marshal(genres)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// MarshalChanges encodes changes as a JSON object, which is never null.
+func MarshalChanges(changes map[string]model.AuditChange) (string, error) {
+	if changes == nil {
+		changes = map[string]model.AuditChange{}
+	}
+
+	b, err := json.Marshal(changes)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,93 @@
+package sqlstore_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/cerfical/muzik/internal/sqlstore"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestSQLStore(t *testing.T) {
+	suite.Run(t, new(SQLStoreTest))
+}
+
+type SQLStoreTest struct {
+	suite.Suite
+}
+
+var dialect = &sqlstore.Dialect{
+	Placeholder: func(n int) string {
+		return fmt.Sprintf("$%d", n)
+	},
+	ContainsFold: func(column, placeholder string) string {
+		return fmt.Sprintf("%s ILIKE %s", column, placeholder)
+	},
+	Binary: func(column string) string {
+		return column + ` COLLATE "C"`
+	},
+}
+
+func (t *SQLStoreTest) TestFilterTracks() {
+	q := sqlstore.FilterTracks(dialect, &model.TrackFilter{Title: "50%_off"}, false)
+	t.Equal(`WHERE deleted_at IS NULL AND title ILIKE $1`, q.Where())
+	t.Equal([]any{`50\%\_off`}, q.Args())
+
+	q = sqlstore.FilterTracks(dialect, &model.TrackFilter{}, true)
+	t.Equal(`WHERE deleted_at IS NOT NULL`, q.Where())
+	t.Empty(q.Args())
+}
+
+func (t *SQLStoreTest) TestSortTracks() {
+	cols, err := sqlstore.SortTracks(dialect, []model.TrackOrder{{Field: model.TrackTitle, Desc: true}})
+	t.Require().NoError(err)
+	t.Equal(`ORDER BY title COLLATE "C" DESC, id`, sqlstore.OrderBy(cols))
+
+	// Sorting stops at IDs, which are unique
+	cols, err = sqlstore.SortTracks(dialect, []model.TrackOrder{{Field: model.TrackID}, {Field: model.TrackTitle}})
+	t.Require().NoError(err)
+	t.Equal("ORDER BY id", sqlstore.OrderBy(cols))
+
+	_, err = sqlstore.SortTracks(dialect, []model.TrackOrder{{Field: "genre"}})
+	t.Error(err)
+}
+
+func (t *SQLStoreTest) TestAfterTrack() {
+	cols, err := sqlstore.SortTracks(dialect, []model.TrackOrder{{Field: model.TrackTitle, Desc: true}})
+	t.Require().NoError(err)
+
+	// The cursor continues the placeholders of the query it is cloned from
+	q := sqlstore.FilterTracks(dialect, &model.TrackFilter{}, false).Clone()
+	q.AddCond("album_id = " + q.Arg(3))
+	q.AfterTrack(cols, 7)
+
+	t.Equal(
+		`WHERE deleted_at IS NULL AND album_id = $1 AND `+
+			`((title COLLATE "C" < (SELECT title COLLATE "C" FROM tracks WHERE id = $2)) OR `+
+			`(title COLLATE "C" = (SELECT title COLLATE "C" FROM tracks WHERE id = $2) AND id > $2))`,
+		q.Where(),
+	)
+	t.Equal([]any{3, 7}, q.Args())
+}
+
+func (t *SQLStoreTest) TestSplitIDs() {
+	ids, err := sqlstore.SplitIDs(nil)
+	t.Require().NoError(err)
+	t.Equal([]int{}, ids)
+
+	s := "3,1,2"
+	ids, err = sqlstore.SplitIDs(&s)
+	t.Require().NoError(err)
+	t.Equal([]int{3, 1, 2}, ids)
+}
+
+func (t *SQLStoreTest) TestMissingItems() {
+	t.Nil(sqlstore.MissingItems([]int{1, 2}, []int{2, 1}))
+
+	errs := sqlstore.MissingItems([]int{1, 2, 3}, []int{2})
+	t.Require().Len(errs, 2)
+	t.Equal(0, errs[0].Index)
+	t.Equal(2, errs[1].Index)
+	t.ErrorIs(errs[1].Err, model.ErrNotFound)
+}
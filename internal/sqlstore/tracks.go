@@ -0,0 +1,102 @@
+package sqlstore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cerfical/muzik/internal/model"
+)
+
+var trackColumns = map[model.TrackField]string{
+	model.TrackID:    "id",
+	model.TrackTitle: "title",
+}
+
+// textColumns are the columns of the tracks table holding text.
+var textColumns = map[string]bool{
+	"title": true,
+}
+
+// SortColumn is a column of the tracks table to sort by.
+type SortColumn struct {
+	// Name is the name of the column, or the expression to sort by for text columns.
+	Name string
+	Desc bool
+}
+
+// FilterTracks makes a query selecting either tracks in the trash or all other tracks matching the filter.
+func FilterTracks(d *Dialect, filter *model.TrackFilter, trashed bool) *Query {
+	q := NewQuery(d)
+	if trashed {
+		q.AddCond("deleted_at IS NOT NULL")
+	} else {
+		q.AddCond("deleted_at IS NULL")
+	}
+
+	if filter.Title != "" {
+		q.AddCond(d.ContainsFold("title", q.Arg(EscapeLike(filter.Title))))
+	}
+	return q
+}
+
+// SortTracks translates the sort order to columns, making sure the order is total by falling back to track IDs.
+func SortTracks(d *Dialect, order []model.TrackOrder) ([]SortColumn, error) {
+	var cols []SortColumn
+	for _, o := range order {
+		name, ok := trackColumns[o.Field]
+		if !ok {
+			return nil, fmt.Errorf("unknown track field %q", o.Field)
+		}
+		if textColumns[name] {
+			name = d.Binary(name)
+		}
+
+		cols = append(cols, SortColumn{name, o.Desc})
+		if o.Field == model.TrackID {
+			// IDs are unique, so any further ordering is redundant
+			return cols, nil
+		}
+	}
+	return append(cols, SortColumn{Name: "id"}), nil
+}
+
+// OrderBy makes the ORDER BY clause sorting by the columns.
+func OrderBy(cols []SortColumn) string {
+	terms := make([]string, len(cols))
+	for i, col := range cols {
+		terms[i] = col.Name
+		if col.Desc {
+			terms[i] += " DESC"
+		}
+	}
+	return "ORDER BY " + strings.Join(terms, ", ")
+}
+
+// AfterTrack adds a condition selecting rows that follow the track with the specified ID in the sort order.
+func (q *Query) AfterTrack(cols []SortColumn, id int) {
+	idArg := q.Arg(id)
+	cursor := func(col string) string {
+		if col == "id" {
+			return idArg
+		}
+		return fmt.Sprintf("(SELECT %s FROM tracks WHERE id = %s)", col, idArg)
+	}
+
+	// Expand the row comparison manually, as the sort directions of the columns may differ
+	alts := make([]string, len(cols))
+	for i, col := range cols {
+		var terms []string
+		for _, prev := range cols[:i] {
+			terms = append(terms, fmt.Sprintf("%s = %s", prev.Name, cursor(prev.Name)))
+		}
+
+		op := ">"
+		if col.Desc {
+			op = "<"
+		}
+		terms = append(terms, fmt.Sprintf("%s %s %s", col.Name, op, cursor(col.Name)))
+		alts[i] = "(" + strings.Join(terms, " AND ") + ")"
+	}
+
+	q.AddCond("(" + strings.Join(alts, " OR ") + ")")
+}
@@ -0,0 +1,1113 @@
+// Package storetest checks that implementations of [model.Store] behave as the rest of the application expects.
+package storetest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/stretchr/testify/suite"
+)
+
+// Factory makes a new empty store for a test.
+type Factory func(t *testing.T) model.Store
+
+// Run runs the conformance suite against stores made by the factory, with every test getting a store of its own.
+func Run(t *testing.T, newStore Factory) {
+	suite.Run(t, &StoreTest{newStore: newStore})
+}
+
+type StoreTest struct {
+	suite.Suite
+
+	newStore Factory
+
+	ctx   context.Context
+	store model.Store
+}
+
+func (t *StoreTest) SetupTest() {
+	t.ctx = context.Background()
+	t.store = t.newStore(t.T())
+	t.T().Cleanup(func() {
+		t.NoError(t.store.Close())
+	})
+}
+
+func (t *StoreTest) SetupSubTest() {
+	t.SetupTest()
+}
+
+func (t *StoreTest) createTrack(title string, rels model.TrackRels) *model.Track {
+	track, err := t.store.CreateTrack(t.ctx, &model.Track{
+		Attrs: model.TrackAttrs{Title: title},
+		Rels:  rels,
+	})
+	t.Require().NoError(err)
+	return track
+}
+
+func (t *StoreTest) TestTrackCRUD() {
+	artist, err := t.store.CreateArtist(t.ctx, &model.ArtistAttrs{Name: "A"})
+	t.Require().NoError(err)
+	album, err := t.store.CreateAlbum(t.ctx, &model.AlbumAttrs{Title: "B"})
+	t.Require().NoError(err)
+
+	want := model.Track{
+		Attrs: model.TrackAttrs{
+			Title:       "T",
+			Duration:    ptr(180000),
+			TrackNumber: ptr(2),
+			DiscNumber:  ptr(1),
+			Year:        ptr(1999),
+			Genres:      []string{"Rock", "Pop"},
+			ISRC:        "USRC17607839",
+			BPM:         ptr(120.5),
+			Explicit:    true,
+			Comment:     "C",
+		},
+		Rels: model.TrackRels{AlbumID: album.ID, ArtistIDs: []int{artist.ID}},
+	}
+
+	track, err := t.store.CreateTrack(t.ctx, &want)
+	t.Require().NoError(err)
+	want.ID = track.ID
+	want.Revision = 1
+	t.Equal(&want, track)
+
+	got, err := t.store.GetTrack(t.ctx, track.ID)
+	t.Require().NoError(err)
+	t.Equal(&want, got)
+
+	want.Attrs = model.TrackAttrs{Title: "U", Genres: []string{}}
+	want.Rels = model.TrackRels{ArtistIDs: []int{}}
+	track, err = t.store.UpdateTrack(t.ctx, &want)
+	t.Require().NoError(err)
+	want.Revision = 2
+	t.Equal(&want, track)
+
+	got, err = t.store.GetTrack(t.ctx, track.ID)
+	t.Require().NoError(err)
+	t.Equal(&want, got)
+
+	t.Require().NoError(t.store.DeleteTrack(t.ctx, track.ID))
+	_, err = t.store.GetTrack(t.ctx, track.ID)
+	t.ErrorIs(err, model.ErrNotFound)
+	t.ErrorIs(t.store.DeleteTrack(t.ctx, track.ID), model.ErrNotFound)
+}
+
+func (t *StoreTest) TestArtistCRUD() {
+	artist, err := t.store.CreateArtist(t.ctx, &model.ArtistAttrs{Name: "A"})
+	t.Require().NoError(err)
+	t.Equal("A", artist.Attrs.Name)
+
+	artist, err = t.store.UpdateArtist(t.ctx, artist.ID, &model.ArtistAttrs{Name: "B"})
+	t.Require().NoError(err)
+	t.Equal("B", artist.Attrs.Name)
+
+	artists, err := t.store.GetArtists(t.ctx)
+	t.Require().NoError(err)
+	t.Equal([]model.Artist{*artist}, artists)
+
+	t.Require().NoError(t.store.DeleteArtist(t.ctx, artist.ID))
+	_, err = t.store.GetArtist(t.ctx, artist.ID)
+	t.ErrorIs(err, model.ErrNotFound)
+	t.ErrorIs(t.store.DeleteArtist(t.ctx, artist.ID), model.ErrNotFound)
+}
+
+func (t *StoreTest) TestAlbumCRUD() {
+	album, err := t.store.CreateAlbum(t.ctx, &model.AlbumAttrs{Title: "A"})
+	t.Require().NoError(err)
+	t.Equal("A", album.Attrs.Title)
+
+	album, err = t.store.UpdateAlbum(t.ctx, album.ID, &model.AlbumAttrs{Title: "B"})
+	t.Require().NoError(err)
+	t.Equal("B", album.Attrs.Title)
+
+	albums, err := t.store.GetAlbums(t.ctx)
+	t.Require().NoError(err)
+	t.Equal([]model.Album{*album}, albums)
+
+	t.Require().NoError(t.store.DeleteAlbum(t.ctx, album.ID))
+	_, err = t.store.GetAlbum(t.ctx, album.ID)
+	t.ErrorIs(err, model.ErrNotFound)
+}
+
+func (t *StoreTest) TestGetByIDs() {
+	a1, err := t.store.CreateArtist(t.ctx, &model.ArtistAttrs{Name: "A"})
+	t.Require().NoError(err)
+	a2, err := t.store.CreateArtist(t.ctx, &model.ArtistAttrs{Name: "B"})
+	t.Require().NoError(err)
+	album, err := t.store.CreateAlbum(t.ctx, &model.AlbumAttrs{Title: "C"})
+	t.Require().NoError(err)
+
+	t1 := t.createTrack("T", model.TrackRels{AlbumID: album.ID, ArtistIDs: []int{a2.ID, a1.ID}})
+	t2 := t.createTrack("U", model.TrackRels{})
+	t3 := t.createTrack("V", model.TrackRels{})
+	t.Require().NoError(t.store.DeleteTrack(t.ctx, t3.ID))
+
+	// Missing IDs are skipped, repeated ones are returned once, and everything is in order of IDs
+	artists, err := t.store.GetArtistsByIDs(t.ctx, []int{a2.ID, 100, a1.ID, a2.ID})
+	t.Require().NoError(err)
+	t.Equal([]model.Artist{*a1, *a2}, artists)
+
+	albums, err := t.store.GetAlbumsByIDs(t.ctx, []int{album.ID, 100})
+	t.Require().NoError(err)
+	t.Equal([]model.Album{*album}, albums)
+
+	// Tracks in the trash are skipped as well
+	tracks, err := t.store.GetTracksByIDs(t.ctx, []int{t3.ID, t2.ID, t1.ID})
+	t.Require().NoError(err)
+	t.Require().Len(tracks, 2)
+	for i, want := range []*model.Track{t1, t2} {
+		got, err := t.store.GetTrack(t.ctx, want.ID)
+		t.Require().NoError(err)
+		t.Equal(*got, tracks[i])
+	}
+
+	tracks, err = t.store.GetTracksByIDs(t.ctx, nil)
+	t.Require().NoError(err)
+	t.Empty(tracks)
+}
+
+func (t *StoreTest) TestPlaylistCRUD() {
+	track := t.createTrack("T", model.TrackRels{})
+
+	want := model.Playlist{
+		Attrs: model.PlaylistAttrs{Name: "P", Description: "D"},
+		Rels:  model.PlaylistRels{TrackIDs: []int{track.ID, track.ID}},
+	}
+	playlist, err := t.store.CreatePlaylist(t.ctx, &want)
+	t.Require().NoError(err)
+	want.ID = playlist.ID
+	t.Equal(&want, playlist)
+
+	want.Attrs = model.PlaylistAttrs{Name: "Q"}
+	want.Rels.TrackIDs = []int{}
+	playlist, err = t.store.UpdatePlaylist(t.ctx, &want)
+	t.Require().NoError(err)
+	t.Equal(&want, playlist)
+
+	playlists, err := t.store.GetPlaylists(t.ctx)
+	t.Require().NoError(err)
+	t.Equal([]model.Playlist{want}, playlists)
+
+	t.Require().NoError(t.store.DeletePlaylist(t.ctx, playlist.ID))
+	_, err = t.store.GetPlaylist(t.ctx, playlist.ID)
+	t.ErrorIs(err, model.ErrNotFound)
+	t.ErrorIs(t.store.DeletePlaylist(t.ctx, playlist.ID), model.ErrNotFound)
+}
+
+func (t *StoreTest) TestEmpty() {
+	// Empty collections are never nil, so that they are encoded as empty lists
+	page, err := t.store.GetTracks(t.ctx, &model.TrackQuery{})
+	t.Require().NoError(err)
+	t.NotNil(page.Tracks)
+	t.Empty(page.Tracks)
+	t.Zero(page.Total)
+
+	artists, err := t.store.GetArtists(t.ctx)
+	t.Require().NoError(err)
+	t.NotNil(artists)
+	t.Empty(artists)
+
+	albums, err := t.store.GetAlbums(t.ctx)
+	t.Require().NoError(err)
+	t.NotNil(albums)
+	t.Empty(albums)
+
+	playlists, err := t.store.GetPlaylists(t.ctx)
+	t.Require().NoError(err)
+	t.NotNil(playlists)
+	t.Empty(playlists)
+}
+
+func (t *StoreTest) TestIDSequence() {
+	a1, err := t.store.CreateArtist(t.ctx, &model.ArtistAttrs{Name: "A"})
+	t.Require().NoError(err)
+	a2, err := t.store.CreateArtist(t.ctx, &model.ArtistAttrs{Name: "B"})
+	t.Require().NoError(err)
+	t.Require().NoError(t.store.DeleteArtist(t.ctx, a2.ID))
+	a3, err := t.store.CreateArtist(t.ctx, &model.ArtistAttrs{Name: "C"})
+	t.Require().NoError(err)
+
+	// Each resource type has its own sequence, and IDs are never reused
+	t.Equal([]int{1, 2, 3}, []int{a1.ID, a2.ID, a3.ID})
+	t.Equal(1, t.createTrack("T", model.TrackRels{}).ID)
+}
+
+func (t *StoreTest) TestNotFound() {
+	_, err := t.store.GetTrack(t.ctx, 1)
+	t.ErrorIs(err, model.ErrNotFound)
+
+	_, err = t.store.UpdateTrack(t.ctx, &model.Track{ID: 1})
+	t.ErrorIs(err, model.ErrNotFound)
+
+	t.ErrorIs(t.store.DeleteTrack(t.ctx, 1), model.ErrNotFound)
+
+	_, err = t.store.UpdateArtist(t.ctx, 1, &model.ArtistAttrs{})
+	t.ErrorIs(err, model.ErrNotFound)
+
+	t.ErrorIs(t.store.DeleteAlbum(t.ctx, 1), model.ErrNotFound)
+
+	_, err = t.store.InsertPlaylistTracks(t.ctx, 1, 0, nil)
+	t.ErrorIs(err, model.ErrNotFound)
+}
+
+func (t *StoreTest) TestRelatedNotFound() {
+	_, err := t.store.CreateTrack(t.ctx, &model.Track{Rels: model.TrackRels{AlbumID: 1}})
+	t.ErrorIs(err, model.ErrRelatedNotFound)
+
+	_, err = t.store.CreateTrack(t.ctx, &model.Track{Rels: model.TrackRels{ArtistIDs: []int{1}}})
+	t.ErrorIs(err, model.ErrRelatedNotFound)
+
+	_, err = t.store.CreatePlaylist(t.ctx, &model.Playlist{Rels: model.PlaylistRels{TrackIDs: []int{1}}})
+	t.ErrorIs(err, model.ErrRelatedNotFound)
+}
+
+func (t *StoreTest) TestConflict() {
+	artist, err := t.store.CreateArtist(t.ctx, &model.ArtistAttrs{Name: "A"})
+	t.Require().NoError(err)
+	album, err := t.store.CreateAlbum(t.ctx, &model.AlbumAttrs{Title: "B"})
+	t.Require().NoError(err)
+
+	track := t.createTrack("T", model.TrackRels{AlbumID: album.ID, ArtistIDs: []int{artist.ID}})
+	t.ErrorIs(t.store.DeleteArtist(t.ctx, artist.ID), model.ErrConflict)
+	t.ErrorIs(t.store.DeleteAlbum(t.ctx, album.ID), model.ErrConflict)
+
+	// Tracks in the trash still refer to their artists and album until purged
+	t.Require().NoError(t.store.DeleteTrack(t.ctx, track.ID))
+	t.ErrorIs(t.store.DeleteArtist(t.ctx, artist.ID), model.ErrConflict)
+	t.ErrorIs(t.store.DeleteAlbum(t.ctx, album.ID), model.ErrConflict)
+
+	_, err = t.store.PurgeTracks(t.ctx, time.Now().Add(time.Hour), 0)
+	t.Require().NoError(err)
+	t.NoError(t.store.DeleteArtist(t.ctx, artist.ID))
+	t.NoError(t.store.DeleteAlbum(t.ctx, album.ID))
+}
+
+func (t *StoreTest) TestTrash() {
+	track := t.createTrack("T", model.TrackRels{})
+	other := t.createTrack("U", model.TrackRels{})
+	playlist, err := t.store.CreatePlaylist(t.ctx, &model.Playlist{
+		Rels: model.PlaylistRels{TrackIDs: []int{track.ID, other.ID, track.ID}},
+	})
+	t.Require().NoError(err)
+
+	t.Require().NoError(t.store.DeleteTrack(t.ctx, track.ID))
+	t.ErrorIs(t.store.DeleteTrack(t.ctx, track.ID), model.ErrNotFound)
+
+	_, err = t.store.GetTrack(t.ctx, track.ID)
+	t.ErrorIs(err, model.ErrNotFound)
+	_, err = t.store.UpdateTrack(t.ctx, track)
+	t.ErrorIs(err, model.ErrNotFound)
+
+	page, err := t.store.GetTracks(t.ctx, &model.TrackQuery{})
+	t.Require().NoError(err)
+	t.Equal(1, page.Total)
+	t.Equal(other.ID, page.Tracks[0].ID)
+
+	page, err = t.store.GetTracks(t.ctx, &model.TrackQuery{Trashed: true})
+	t.Require().NoError(err)
+	t.Equal(1, page.Total)
+	t.Equal(track.ID, page.Tracks[0].ID)
+	t.NotNil(page.Tracks[0].DeletedAt)
+
+	trashed, err := t.store.GetTrashedTrack(t.ctx, track.ID)
+	t.Require().NoError(err)
+	t.Equal(page.Tracks[0].ID, trashed.ID)
+	t.Equal(page.Tracks[0].Revision, trashed.Revision)
+	_, err = t.store.GetTrashedTrack(t.ctx, other.ID)
+	t.ErrorIs(err, model.ErrNotFound)
+
+	// Trashing a track hides it from playlists, to which it cannot be added back while in the trash
+	got, err := t.store.GetPlaylist(t.ctx, playlist.ID)
+	t.Require().NoError(err)
+	t.Equal([]int{other.ID}, got.Rels.TrackIDs)
+
+	_, err = t.store.InsertPlaylistTracks(t.ctx, playlist.ID, -1, []int{track.ID})
+	t.ErrorIs(err, model.ErrRelatedNotFound)
+
+	// Positions of inserted tracks only count the tracks listed
+	got, err = t.store.InsertPlaylistTracks(t.ctx, playlist.ID, 0, []int{other.ID})
+	t.Require().NoError(err)
+	t.Equal([]int{other.ID, other.ID}, got.Rels.TrackIDs)
+
+	restored, err := t.store.RestoreTrack(t.ctx, track.ID)
+	t.Require().NoError(err)
+	t.Equal("T", restored.Attrs.Title)
+	t.Equal(2, restored.Revision)
+	t.Nil(restored.DeletedAt)
+
+	// A restored track is back in its playlists where it was
+	got, err = t.store.GetPlaylist(t.ctx, playlist.ID)
+	t.Require().NoError(err)
+	t.Equal([]int{track.ID, other.ID, other.ID, track.ID}, got.Rels.TrackIDs)
+
+	_, err = t.store.GetTrack(t.ctx, track.ID)
+	t.NoError(err)
+	_, err = t.store.RestoreTrack(t.ctx, track.ID)
+	t.ErrorIs(err, model.ErrNotFound)
+	_, err = t.store.RestoreTrack(t.ctx, 100)
+	t.ErrorIs(err, model.ErrNotFound)
+}
+
+func (t *StoreTest) TestTrash_ReplacePlaylistTracks() {
+	track := t.createTrack("T", model.TrackRels{})
+	other := t.createTrack("U", model.TrackRels{})
+	third := t.createTrack("V", model.TrackRels{})
+	playlist, err := t.store.CreatePlaylist(t.ctx, &model.Playlist{
+		Rels: model.PlaylistRels{TrackIDs: []int{other.ID, track.ID, third.ID}},
+	})
+	t.Require().NoError(err)
+
+	t.Require().NoError(t.store.DeleteTrack(t.ctx, track.ID))
+
+	// Replaced tracks take the places of the listed ones, with the tracks in the trash staying where they are
+	got, err := t.store.SetPlaylistTracks(t.ctx, playlist.ID, []int{third.ID, other.ID, third.ID})
+	t.Require().NoError(err)
+	t.Equal([]int{third.ID, other.ID, third.ID}, got.Rels.TrackIDs)
+
+	got, err = t.store.UpdatePlaylist(t.ctx, &model.Playlist{
+		ID:    playlist.ID,
+		Attrs: model.PlaylistAttrs{Name: "P"},
+		Rels:  model.PlaylistRels{TrackIDs: []int{other.ID}},
+	})
+	t.Require().NoError(err)
+	t.Equal([]int{other.ID}, got.Rels.TrackIDs)
+
+	_, err = t.store.RestoreTrack(t.ctx, track.ID)
+	t.Require().NoError(err)
+
+	got, err = t.store.GetPlaylist(t.ctx, playlist.ID)
+	t.Require().NoError(err)
+	t.Equal([]int{other.ID, track.ID}, got.Rels.TrackIDs)
+
+	// Tracks in the trash stay in empty playlists too
+	t.Require().NoError(t.store.DeleteTrack(t.ctx, track.ID))
+	_, err = t.store.SetPlaylistTracks(t.ctx, playlist.ID, []int{})
+	t.Require().NoError(err)
+	_, err = t.store.RestoreTrack(t.ctx, track.ID)
+	t.Require().NoError(err)
+
+	got, err = t.store.GetPlaylist(t.ctx, playlist.ID)
+	t.Require().NoError(err)
+	t.Equal([]int{track.ID}, got.Rels.TrackIDs)
+}
+
+func (t *StoreTest) TestTrackAudio() {
+	track := t.createTrack("T", model.TrackRels{})
+	t.Nil(track.Meta)
+
+	audio := model.Audio{ContentType: "audio/flac", Size: 1 << 40, SHA256: strings.Repeat("ab", 32)}
+	updated, err := t.store.SetTrackAudio(t.ctx, track.ID, &audio)
+	t.Require().NoError(err)
+	t.Equal(2, updated.Revision)
+	t.Equal(&model.TrackMeta{Audio: &audio}, updated.Meta)
+
+	got, err := t.store.GetTrack(t.ctx, track.ID)
+	t.Require().NoError(err)
+	t.Equal(updated, got)
+
+	// Updates keep the audio, which cannot be changed through them
+	got.Attrs.Title = "U"
+	got.Meta = &model.TrackMeta{Audio: &model.Audio{ContentType: "audio/ogg", Size: 1, SHA256: "00"}}
+	updated, err = t.store.UpdateTrack(t.ctx, got)
+	t.Require().NoError(err)
+	t.Equal(&model.TrackMeta{Audio: &audio}, updated.Meta)
+
+	page, err := t.store.GetTracks(t.ctx, &model.TrackQuery{})
+	t.Require().NoError(err)
+	t.Equal(&model.TrackMeta{Audio: &audio}, page.Tracks[0].Meta)
+
+	replaced := model.Audio{ContentType: "audio/mpeg", Size: 100, SHA256: strings.Repeat("cd", 32)}
+	updated, err = t.store.SetTrackAudio(t.ctx, track.ID, &replaced)
+	t.Require().NoError(err)
+	t.Equal(&model.TrackMeta{Audio: &replaced}, updated.Meta)
+
+	inUse, err := t.store.AudioInUse(t.ctx, audio.SHA256)
+	t.Require().NoError(err)
+	t.False(inUse)
+
+	t.Require().NoError(t.store.DeleteTrack(t.ctx, track.ID))
+
+	// Tracks in the trash still have their audio
+	inUse, err = t.store.AudioInUse(t.ctx, replaced.SHA256)
+	t.Require().NoError(err)
+	t.True(inUse)
+
+	_, err = t.store.SetTrackAudio(t.ctx, track.ID, &audio)
+	t.ErrorIs(err, model.ErrNotFound)
+	_, err = t.store.SetTrackAudio(t.ctx, 100, &audio)
+	t.ErrorIs(err, model.ErrNotFound)
+}
+
+func (t *StoreTest) TestCreateTracks() {
+	artist, err := t.store.CreateArtist(t.ctx, &model.ArtistAttrs{Name: "A"})
+	t.Require().NoError(err)
+	album, err := t.store.CreateAlbum(t.ctx, &model.AlbumAttrs{Title: "B"})
+	t.Require().NoError(err)
+
+	tracks := []model.Track{
+		{Attrs: model.TrackAttrs{Title: "T", Genres: []string{"rock"}}},
+		{Attrs: model.TrackAttrs{Title: "U"}, Rels: model.TrackRels{AlbumID: album.ID, ArtistIDs: []int{artist.ID}}},
+	}
+
+	created, err := t.store.CreateTracks(t.ctx, tracks)
+	t.Require().NoError(err)
+	t.Require().Len(created, 2)
+	t.NotEqual(created[0].ID, created[1].ID)
+
+	for i := range created {
+		got, err := t.store.GetTrack(t.ctx, created[i].ID)
+		t.Require().NoError(err)
+		t.Equal(created[i].Rels, got.Rels)
+		t.Equal(tracks[i].Attrs.Title, got.Attrs.Title)
+		t.Equal(1, got.Revision)
+	}
+	t.Equal(album.ID, created[1].Rels.AlbumID)
+	t.Equal([]int{artist.ID}, created[1].Rels.ArtistIDs)
+
+	// IDs keep increasing after bulk creation
+	next := t.createTrack("V", model.TrackRels{})
+	t.Greater(next.ID, created[1].ID)
+}
+
+func (t *StoreTest) TestCreateTracks_RelatedNotFound() {
+	artist, err := t.store.CreateArtist(t.ctx, &model.ArtistAttrs{Name: "A"})
+	t.Require().NoError(err)
+
+	_, err = t.store.CreateTracks(t.ctx, []model.Track{
+		{Attrs: model.TrackAttrs{Title: "T"}, Rels: model.TrackRels{AlbumID: 100}},
+		{Attrs: model.TrackAttrs{Title: "U"}, Rels: model.TrackRels{ArtistIDs: []int{artist.ID}}},
+		{Attrs: model.TrackAttrs{Title: "V"}, Rels: model.TrackRels{ArtistIDs: []int{artist.ID, 100}}},
+	})
+	t.ErrorIs(err, model.ErrRelatedNotFound)
+
+	var errs model.ItemErrors
+	t.Require().ErrorAs(err, &errs)
+	t.Equal([]int{0, 2}, itemIndices(errs))
+
+	// Nothing is created if any of the tracks fails
+	page, err := t.store.GetTracks(t.ctx, &model.TrackQuery{})
+	t.Require().NoError(err)
+	t.Zero(page.Total)
+}
+
+func (t *StoreTest) TestDeleteTracks() {
+	track := t.createTrack("T", model.TrackRels{})
+	other := t.createTrack("U", model.TrackRels{})
+	kept := t.createTrack("V", model.TrackRels{})
+	playlist, err := t.store.CreatePlaylist(t.ctx, &model.Playlist{
+		Rels: model.PlaylistRels{TrackIDs: []int{track.ID, kept.ID, other.ID}},
+	})
+	t.Require().NoError(err)
+
+	t.Require().NoError(t.store.DeleteTracks(t.ctx, []int{track.ID, other.ID}))
+
+	page, err := t.store.GetTracks(t.ctx, &model.TrackQuery{Trashed: true})
+	t.Require().NoError(err)
+	t.Equal(2, page.Total)
+
+	got, err := t.store.GetPlaylist(t.ctx, playlist.ID)
+	t.Require().NoError(err)
+	t.Equal([]int{kept.ID}, got.Rels.TrackIDs)
+
+	// Nothing is deleted if any of the tracks does not exist
+	err = t.store.DeleteTracks(t.ctx, []int{kept.ID, track.ID, 100})
+	t.ErrorIs(err, model.ErrNotFound)
+
+	var errs model.ItemErrors
+	t.Require().ErrorAs(err, &errs)
+	t.Equal([]int{1, 2}, itemIndices(errs))
+
+	_, err = t.store.GetTrack(t.ctx, kept.ID)
+	t.NoError(err)
+}
+
+func itemIndices(errs model.ItemErrors) []int {
+	indices := make([]int, len(errs))
+	for i := range errs {
+		indices[i] = errs[i].Index
+	}
+	return indices
+}
+
+func (t *StoreTest) TestPurgeTracks() {
+	kept := t.createTrack("T", model.TrackRels{})
+	trackIDs := []int{kept.ID}
+	for range 2 {
+		track := t.createTrack("U", model.TrackRels{})
+		trackIDs = append(trackIDs, track.ID)
+	}
+
+	playlist, err := t.store.CreatePlaylist(t.ctx, &model.Playlist{
+		Rels: model.PlaylistRels{TrackIDs: trackIDs},
+	})
+	t.Require().NoError(err)
+	t.Require().NoError(t.store.DeleteTracks(t.ctx, trackIDs[1:]))
+
+	purged, err := t.store.PurgeTracks(t.ctx, time.Now().Add(-time.Hour), 0)
+	t.Require().NoError(err)
+	t.Empty(purged)
+
+	// Tracks are purged in batches of limited size, in the order of their IDs
+	purged, err = t.store.PurgeTracks(t.ctx, time.Now().Add(time.Hour), 1)
+	t.Require().NoError(err)
+	t.Require().Len(purged, 1)
+	t.Equal(trackIDs[1], purged[0].ID)
+	t.Equal("U", purged[0].Attrs.Title)
+	t.NotNil(purged[0].DeletedAt)
+
+	purged, err = t.store.PurgeTracks(t.ctx, time.Now().Add(time.Hour), 0)
+	t.Require().NoError(err)
+	t.Require().Len(purged, 1)
+	t.Equal(trackIDs[2], purged[0].ID)
+
+	page, err := t.store.GetTracks(t.ctx, &model.TrackQuery{Trashed: true})
+	t.Require().NoError(err)
+	t.Zero(page.Total)
+
+	_, err = t.store.RestoreTrack(t.ctx, kept.ID+1)
+	t.ErrorIs(err, model.ErrNotFound)
+	_, err = t.store.GetTrack(t.ctx, kept.ID)
+	t.NoError(err)
+
+	// Purged tracks are gone from playlists for good
+	got, err := t.store.GetPlaylist(t.ctx, playlist.ID)
+	t.Require().NoError(err)
+	t.Equal([]int{kept.ID}, got.Rels.TrackIDs)
+}
+
+func (t *StoreTest) TestCopies() {
+	track := t.createTrack("T", model.TrackRels{})
+	track.Attrs.Genres = append(track.Attrs.Genres, "Rock")
+
+	got, err := t.store.GetTrack(t.ctx, track.ID)
+	t.Require().NoError(err)
+	t.Empty(got.Attrs.Genres)
+	t.NotNil(got.Rels.ArtistIDs)
+}
+
+func (t *StoreTest) TestGetTracks() {
+	tests := []struct {
+		name  string
+		query model.TrackQuery
+		ids   []int
+		total int
+	}{
+		{"all", model.TrackQuery{}, []int{1, 2, 3, 4}, 4},
+		{"filter_ignores_case", model.TrackQuery{Filter: model.TrackFilter{Title: "a"}}, []int{2, 4}, 2},
+		{"sort_desc", model.TrackQuery{Sort: []model.TrackOrder{{Field: model.TrackTitle, Desc: true}}}, []int{3, 1, 4, 2}, 4},
+		{"limit_offset", model.TrackQuery{Limit: 2, Offset: 1}, []int{2, 3}, 4},
+		{"offset_past_end", model.TrackQuery{Offset: 10}, []int{}, 4},
+		{"after", model.TrackQuery{Sort: []model.TrackOrder{{Field: model.TrackTitle}}, After: 4, Limit: 1}, []int{1}, 4},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func() {
+			for _, title := range []string{"b", "A", "c", "ab"} {
+				t.createTrack(title, model.TrackRels{})
+			}
+			page, err := t.store.GetTracks(t.ctx, &test.query)
+			t.Require().NoError(err)
+
+			ids := []int{}
+			for _, track := range page.Tracks {
+				ids = append(ids, track.ID)
+			}
+			t.Equal(test.ids, ids)
+			t.Equal(test.total, page.Total)
+		})
+	}
+}
+
+func (t *StoreTest) TestGetTracks_MixedCase() {
+	for _, title := range []string{"b", "B", "a", "A", "é"} {
+		t.createTrack(title, model.TrackRels{})
+	}
+
+	ids := func(query *model.TrackQuery) []int {
+		page, err := t.store.GetTracks(t.ctx, query)
+		t.Require().NoError(err)
+
+		ids := []int{}
+		for _, track := range page.Tracks {
+			ids = append(ids, track.ID)
+		}
+		return ids
+	}
+
+	// Titles are sorted by their bytes, whatever the collation
+	byTitle := []model.TrackOrder{{Field: model.TrackTitle}}
+	t.Equal([]int{4, 2, 3, 1, 5}, ids(&model.TrackQuery{Sort: byTitle}))
+	t.Equal([]int{3, 1, 5}, ids(&model.TrackQuery{Sort: byTitle, After: 2}))
+	t.Equal([]int{2, 4}, ids(&model.TrackQuery{Sort: []model.TrackOrder{{Field: model.TrackTitle, Desc: true}}, After: 3}))
+
+	t.Equal([]int{1, 2}, ids(&model.TrackQuery{Filter: model.TrackFilter{Title: "b"}}))
+}
+
+func (t *StoreTest) TestGetTracks_AfterDeletedTrack() {
+	for range 3 {
+		t.createTrack("T", model.TrackRels{})
+	}
+	t.Require().NoError(t.store.DeleteTrack(t.ctx, 2))
+	_, err := t.store.PurgeTracks(t.ctx, time.Now().Add(time.Hour), 0)
+	t.Require().NoError(err)
+
+	// Tracks can still be paged by ID, but there is nothing to compare other fields against
+	page, err := t.store.GetTracks(t.ctx, &model.TrackQuery{After: 2})
+	t.Require().NoError(err)
+	t.Len(page.Tracks, 1)
+	t.Equal(3, page.Tracks[0].ID)
+
+	page, err = t.store.GetTracks(t.ctx, &model.TrackQuery{After: 2, Sort: []model.TrackOrder{{Field: model.TrackTitle}}})
+	t.Require().NoError(err)
+	t.Empty(page.Tracks)
+}
+
+func (t *StoreTest) TestPlaylistTracks() {
+	for range 3 {
+		t.createTrack("T", model.TrackRels{})
+	}
+
+	playlist, err := t.store.CreatePlaylist(t.ctx, &model.Playlist{
+		Attrs: model.PlaylistAttrs{Name: "P"},
+		Rels:  model.PlaylistRels{TrackIDs: []int{1, 2}},
+	})
+	t.Require().NoError(err)
+
+	playlist, err = t.store.InsertPlaylistTracks(t.ctx, playlist.ID, 1, []int{3, 3})
+	t.Require().NoError(err)
+	t.Equal([]int{1, 3, 3, 2}, playlist.Rels.TrackIDs)
+
+	playlist, err = t.store.InsertPlaylistTracks(t.ctx, playlist.ID, 10, []int{1})
+	t.Require().NoError(err)
+	t.Equal([]int{1, 3, 3, 2, 1}, playlist.Rels.TrackIDs)
+
+	playlist, err = t.store.RemovePlaylistTracks(t.ctx, playlist.ID, []int{1})
+	t.Require().NoError(err)
+	t.Equal([]int{3, 3, 2}, playlist.Rels.TrackIDs)
+
+	t.Require().NoError(t.store.DeleteTrack(t.ctx, 3))
+	playlist, err = t.store.GetPlaylist(t.ctx, playlist.ID)
+	t.Require().NoError(err)
+	t.Equal([]int{2}, playlist.Rels.TrackIDs)
+
+	_, err = t.store.SetPlaylistTracks(t.ctx, playlist.ID, []int{3})
+	t.ErrorIs(err, model.ErrRelatedNotFound)
+}
+
+// createSearchable creates tracks 1-4, artist 1 and album 1 to search for.
+func (t *StoreTest) createSearchable() {
+	_, err := t.store.CreateTracks(t.ctx, []model.Track{
+		{Attrs: model.TrackAttrs{Title: "Café del Mar", Genres: []string{"chillout"}}},
+		{Attrs: model.TrackAttrs{Title: "Sunset", Comment: "Recorded at the café"}},
+		{Attrs: model.TrackAttrs{Title: "Midnight", Genres: []string{"jazz"}}},
+		{Attrs: model.TrackAttrs{Title: "Cafeteria"}},
+	})
+	t.Require().NoError(err)
+	_, err = t.store.CreateArtist(t.ctx, &model.ArtistAttrs{Name: "Cafe Quartet"})
+	t.Require().NoError(err)
+	_, err = t.store.CreateAlbum(t.ctx, &model.AlbumAttrs{Title: "Midnight Café"})
+	t.Require().NoError(err)
+}
+
+func (t *StoreTest) search(query *model.SearchQuery) (*model.SearchPage, []model.ResourceID) {
+	page, err := t.store.Search(t.ctx, query)
+	t.Require().NoError(err)
+
+	ids := []model.ResourceID{}
+	for _, hit := range page.Hits {
+		switch res := hit.Resource.(type) {
+		case *model.Track:
+			ids = append(ids, model.ResourceID{Type: model.TrackType, ID: res.ID})
+		case *model.Artist:
+			ids = append(ids, model.ResourceID{Type: model.ArtistType, ID: res.ID})
+		case *model.Album:
+			ids = append(ids, model.ResourceID{Type: model.AlbumType, ID: res.ID})
+		default:
+			t.Failf("unexpected resource", "%T", res)
+		}
+	}
+	return page, ids
+}
+
+func (t *StoreTest) TestSearch() {
+	track := func(id int) model.ResourceID { return model.ResourceID{Type: model.TrackType, ID: id} }
+	album := model.ResourceID{Type: model.AlbumType, ID: 1}
+
+	t.Run("matches prefixes ignoring case and accents", func() {
+		t.createSearchable()
+
+		page, ids := t.search(&model.SearchQuery{Text: "CAFÉ"})
+		t.Equal(5, page.Total)
+		t.ElementsMatch([]model.ResourceID{track(1), track(2), track(4), {Type: model.ArtistType, ID: 1}, album}, ids)
+	})
+
+	t.Run("requires every term to match", func() {
+		t.createSearchable()
+
+		_, ids := t.search(&model.SearchQuery{Text: "jazz, mid"})
+		t.Equal([]model.ResourceID{track(3)}, ids)
+	})
+
+	t.Run("ranks titles above comments", func() {
+		t.createSearchable()
+
+		page, ids := t.search(&model.SearchQuery{Text: "cafe", Types: []string{model.TrackType}})
+		t.Equal([]model.ResourceID{track(1), track(4), track(2)}, ids)
+		t.Greater(page.Hits[1].Score, page.Hits[2].Score)
+	})
+
+	t.Run("filters by type", func() {
+		t.createSearchable()
+
+		page, ids := t.search(&model.SearchQuery{Text: "midnight", Types: []string{model.ArtistType, model.AlbumType}})
+		t.Equal([]model.ResourceID{album}, ids)
+		t.Equal("Midnight Café", page.Hits[0].Resource.(*model.Album).Attrs.Title)
+	})
+
+	t.Run("ignores trashed tracks", func() {
+		t.createSearchable()
+		t.Require().NoError(t.store.DeleteTrack(t.ctx, 3))
+
+		_, ids := t.search(&model.SearchQuery{Text: "midnight"})
+		t.Equal([]model.ResourceID{album}, ids)
+	})
+
+	t.Run("paginates results", func() {
+		t.createSearchable()
+		_, all := t.search(&model.SearchQuery{Text: "caf"})
+
+		page, ids := t.search(&model.SearchQuery{Text: "caf", Limit: 2, Offset: 3})
+		t.Equal(5, page.Total)
+		t.Equal(all[3:5], ids)
+
+		page, ids = t.search(&model.SearchQuery{Text: "caf", Offset: 10})
+		t.Equal(5, page.Total)
+		t.Empty(ids)
+	})
+
+	t.Run("finds nothing without terms", func() {
+		t.createSearchable()
+
+		page, ids := t.search(&model.SearchQuery{Text: " ?! "})
+		t.Zero(page.Total)
+		t.NotNil(page.Hits)
+		t.Empty(ids)
+	})
+}
+
+// appendAudit appends entries for the first two tracks and the first artist.
+func (t *StoreTest) appendAudit() {
+	resources := []model.ResourceID{{Type: model.TrackType, ID: 1}, {Type: model.TrackType, ID: 2}, {Type: model.ArtistType, ID: 1}}
+	for i, res := range resources {
+		entry, err := t.store.AppendAudit(t.ctx, &model.AuditEntry{
+			Attrs: model.AuditAttrs{
+				Actor:     "A",
+				RequestID: "R",
+				Action:    model.AuditUpdate,
+				Resource:  res,
+				Changes: map[string]model.AuditChange{
+					"/attributes/title": {Before: json.RawMessage(`"T"`), After: json.RawMessage(`"U"`)},
+				},
+			},
+		})
+		t.Require().NoError(err)
+		t.Equal(i+1, entry.ID)
+		t.WithinDuration(time.Now(), entry.Attrs.Time, time.Minute)
+		t.Equal(res, entry.Attrs.Resource)
+	}
+}
+
+func (t *StoreTest) TestAudit() {
+	hourAgo, inHour := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	tests := []struct {
+		name  string
+		query model.AuditQuery
+		ids   []int
+		total int
+	}{
+		{"all", model.AuditQuery{}, []int{1, 2, 3}, 3},
+		{"resource_type", model.AuditQuery{Resource: model.ResourceID{Type: model.TrackType}}, []int{1, 2}, 2},
+		{"resource", model.AuditQuery{Resource: model.ResourceID{Type: model.TrackType, ID: 2}}, []int{2}, 1},
+		{"time_range", model.AuditQuery{Since: hourAgo, Until: inHour}, []int{1, 2, 3}, 3},
+		{"since", model.AuditQuery{Since: inHour}, []int{}, 0},
+		{"until", model.AuditQuery{Until: hourAgo}, []int{}, 0},
+		{"limit_offset", model.AuditQuery{Limit: 1, Offset: 1}, []int{2}, 3},
+		{"after", model.AuditQuery{After: 1}, []int{2, 3}, 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func() {
+			t.appendAudit()
+			page, err := t.store.GetAudit(t.ctx, &test.query)
+			t.Require().NoError(err)
+			t.Equal(test.total, page.Total)
+
+			ids := []int{}
+			for _, entry := range page.Entries {
+				ids = append(ids, entry.ID)
+			}
+			t.Equal(test.ids, ids)
+		})
+	}
+
+}
+
+func (t *StoreTest) TestAudit_Attrs() {
+	t.appendAudit()
+	page, err := t.store.GetAudit(t.ctx, &model.AuditQuery{Limit: 1})
+	t.Require().NoError(err)
+	attrs := page.Entries[0].Attrs
+	t.Equal("A", attrs.Actor)
+	t.Equal("R", attrs.RequestID)
+	t.Equal(model.AuditUpdate, attrs.Action)
+	t.Require().Contains(attrs.Changes, "/attributes/title")
+	t.JSONEq(`"T"`, string(attrs.Changes["/attributes/title"].Before))
+	t.JSONEq(`"U"`, string(attrs.Changes["/attributes/title"].After))
+}
+
+func (t *StoreTest) TestRunInTx() {
+	err := t.store.RunInTx(t.ctx, func(tx model.TxStores) error {
+		artist, err := tx.CreateArtist(t.ctx, &model.ArtistAttrs{Name: "A"})
+		if err != nil {
+			return err
+		}
+
+		// Changes are visible within the transaction
+		_, err = tx.CreateTrack(t.ctx, &model.Track{
+			Attrs: model.TrackAttrs{Title: "T"},
+			Rels:  model.TrackRels{ArtistIDs: []int{artist.ID}},
+		})
+		return err
+	})
+	t.Require().NoError(err)
+
+	track, err := t.store.GetTrack(t.ctx, 1)
+	t.Require().NoError(err)
+	t.Equal([]int{1}, track.Rels.ArtistIDs)
+}
+
+func (t *StoreTest) TestRunInTx_Rollback() {
+	track := t.createTrack("T", model.TrackRels{})
+	album, err := t.store.CreateAlbum(t.ctx, &model.AlbumAttrs{Title: "B"})
+	t.Require().NoError(err)
+	errAbort := errors.New("abort")
+
+	err = t.store.RunInTx(t.ctx, func(tx model.TxStores) error {
+		if _, err := tx.CreateArtist(t.ctx, &model.ArtistAttrs{Name: "A"}); err != nil {
+			return err
+		}
+		if err := tx.DeleteTrack(t.ctx, track.ID); err != nil {
+			return err
+		}
+
+		// A resource changed more than once is restored to how it was before all of the changes
+		if _, err := tx.UpdateAlbum(t.ctx, album.ID, &model.AlbumAttrs{Title: "C"}); err != nil {
+			return err
+		}
+		if err := tx.DeleteAlbum(t.ctx, album.ID); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	t.ErrorIs(err, errAbort)
+
+	_, err = t.store.GetTrack(t.ctx, track.ID)
+	t.NoError(err)
+
+	artists, err := t.store.GetArtists(t.ctx)
+	t.Require().NoError(err)
+	t.Empty(artists)
+
+	got, err := t.store.GetAlbum(t.ctx, album.ID)
+	t.Require().NoError(err)
+	t.Equal(album, got)
+}
+
+func (t *StoreTest) TestRunInTx_FailedOperation() {
+	err := t.store.RunInTx(t.ctx, func(tx model.TxStores) error {
+		// A failed operation is undone entirely, but leaves the transaction usable
+		_, err := tx.CreateTrack(t.ctx, &model.Track{
+			Attrs: model.TrackAttrs{Title: "T"},
+			Rels:  model.TrackRels{ArtistIDs: []int{1}},
+		})
+		if !errors.Is(err, model.ErrRelatedNotFound) {
+			return fmt.Errorf("expected %v, got %v", model.ErrRelatedNotFound, err)
+		}
+
+		_, err = tx.CreateArtist(t.ctx, &model.ArtistAttrs{Name: "A"})
+		return err
+	})
+	t.Require().NoError(err)
+
+	page, err := t.store.GetTracks(t.ctx, &model.TrackQuery{})
+	t.Require().NoError(err)
+	t.Empty(page.Tracks)
+
+	artists, err := t.store.GetArtists(t.ctx)
+	t.Require().NoError(err)
+	t.Len(artists, 1)
+}
+
+func (t *StoreTest) TestConcurrentTxs() {
+	// Keep the number low enough for stores that give up after a few retries of conflicting transactions
+	const n = 5
+
+	// Each transaction increments a counter, so lost updates would show up in the final value
+	album, err := t.store.CreateAlbum(t.ctx, &model.AlbumAttrs{Title: "0"})
+	t.Require().NoError(err)
+
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = t.store.RunInTx(t.ctx, func(tx model.TxStores) error {
+				a, err := tx.GetAlbum(t.ctx, album.ID)
+				if err != nil {
+					return err
+				}
+
+				count, err := strconv.Atoi(a.Attrs.Title)
+				if err != nil {
+					return err
+				}
+				_, err = tx.UpdateAlbum(t.ctx, album.ID, &model.AlbumAttrs{Title: strconv.Itoa(count + 1)})
+				return err
+			})
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		t.Require().NoError(err)
+	}
+
+	album, err = t.store.GetAlbum(t.ctx, album.ID)
+	t.Require().NoError(err)
+	t.Equal(strconv.Itoa(n), album.Attrs.Title)
+}
+
+func (t *StoreTest) TestCanceledContext() {
+	ctx, cancel := context.WithCancel(t.ctx)
+	cancel()
+	t.checkContextErr(ctx, context.Canceled)
+}
+
+func (t *StoreTest) TestExpiredDeadline() {
+	ctx, cancel := context.WithTimeout(t.ctx, -time.Second)
+	defer cancel()
+	t.checkContextErr(ctx, context.DeadlineExceeded)
+}
+
+// checkContextErr checks that operations fail with the error of an already done context, without making any changes.
+func (t *StoreTest) checkContextErr(ctx context.Context, want error) {
+	track := t.createTrack("T", model.TrackRels{})
+
+	_, err := t.store.CreateTrack(ctx, &model.Track{Attrs: model.TrackAttrs{Title: "U"}})
+	t.ErrorIs(err, want)
+
+	_, err = t.store.GetTrack(ctx, track.ID)
+	t.ErrorIs(err, want)
+
+	_, err = t.store.GetTracks(ctx, &model.TrackQuery{})
+	t.ErrorIs(err, want)
+
+	_, err = t.store.UpdateTrack(ctx, &model.Track{ID: track.ID, Attrs: model.TrackAttrs{Title: "U"}})
+	t.ErrorIs(err, want)
+
+	t.ErrorIs(t.store.DeleteTrack(ctx, track.ID), want)
+
+	_, err = t.store.CreateArtist(ctx, &model.ArtistAttrs{Name: "A"})
+	t.ErrorIs(err, want)
+
+	_, err = t.store.GetAlbums(ctx)
+	t.ErrorIs(err, want)
+
+	_, err = t.store.CreatePlaylist(ctx, &model.Playlist{Attrs: model.PlaylistAttrs{Name: "P"}})
+	t.ErrorIs(err, want)
+
+	page, err := t.store.GetTracks(t.ctx, &model.TrackQuery{})
+	t.Require().NoError(err)
+	t.Require().Len(page.Tracks, 1)
+	t.Equal("T", page.Tracks[0].Attrs.Title)
+
+	artists, err := t.store.GetArtists(t.ctx)
+	t.Require().NoError(err)
+	t.Empty(artists)
+
+	playlists, err := t.store.GetPlaylists(t.ctx)
+	t.Require().NoError(err)
+	t.Empty(playlists)
+}
+
+func (t *StoreTest) TestConcurrentCreates() {
+	const n = 100
+
+	var wg sync.WaitGroup
+	ids := make([]int, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			artist, err := t.store.CreateArtist(t.ctx, &model.ArtistAttrs{Name: "A"})
+			if err == nil {
+				ids[i] = artist.ID
+			}
+		}()
+	}
+	wg.Wait()
+
+	want := make([]int, n)
+	for i := range want {
+		want[i] = i + 1
+	}
+	t.ElementsMatch(want, ids)
+}
+
+func (t *StoreTest) TestConcurrentPlaylistEdits() {
+	const n = 50
+
+	track := t.createTrack("T", model.TrackRels{})
+	playlist, err := t.store.CreatePlaylist(t.ctx, &model.Playlist{Attrs: model.PlaylistAttrs{Name: "P"}})
+	t.Require().NoError(err)
+
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Half of the edits append tracks, and the other half insert them at the start
+			index := -1
+			if i%2 == 0 {
+				index = 0
+			}
+			_, errs[i] = t.store.InsertPlaylistTracks(t.ctx, playlist.ID, index, []int{track.ID})
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		t.Require().NoError(err)
+	}
+
+	playlist, err = t.store.GetPlaylist(t.ctx, playlist.ID)
+	t.Require().NoError(err)
+	t.Len(playlist.Rels.TrackIDs, n)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,158 @@
+package tags
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cerfical/muzik/internal/model"
+)
+
+// Conflict is a track attribute whose value differs from the one found in the tags.
+type Conflict struct {
+	// Field is the name of the attribute.
+	Field string `json:"attribute"`
+
+	// Value is the value of the attribute according to the tags.
+	Value any `json:"value"`
+}
+
+// Attrs converts the tags to track attributes, leaving out values that are not valid for tracks.
+func (t *Tags) Attrs() model.TrackAttrs {
+	attrs := model.TrackAttrs{
+		Title:   t.Title,
+		ISRC:    strings.ToUpper(strings.ReplaceAll(t.ISRC, "-", "")),
+		Comment: t.Comment,
+		Genres:  slices.Clone(t.Genres),
+	}
+
+	if t.Duration > 0 {
+		attrs.Duration = ptr(int(t.Duration.Milliseconds()))
+	}
+	if t.TrackNumber > 0 {
+		attrs.TrackNumber = ptr(t.TrackNumber)
+	}
+	if t.DiscNumber > 0 {
+		attrs.DiscNumber = ptr(t.DiscNumber)
+	}
+	if t.Year > 0 {
+		attrs.Year = ptr(t.Year)
+	}
+	if t.BPM > 0 {
+		attrs.BPM = ptr(t.BPM)
+	}
+
+	// A title is required by the validation, but may well be missing from the tags
+	probe := attrs
+	if strings.TrimSpace(probe.Title) == "" {
+		attrs.Title, probe.Title = "", "-"
+	}
+
+	var errs model.ValidationError
+	if errors.As(probe.Validate(), &errs) {
+		for _, e := range errs {
+			clearField(&attrs, e.Field)
+		}
+	}
+	return attrs
+}
+
+func clearField(attrs *model.TrackAttrs, field string) {
+	switch field {
+	case "duration":
+		attrs.Duration = nil
+	case "trackNumber":
+		attrs.TrackNumber = nil
+	case "discNumber":
+		attrs.DiscNumber = nil
+	case "year":
+		attrs.Year = nil
+	case "genres":
+		attrs.Genres = nil
+	case "isrc":
+		attrs.ISRC = ""
+	case "bpm":
+		attrs.BPM = nil
+	case "comment":
+		attrs.Comment = ""
+	}
+}
+
+// Apply fills in the attributes missing from attrs with the tagged ones, reporting whether there were any.
+// Attributes present in both with different values are reported as conflicts and left as they are.
+//
+// Titles and genres are compared ignoring case, and durations within a second of each other are considered equal,
+// since some formats only allow durations to be estimated.
+func Apply(attrs, tagged *model.TrackAttrs) (bool, []Conflict) {
+	changed := false
+	var conflicts []Conflict
+	apply := func(field string, missing, present, equal bool, fill func(), value any) {
+		switch {
+		case !present:
+		case missing:
+			fill()
+			changed = true
+		case !equal:
+			conflicts = append(conflicts, Conflict{Field: field, Value: value})
+		}
+	}
+
+	apply("title", strings.TrimSpace(attrs.Title) == "", tagged.Title != "",
+		strings.EqualFold(strings.TrimSpace(attrs.Title), strings.TrimSpace(tagged.Title)),
+		func() { attrs.Title = tagged.Title }, tagged.Title,
+	)
+
+	applyInt := func(field string, dst **int, src *int, equal func(a, b int) bool) {
+		if src == nil {
+			return
+		}
+		apply(field, *dst == nil, true, *dst != nil && equal(**dst, *src), func() { *dst = ptr(*src) }, *src)
+	}
+
+	same := func(a, b int) bool { return a == b }
+	applyInt("duration", &attrs.Duration, tagged.Duration, func(a, b int) bool {
+		return (time.Duration(a-b) * time.Millisecond).Abs() < time.Second
+	})
+	applyInt("trackNumber", &attrs.TrackNumber, tagged.TrackNumber, same)
+	applyInt("discNumber", &attrs.DiscNumber, tagged.DiscNumber, same)
+	applyInt("year", &attrs.Year, tagged.Year, same)
+
+	apply("genres", len(attrs.Genres) == 0, len(tagged.Genres) != 0, sameGenres(attrs.Genres, tagged.Genres),
+		func() { attrs.Genres = slices.Clone(tagged.Genres) }, tagged.Genres,
+	)
+
+	apply("isrc", attrs.ISRC == "", tagged.ISRC != "", attrs.ISRC == tagged.ISRC,
+		func() { attrs.ISRC = tagged.ISRC }, tagged.ISRC,
+	)
+
+	if tagged.BPM != nil {
+		apply("bpm", attrs.BPM == nil, true, attrs.BPM != nil && math.Abs(*attrs.BPM-*tagged.BPM) < 0.5,
+			func() { attrs.BPM = ptr(*tagged.BPM) }, *tagged.BPM,
+		)
+	}
+
+	apply("comment", strings.TrimSpace(attrs.Comment) == "", tagged.Comment != "",
+		strings.TrimSpace(attrs.Comment) == strings.TrimSpace(tagged.Comment),
+		func() { attrs.Comment = tagged.Comment }, tagged.Comment,
+	)
+	return changed, conflicts
+}
+
+// sameGenres compares two lists of genres as sets, ignoring case.
+func sameGenres(a, b []string) bool {
+	normalize := func(genres []string) []string {
+		n := make([]string, len(genres))
+		for i, g := range genres {
+			n[i] = strings.ToLower(strings.TrimSpace(g))
+		}
+		slices.Sort(n)
+		return slices.Compact(n)
+	}
+	return slices.Equal(normalize(a), normalize(b))
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
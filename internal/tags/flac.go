@@ -0,0 +1,77 @@
+package tags
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+const (
+	flacStreamInfo    = 0
+	flacVorbisComment = 4
+
+	flacStreamInfoSize = 34
+)
+
+func readFLAC(r io.ReaderAt, size int64, t *Tags) error {
+	// Some tools prepend ID3v2 tags to FLAC files, which only fill in what the Vorbis comment lacks
+	var id3 Tags
+	off, err := readID3v2(r, 0, &id3)
+	if err != nil {
+		return err
+	}
+	defer t.fill(&id3)
+
+	magic, err := readAt(r, off, 4)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(magic, []byte("fLaC")) {
+		return errMalformed
+	}
+	off += 4
+
+	for off < size {
+		header, err := readAt(r, off, 4)
+		if err != nil {
+			return err
+		}
+
+		last, blockType := header[0]&0x80 != 0, header[0]&0x7F
+		length := int(header[1])<<16 | int(header[2])<<8 | int(header[3])
+		off += 4
+
+		switch {
+		case blockType == flacStreamInfo && length >= flacStreamInfoSize:
+			info, err := readAt(r, off, flacStreamInfoSize)
+			if err != nil {
+				return err
+			}
+			t.Duration = flacDuration(info)
+		case blockType == flacVorbisComment && length <= maxBlockSize:
+			comment, err := readAt(r, off, length)
+			if err != nil {
+				return err
+			}
+			parseVorbisComment(comment, t)
+		}
+
+		if last {
+			break
+		}
+		off += int64(length)
+	}
+	return nil
+}
+
+// flacDuration computes the duration from the sample rate and the total number of samples in the STREAMINFO block.
+func flacDuration(info []byte) time.Duration {
+	// 20 bits of the sample rate, 3 bits of the channels, 5 bits of the sample size and 36 bits of the number of samples
+	v := binary.BigEndian.Uint64(info[10:18])
+	sampleRate, samples := v>>44, v&(1<<36-1)
+	if sampleRate == 0 {
+		return 0
+	}
+	return time.Duration(samples*1000/sampleRate) * time.Millisecond
+}
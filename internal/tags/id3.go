@@ -0,0 +1,399 @@
+package tags
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	id3v2HeaderSize = 10
+	id3v1Size       = 128
+)
+
+// id3v2Size reports the total size of an ID3v2 tag starting with the header, or zero if the header is not of an ID3v2 tag.
+func id3v2Size(header []byte) int64 {
+	if len(header) < id3v2HeaderSize || !bytes.HasPrefix(header, []byte("ID3")) || header[3] < 2 || header[3] > 4 {
+		return 0
+	}
+
+	size, ok := syncsafe(header[6:10])
+	if !ok {
+		return 0
+	}
+
+	size += id3v2HeaderSize
+	if header[5]&0x10 != 0 {
+		// A footer follows the tag
+		size += id3v2HeaderSize
+	}
+	return int64(size)
+}
+
+// readID3v2 reads an ID3v2 tag at the offset if there is one, reporting its total size.
+func readID3v2(r io.ReaderAt, off int64, t *Tags) (int64, error) {
+	header, err := readAt(r, off, id3v2HeaderSize)
+	if err != nil {
+		return 0, err
+	}
+
+	size := id3v2Size(header)
+	if size == 0 {
+		return 0, nil
+	}
+
+	bodySize, _ := syncsafe(header[6:10])
+	if bodySize > maxBlockSize {
+		return size, nil
+	}
+
+	body, err := readAt(r, off+id3v2HeaderSize, bodySize)
+	if err != nil {
+		return size, err
+	}
+
+	parseID3v2(header[3], header[5], body, t)
+	return size, nil
+}
+
+// parseID3v2 parses the body of an ID3v2 tag of the major version with the header flags.
+func parseID3v2(version, flags byte, body []byte, t *Tags) {
+	if version < 4 && flags&0x80 != 0 {
+		// Before version 2.4, unsynchronisation applies to the whole tag rather than to individual frames
+		body = resync(body)
+	}
+
+	if flags&0x40 != 0 && version > 2 {
+		// Skip the extended header, whose size only includes itself since version 2.4
+		if len(body) < 4 {
+			return
+		}
+
+		var extSize int
+		if version == 4 {
+			extSize, _ = syncsafe(body[:4])
+		} else {
+			extSize = int(binary.BigEndian.Uint32(body[:4])) + 4
+		}
+
+		if extSize > len(body) {
+			return
+		}
+		body = body[extSize:]
+	}
+
+	idSize, headerSize := 4, 10
+	if version == 2 {
+		idSize, headerSize = 3, 6
+	}
+
+	for len(body) >= headerSize && body[0] != 0 {
+		id := string(body[:idSize])
+
+		var size int
+		var formatFlags byte
+		switch version {
+		case 2:
+			size = int(body[3])<<16 | int(body[4])<<8 | int(body[5])
+		case 3:
+			size = int(binary.BigEndian.Uint32(body[4:8]))
+			formatFlags = body[9]
+		default:
+			size, _ = syncsafe(body[4:8])
+			formatFlags = body[9]
+		}
+
+		if size < 0 || size > len(body)-headerSize {
+			return
+		}
+
+		data := body[headerSize : headerSize+size]
+		body = body[headerSize+size:]
+
+		data, ok := frameData(version, formatFlags, data)
+		if !ok {
+			continue
+		}
+
+		if version == 2 {
+			id = id3v22Frames[id]
+		}
+		parseID3Frame(id, data, t)
+	}
+}
+
+// frameData undoes the transformations applied to the data of a frame, reporting whether they are supported.
+func frameData(version, flags byte, data []byte) ([]byte, bool) {
+	switch version {
+	case 3:
+		// Compressed and encrypted frames are skipped
+		if flags&0xC0 != 0 {
+			return nil, false
+		}
+
+		if flags&0x20 != 0 {
+			// Skip the group identifier
+			if len(data) < 1 {
+				return nil, false
+			}
+			data = data[1:]
+		}
+	case 4:
+		if flags&0x0C != 0 {
+			return nil, false
+		}
+
+		if flags&0x40 != 0 {
+			if len(data) < 1 {
+				return nil, false
+			}
+			data = data[1:]
+		}
+
+		if flags&0x01 != 0 {
+			// Skip the data length indicator
+			if len(data) < 4 {
+				return nil, false
+			}
+			data = data[4:]
+		}
+
+		if flags&0x02 != 0 {
+			data = resync(data)
+		}
+	}
+	return data, true
+}
+
+// id3v22Frames maps the IDs of ID3v2.2 frames to their later equivalents.
+var id3v22Frames = map[string]string{
+	"TT2": "TIT2",
+	"TP1": "TPE1",
+	"TAL": "TALB",
+	"TRK": "TRCK",
+	"TPA": "TPOS",
+	"TYE": "TYER",
+	"TCO": "TCON",
+	"TRC": "TSRC",
+	"TBP": "TBPM",
+	"COM": "COMM",
+}
+
+func parseID3Frame(id string, data []byte, t *Tags) {
+	if id == "COMM" {
+		// Comments with a description are mostly used by software to store its own data, such as iTunNORM
+		if t.Comment == "" && len(data) > 4 {
+			enc := data[0]
+			desc, text := splitID3Text(enc, data[4:])
+			if decodeID3Text(enc, desc) == "" {
+				t.Comment = strings.TrimSpace(decodeID3Text(enc, text))
+			}
+		}
+		return
+	}
+
+	if !strings.HasPrefix(id, "T") || len(data) < 1 {
+		return
+	}
+
+	values := id3TextValues(data)
+	if len(values) == 0 {
+		return
+	}
+
+	switch id {
+	case "TIT2":
+		t.Title = values[0]
+	case "TPE1":
+		t.Artists = values
+	case "TALB":
+		t.Album = values[0]
+	case "TRCK":
+		t.TrackNumber = parseNumber(values[0])
+	case "TPOS":
+		t.DiscNumber = parseNumber(values[0])
+	case "TYER", "TDRC":
+		if year := parseYear(values[0]); year > 0 {
+			t.Year = year
+		}
+	case "TCON":
+		for _, v := range values {
+			t.Genres = addGenre(t.Genres, id3Genre(v))
+		}
+	case "TSRC":
+		t.ISRC = values[0]
+	case "TBPM":
+		t.BPM = parseBPM(values[0])
+	}
+}
+
+// id3TextValues decodes the non-empty values of a text frame, which are separated by null characters since version 2.4.
+func id3TextValues(data []byte) []string {
+	enc, text := data[0], data[1:]
+
+	var values []string
+	for len(text) > 0 {
+		var value []byte
+		value, text = splitID3Text(enc, text)
+		if v := strings.TrimSpace(decodeID3Text(enc, value)); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// splitID3Text splits text of the encoding at the first null character.
+func splitID3Text(enc byte, b []byte) ([]byte, []byte) {
+	if enc == 1 || enc == 2 {
+		for i := 0; i+1 < len(b); i += 2 {
+			if b[i] == 0 && b[i+1] == 0 {
+				return b[:i], b[i+2:]
+			}
+		}
+		return b, nil
+	}
+
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		return b[:i], b[i+1:]
+	}
+	return b, nil
+}
+
+// decodeID3Text decodes text in one of the ID3v2 encodings: Latin-1, UTF-16 with a byte order mark, UTF-16BE or UTF-8.
+func decodeID3Text(enc byte, b []byte) string {
+	switch enc {
+	case 0:
+		return decodeLatin1(b)
+	case 1, 2:
+		var order binary.ByteOrder = binary.BigEndian
+		if enc == 1 && len(b) >= 2 {
+			switch {
+			case b[0] == 0xFF && b[1] == 0xFE:
+				order, b = binary.LittleEndian, b[2:]
+			case b[0] == 0xFE && b[1] == 0xFF:
+				b = b[2:]
+			}
+		}
+
+		units := make([]uint16, len(b)/2)
+		for i := range units {
+			units[i] = order.Uint16(b[2*i:])
+		}
+		return string(utf16.Decode(units))
+	default:
+		return string(bytes.ToValidUTF8(b, []byte("�")))
+	}
+}
+
+// id3Genre resolves references to the ID3v1 genres, as in "(17)", "17" or "(17)Rock".
+func id3Genre(s string) string {
+	if rest, ok := strings.CutPrefix(s, "("); ok {
+		ref, name, ok := strings.Cut(rest, ")")
+		if !ok {
+			return s
+		}
+
+		if name = strings.TrimSpace(name); name != "" {
+			return name
+		}
+		s = ref
+	}
+
+	switch s {
+	case "RX":
+		return "Remix"
+	case "CR":
+		return "Cover"
+	}
+
+	if n, err := strconv.Atoi(s); err == nil {
+		if n >= 0 && n < len(id3v1Genres) {
+			return id3v1Genres[n]
+		}
+		return ""
+	}
+	return s
+}
+
+// readID3v1 reads an ID3v1 tag at the end of a file of the size if there is one, reporting whether there was.
+func readID3v1(r io.ReaderAt, size int64, t *Tags) (bool, error) {
+	if size < id3v1Size {
+		return false, nil
+	}
+
+	tag, err := readAt(r, size-id3v1Size, id3v1Size)
+	if err != nil {
+		return false, err
+	}
+
+	if !bytes.HasPrefix(tag, []byte("TAG")) {
+		return false, nil
+	}
+
+	text := func(b []byte) string {
+		if i := bytes.IndexByte(b, 0); i >= 0 {
+			b = b[:i]
+		}
+		return strings.TrimSpace(decodeLatin1(b))
+	}
+
+	t.Title = text(tag[3:33])
+	if artist := text(tag[33:63]); artist != "" {
+		t.Artists = []string{artist}
+	}
+	t.Album = text(tag[63:93])
+	t.Year = parseNumber(text(tag[93:97]))
+
+	comment := tag[97:127]
+	if comment[28] == 0 && comment[29] != 0 {
+		// ID3v1.1 stores the track number in the last byte of the comment
+		t.TrackNumber = int(comment[29])
+		comment = comment[:28]
+	}
+	t.Comment = text(comment)
+
+	if genre := int(tag[127]); genre < len(id3v1Genres) {
+		t.Genres = []string{id3v1Genres[genre]}
+	}
+	return true, nil
+}
+
+// id3v1Genres lists the genres of ID3v1 along with the Winamp extensions, which ID3v2 genres may refer to.
+var id3v1Genres = []string{
+	"Blues", "Classic Rock", "Country", "Dance", "Disco", "Funk", "Grunge", "Hip-Hop",
+	"Jazz", "Metal", "New Age", "Oldies", "Other", "Pop", "R&B", "Rap",
+	"Reggae", "Rock", "Techno", "Industrial", "Alternative", "Ska", "Death Metal", "Pranks",
+	"Soundtrack", "Euro-Techno", "Ambient", "Trip-Hop", "Vocal", "Jazz+Funk", "Fusion", "Trance",
+	"Classical", "Instrumental", "Acid", "House", "Game", "Sound Clip", "Gospel", "Noise",
+	"Alternative Rock", "Bass", "Soul", "Punk", "Space", "Meditative", "Instrumental Pop", "Instrumental Rock",
+	"Ethnic", "Gothic", "Darkwave", "Techno-Industrial", "Electronic", "Pop-Folk", "Eurodance", "Dream",
+	"Southern Rock", "Comedy", "Cult", "Gangsta", "Top 40", "Christian Rap", "Pop/Funk", "Jungle",
+	"Native American", "Cabaret", "New Wave", "Psychedelic", "Rave", "Showtunes", "Trailer", "Lo-Fi",
+	"Tribal", "Acid Punk", "Acid Jazz", "Polka", "Retro", "Musical", "Rock & Roll", "Hard Rock",
+	"Folk", "Folk-Rock", "National Folk", "Swing", "Fast Fusion", "Bebop", "Latin", "Revival",
+	"Celtic", "Bluegrass", "Avantgarde", "Gothic Rock", "Progressive Rock", "Psychedelic Rock", "Symphonic Rock", "Slow Rock",
+	"Big Band", "Chorus", "Easy Listening", "Acoustic", "Humour", "Speech", "Chanson", "Opera",
+	"Chamber Music", "Sonata", "Symphony", "Booty Bass", "Primus", "Porn Groove", "Satire", "Slow Jam",
+	"Club", "Tango", "Samba", "Folklore", "Ballad", "Power Ballad", "Rhythmic Soul", "Freestyle",
+	"Duet", "Punk Rock", "Drum Solo", "A Cappella", "Euro-House", "Dance Hall",
+}
+
+// syncsafe decodes a 28-bit integer stored in four bytes with their high bits unset.
+func syncsafe(b []byte) (int, bool) {
+	n := 0
+	for _, c := range b[:4] {
+		if c&0x80 != 0 {
+			return 0, false
+		}
+		n = n<<7 | int(c)
+	}
+	return n, true
+}
+
+// resync undoes the unsynchronisation scheme, which inserts a zero byte after every 0xFF byte that could be mistaken for a frame sync.
+func resync(b []byte) []byte {
+	return bytes.ReplaceAll(b, []byte{0xFF, 0x00}, []byte{0xFF})
+}
@@ -0,0 +1,205 @@
+package tags
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// mpegScanSize is how far past the ID3v2 tag the first MPEG audio frame is looked for.
+const mpegScanSize = 64 << 10
+
+func readMP3(r io.ReaderAt, size int64, t *Tags) error {
+	start, err := readID3v2(r, 0, t)
+	if err != nil {
+		return err
+	}
+
+	var v1 Tags
+	hasV1, err := readID3v1(r, size, &v1)
+	if err != nil {
+		return err
+	}
+	t.fill(&v1)
+
+	end := size
+	if hasV1 {
+		end -= id3v1Size
+	}
+
+	t.Duration, err = mpegDuration(r, start, end)
+	return err
+}
+
+// mpegDuration computes the duration of the MPEG audio stream between the offsets.
+//
+// The number of frames is taken from the Xing or VBRI header of variable bitrate streams,
+// while the duration of constant bitrate streams is inferred from their size.
+func mpegDuration(r io.ReaderAt, start, end int64) (time.Duration, error) {
+	n := min(end-start, mpegScanSize)
+	if n <= 0 {
+		return 0, nil
+	}
+
+	buf := make([]byte, n)
+	if _, err := r.ReadAt(buf, start); err != nil && err != io.EOF {
+		return 0, err
+	}
+
+	off, frame, ok := findMPEGFrame(buf)
+	if !ok {
+		return 0, errMalformed
+	}
+
+	if frames, ok := vbrFrames(buf[off:], frame); ok {
+		samples := int64(frames) * int64(frame.samples())
+		return time.Duration(samples*1000/int64(frame.sampleRate)) * time.Millisecond, nil
+	}
+
+	// With the bitrate in kbit/s, the number of bits divided by it is the duration in milliseconds
+	bits := (end - start - int64(off)) * 8
+	return time.Duration(bits/int64(frame.bitrate)) * time.Millisecond, nil
+}
+
+// findMPEGFrame finds the first frame header that is followed by another one of the same stream, if the buffer is long enough.
+func findMPEGFrame(buf []byte) (int, mpegFrame, bool) {
+	for off := 0; off+4 <= len(buf); off++ {
+		frame, ok := parseMPEGFrame(buf[off:])
+		if !ok {
+			continue
+		}
+
+		next := off + frame.size()
+		if next+4 <= len(buf) {
+			nextFrame, ok := parseMPEGFrame(buf[next:])
+			if !ok || nextFrame.version != frame.version || nextFrame.layer != frame.layer || nextFrame.sampleRate != frame.sampleRate {
+				continue
+			}
+		}
+		return off, frame, true
+	}
+	return 0, mpegFrame{}, false
+}
+
+// mpegFrame describes an MPEG audio frame by its header.
+type mpegFrame struct {
+	// version is 1 for MPEG-1, 2 for MPEG-2 and 3 for MPEG-2.5.
+	version int
+	layer   int
+
+	bitrate    int // in kbit/s
+	sampleRate int // in Hz
+	padding    bool
+	mono       bool
+}
+
+var mpegBitrates = [...][15]int{
+	{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448}, // MPEG-1 Layer I
+	{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},    // MPEG-1 Layer II
+	{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},     // MPEG-1 Layer III
+	{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},    // MPEG-2 Layer I
+	{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},         // MPEG-2 Layers II and III
+}
+
+var mpegSampleRates = [...][3]int{
+	{44100, 48000, 32000}, // MPEG-1
+	{22050, 24000, 16000}, // MPEG-2
+	{11025, 12000, 8000},  // MPEG-2.5
+}
+
+func parseMPEGFrame(h []byte) (mpegFrame, bool) {
+	if len(h) < 4 || h[0] != 0xFF || h[1]&0xE0 != 0xE0 {
+		return mpegFrame{}, false
+	}
+
+	var f mpegFrame
+	switch h[1] >> 3 & 0x03 {
+	case 0x03:
+		f.version = 1
+	case 0x02:
+		f.version = 2
+	case 0x00:
+		f.version = 3
+	default:
+		return mpegFrame{}, false
+	}
+
+	f.layer = 4 - int(h[1]>>1&0x03)
+	if f.layer == 4 {
+		return mpegFrame{}, false
+	}
+
+	// Free-format streams have no bitrate in their headers and are not supported
+	bitrateIndex, sampleRateIndex := int(h[2]>>4), int(h[2]>>2&0x03)
+	if bitrateIndex == 0 || bitrateIndex == 0x0F || sampleRateIndex == 0x03 {
+		return mpegFrame{}, false
+	}
+
+	table := f.layer - 1
+	if f.version != 1 {
+		table = min(3+f.layer-1, 4)
+	}
+
+	f.bitrate = mpegBitrates[table][bitrateIndex]
+	f.sampleRate = mpegSampleRates[f.version-1][sampleRateIndex]
+	f.padding = h[2]&0x02 != 0
+	f.mono = h[3]>>6 == 0x03
+	return f, true
+}
+
+// samples returns the number of samples per channel in the frame.
+func (f *mpegFrame) samples() int {
+	switch {
+	case f.layer == 1:
+		return 384
+	case f.layer == 3 && f.version != 1:
+		return 576
+	default:
+		return 1152
+	}
+}
+
+// size returns the length of the frame in bytes, including the header.
+func (f *mpegFrame) size() int {
+	padding := 0
+	if f.padding {
+		padding = 1
+	}
+
+	if f.layer == 1 {
+		return (12*f.bitrate*1000/f.sampleRate + padding) * 4
+	}
+	return f.samples()/8*f.bitrate*1000/f.sampleRate + padding
+}
+
+// vbrFrames reads the number of frames in the stream from the Xing or VBRI header in its first frame, if there is one.
+func vbrFrames(buf []byte, f mpegFrame) (int, bool) {
+	if f.layer != 3 {
+		return 0, false
+	}
+
+	// The Xing header follows the side information, whose size depends on the version and the channel mode
+	sideInfo := 32
+	switch {
+	case f.version == 1 && f.mono, f.version != 1 && !f.mono:
+		sideInfo = 17
+	case f.version != 1 && f.mono:
+		sideInfo = 9
+	}
+
+	if xing := buf[min(4+sideInfo, len(buf)):]; len(xing) >= 12 && (bytes.HasPrefix(xing, []byte("Xing")) || bytes.HasPrefix(xing, []byte("Info"))) {
+		if flags := binary.BigEndian.Uint32(xing[4:8]); flags&0x01 != 0 {
+			frames := int(binary.BigEndian.Uint32(xing[8:12]))
+			return frames, frames > 0
+		}
+		return 0, false
+	}
+
+	// The VBRI header written by the Fraunhofer encoder is always at the same offset
+	if vbri := buf[min(36, len(buf)):]; len(vbri) >= 18 && bytes.HasPrefix(vbri, []byte("VBRI")) {
+		frames := int(binary.BigEndian.Uint32(vbri[14:18]))
+		return frames, frames > 0
+	}
+	return 0, false
+}
@@ -0,0 +1,142 @@
+package tags
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+const (
+	oggPageHeaderSize = 27
+
+	// oggTailSize is how far from the end of the file the last page is looked for.
+	oggTailSize = 64 << 10
+
+	// opusSampleRate is the rate of the granule positions of Opus streams, whatever the rate of the original audio.
+	opusSampleRate = 48000
+)
+
+// readOgg reads the tags of the first logical stream of an Ogg file, which must be Vorbis or Opus.
+func readOgg(r io.ReaderAt, size int64, t *Tags) error {
+	packets, serial, err := oggHeaderPackets(r, size)
+	if err != nil {
+		return err
+	}
+
+	ident, comment := packets[0], packets[1]
+
+	var sampleRate, preSkip uint64
+	switch {
+	case bytes.HasPrefix(ident, []byte("\x01vorbis")) && len(ident) >= 16:
+		sampleRate = uint64(binary.LittleEndian.Uint32(ident[12:16]))
+		if c, ok := bytes.CutPrefix(comment, []byte("\x03vorbis")); ok {
+			parseVorbisComment(c, t)
+		}
+	case bytes.HasPrefix(ident, []byte("OpusHead")) && len(ident) >= 12:
+		sampleRate = opusSampleRate
+		preSkip = uint64(binary.LittleEndian.Uint16(ident[10:12]))
+		if c, ok := bytes.CutPrefix(comment, []byte("OpusTags")); ok {
+			parseVorbisComment(c, t)
+		}
+	default:
+		return errMalformed
+	}
+
+	granule, err := oggLastGranule(r, size, serial)
+	if err != nil {
+		return err
+	}
+
+	if sampleRate > 0 && granule > preSkip {
+		t.Duration = time.Duration((granule-preSkip)*1000/sampleRate) * time.Millisecond
+	}
+	return nil
+}
+
+// oggHeaderPackets reassembles the first two packets of the first logical stream, which identify the codec and carry the comments.
+func oggHeaderPackets(r io.ReaderAt, size int64) ([2][]byte, uint32, error) {
+	var packets [2][]byte
+	var serial uint32
+	var packet []byte
+	n := 0
+
+	for off := int64(0); off < size && n < len(packets); {
+		header, err := readAt(r, off, oggPageHeaderSize)
+		if err != nil {
+			return packets, 0, err
+		}
+		if !bytes.HasPrefix(header, []byte("OggS")) {
+			return packets, 0, errMalformed
+		}
+
+		pageSerial := binary.LittleEndian.Uint32(header[14:18])
+		if off == 0 {
+			serial = pageSerial
+		}
+
+		segments, err := readAt(r, off+oggPageHeaderSize, int(header[26]))
+		if err != nil {
+			return packets, 0, err
+		}
+
+		bodySize := 0
+		for _, s := range segments {
+			bodySize += int(s)
+		}
+
+		bodyOff := off + oggPageHeaderSize + int64(len(segments))
+		off = bodyOff + int64(bodySize)
+
+		// Pages of other multiplexed streams are skipped
+		if pageSerial != serial {
+			continue
+		}
+
+		body, err := readAt(r, bodyOff, bodySize)
+		if err != nil {
+			return packets, 0, err
+		}
+
+		// Packets are split into segments of 255 bytes, with a shorter one ending each packet
+		for _, s := range segments {
+			packet = append(packet, body[:s]...)
+			body = body[s:]
+			if len(packet) > maxBlockSize {
+				return packets, 0, errMalformed
+			}
+
+			if s < 255 && n < len(packets) {
+				packets[n], packet = packet, nil
+				n++
+			}
+		}
+	}
+
+	if n < len(packets) {
+		return packets, 0, errMalformed
+	}
+	return packets, serial, nil
+}
+
+// oggLastGranule finds the granule position of the last page of the stream, which is the number of samples in it.
+func oggLastGranule(r io.ReaderAt, size int64, serial uint32) (uint64, error) {
+	start := max(size-oggTailSize, 0)
+	tail, err := readAt(r, start, int(size-start))
+	if err != nil {
+		return 0, err
+	}
+
+	for i := len(tail) - oggPageHeaderSize; i >= 0; i-- {
+		page := tail[i:]
+		if !bytes.HasPrefix(page, []byte("OggS")) || binary.LittleEndian.Uint32(page[14:18]) != serial {
+			continue
+		}
+
+		// Pages with no packets ending on them have the granule position of -1
+		if granule := binary.LittleEndian.Uint64(page[6:14]); granule != 1<<64-1 {
+			return granule, nil
+		}
+	}
+	return 0, errMalformed
+}
@@ -0,0 +1,106 @@
+package tags
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+const riffChunkHeaderSize = 8
+
+// readWAV reads the INFO list of a WAV file, along with an ID3v2 tag embedded as a chunk, which only fills in what the list lacks.
+func readWAV(r io.ReaderAt, size int64, t *Tags) error {
+	header, err := readAt(r, 0, 12)
+	if err != nil {
+		return err
+	}
+	if !bytes.HasPrefix(header, []byte("RIFF")) || !bytes.Equal(header[8:12], []byte("WAVE")) {
+		return errMalformed
+	}
+
+	var id3 Tags
+	defer t.fill(&id3)
+
+	var byteRate, dataSize int64
+	defer func() {
+		if byteRate > 0 {
+			t.Duration = time.Duration(dataSize*1000/byteRate) * time.Millisecond
+		}
+	}()
+
+	for off := int64(12); off+riffChunkHeaderSize <= size; {
+		header, err := readAt(r, off, riffChunkHeaderSize)
+		if err != nil {
+			return err
+		}
+
+		id, length := string(header[:4]), int64(binary.LittleEndian.Uint32(header[4:8]))
+		off += riffChunkHeaderSize
+
+		switch id {
+		case "fmt ":
+			format, err := readAt(r, off, 12)
+			if err != nil {
+				return err
+			}
+			byteRate = int64(binary.LittleEndian.Uint32(format[8:12]))
+		case "data":
+			// Streaming encoders leave the size of the data unset, and the data lasts until the end of the file then
+			dataSize = min(length, size-off)
+		case "LIST":
+			if length <= maxBlockSize {
+				list, err := readAt(r, off, int(length))
+				if err != nil {
+					return err
+				}
+
+				if info, ok := bytes.CutPrefix(list, []byte("INFO")); ok {
+					parseRIFFInfo(info, t)
+				}
+			}
+		case "id3 ", "ID3 ":
+			if _, err := readID3v2(r, off, &id3); err != nil {
+				return err
+			}
+		}
+
+		// Chunks are padded to an even length
+		off += length + length%2
+	}
+	return nil
+}
+
+// parseRIFFInfo parses the subchunks of an INFO list, each holding a null-terminated string.
+func parseRIFFInfo(b []byte, t *Tags) {
+	for len(b) >= riffChunkHeaderSize {
+		id, length := string(b[:4]), int(binary.LittleEndian.Uint32(b[4:8]))
+		b = b[riffChunkHeaderSize:]
+		if length > len(b) {
+			return
+		}
+
+		value := decodeLegacyText(b[:length])
+		b = b[min(length+length%2, len(b)):]
+		if value == "" {
+			continue
+		}
+
+		switch id {
+		case "INAM":
+			t.Title = value
+		case "IART":
+			t.Artists = []string{value}
+		case "IPRD":
+			t.Album = value
+		case "ITRK", "IPRT":
+			fillZero(&t.TrackNumber, parseNumber(value))
+		case "ICRD":
+			t.Year = parseYear(value)
+		case "IGNR":
+			t.Genres = addGenre(t.Genres, value)
+		case "ICMT":
+			t.Comment = value
+		}
+	}
+}
@@ -0,0 +1,166 @@
+// Package tags reads metadata embedded in audio files and computes their duration from the stream headers.
+package tags
+
+import (
+	"errors"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cerfical/muzik/internal/audiofmt"
+)
+
+// Tags is a format-neutral representation of the metadata of an audio file.
+//
+// Values missing from the file are left zero.
+type Tags struct {
+	Title   string
+	Artists []string
+	Album   string
+
+	TrackNumber int
+	DiscNumber  int
+	Year        int
+
+	Genres  []string
+	ISRC    string
+	BPM     float64
+	Comment string
+
+	// Duration is the playback length computed from the stream headers.
+	Duration time.Duration
+}
+
+// maxBlockSize limits the size of tag blocks read into memory, such as ID3v2 tags or Vorbis comments.
+// Larger blocks are skipped, which mostly happens with embedded cover art.
+const maxBlockSize = 16 << 20
+
+// errMalformed aborts reading of a malformed or truncated part of a file, keeping the tags read before.
+var errMalformed = errors.New("malformed audio file")
+
+// Read reads the tags of an audio file of the given size and format.
+//
+// Reading is best-effort, with malformed or unsupported parts of the file skipped,
+// so errors are only returned when the file itself cannot be read.
+func Read(r io.ReaderAt, size int64, format *audiofmt.Format) (*Tags, error) {
+	var t Tags
+	var err error
+	switch format {
+	case audiofmt.MP3:
+		err = readMP3(r, size, &t)
+	case audiofmt.FLAC:
+		err = readFLAC(r, size, &t)
+	case audiofmt.Ogg:
+		err = readOgg(r, size, &t)
+	case audiofmt.WAV:
+		err = readWAV(r, size, &t)
+	}
+
+	if err != nil && !errors.Is(err, errMalformed) {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// fill sets the missing values from other tags.
+func (t *Tags) fill(other *Tags) {
+	fillZero(&t.Title, other.Title)
+	fillZero(&t.Album, other.Album)
+	fillZero(&t.TrackNumber, other.TrackNumber)
+	fillZero(&t.DiscNumber, other.DiscNumber)
+	fillZero(&t.Year, other.Year)
+	fillZero(&t.ISRC, other.ISRC)
+	fillZero(&t.BPM, other.BPM)
+	fillZero(&t.Comment, other.Comment)
+	fillZero(&t.Duration, other.Duration)
+
+	if len(t.Artists) == 0 {
+		t.Artists = other.Artists
+	}
+	if len(t.Genres) == 0 {
+		t.Genres = other.Genres
+	}
+}
+
+func fillZero[T comparable](dst *T, src T) {
+	var zero T
+	if *dst == zero {
+		*dst = src
+	}
+}
+
+// readAt reads exactly n bytes at the offset, reporting truncated files as malformed.
+func readAt(r io.ReaderAt, off int64, n int) ([]byte, error) {
+	if n < 0 || off < 0 {
+		return nil, errMalformed
+	}
+
+	b := make([]byte, n)
+	if _, err := r.ReadAt(b, off); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, errMalformed
+		}
+		return nil, err
+	}
+	return b, nil
+}
+
+// parseNumber parses numbers such as track numbers, which may be followed by the total, as in "3/12".
+func parseNumber(s string) int {
+	s, _, _ = strings.Cut(strings.TrimSpace(s), "/")
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// parseYear parses the year from a date, which may be just the year or a full timestamp, as in "2011-05-03T10:00".
+func parseYear(s string) int {
+	s = strings.TrimSpace(s)
+	if len(s) < 4 {
+		return 0
+	}
+	return parseNumber(s[:4])
+}
+
+func parseBPM(s string) float64 {
+	bpm, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || bpm <= 0 || math.IsInf(bpm, 0) {
+		return 0
+	}
+	return bpm
+}
+
+// addGenre adds a genre unless it is empty or already present.
+func addGenre(genres []string, genre string) []string {
+	genre = strings.TrimSpace(genre)
+	if genre == "" || slices.ContainsFunc(genres, func(g string) bool { return strings.EqualFold(g, genre) }) {
+		return genres
+	}
+	return append(genres, genre)
+}
+
+// decodeLegacyText decodes text of an unspecified encoding, which is assumed to be UTF-8 if valid and Latin-1 otherwise.
+func decodeLegacyText(b []byte) string {
+	if i := slices.Index(b, 0); i >= 0 {
+		b = b[:i]
+	}
+
+	if utf8.Valid(b) {
+		return strings.TrimSpace(string(b))
+	}
+	return strings.TrimSpace(decodeLatin1(b))
+}
+
+func decodeLatin1(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
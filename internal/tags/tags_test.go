@@ -0,0 +1,401 @@
+package tags_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/cerfical/muzik/internal/audiofmt"
+	"github.com/cerfical/muzik/internal/model"
+	"github.com/cerfical/muzik/internal/tags"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestTags(t *testing.T) {
+	suite.Run(t, new(TagsTest))
+}
+
+type TagsTest struct {
+	suite.Suite
+}
+
+func (t *TagsTest) TestRead_MP3() {
+	tests := []struct {
+		name string
+		file []byte
+		want tags.Tags
+	}{
+		{"id3v23_cbr", concat(
+			id3v2(3,
+				frame("TIT2", utf16Text("Song ü")),
+				frame("TPE1", latin1Text("Artist")),
+				frame("TALB", latin1Text("Album")),
+				frame("TRCK", latin1Text("3/12")),
+				frame("TPOS", latin1Text("1")),
+				frame("TYER", latin1Text("2011")),
+				frame("TCON", latin1Text("(17)")),
+				frame("TSRC", latin1Text("USRC17607839")),
+				frame("TBPM", latin1Text("120")),
+				frame("COMM", []byte("\x00engiTunNORM\x00 0000\x00")),
+				frame("COMM", []byte("\x00eng\x00Nice one")),
+			),
+			cbrFrames(10),
+		), tags.Tags{
+			Title:       "Song ü",
+			Artists:     []string{"Artist"},
+			Album:       "Album",
+			TrackNumber: 3,
+			DiscNumber:  1,
+			Year:        2011,
+			Genres:      []string{"Rock"},
+			ISRC:        "USRC17607839",
+			BPM:         120,
+			Comment:     "Nice one",
+			Duration:    260 * time.Millisecond,
+		}},
+		{"id3v24_vbr", concat(
+			id3v2(4,
+				frame("TIT2", utf8Text("Song")),
+				frame("TPE1", utf8Text("First\x00Second")),
+				frame("TDRC", utf8Text("2019-05-03T10:00")),
+				frame("TCON", utf8Text("Jazz\x0032")),
+			),
+			xingFrame(100),
+			cbrFrames(2),
+		), tags.Tags{
+			Title:    "Song",
+			Artists:  []string{"First", "Second"},
+			Year:     2019,
+			Genres:   []string{"Jazz", "Classical"},
+			Duration: 2612 * time.Millisecond,
+		}},
+		{"id3v22", concat(
+			id3v2(2,
+				id3v22Frame("TT2", latin1Text("Old")),
+				id3v22Frame("TCO", latin1Text("(8)Smooth Jazz")),
+			),
+			cbrFrames(1),
+		), tags.Tags{
+			Title:    "Old",
+			Genres:   []string{"Smooth Jazz"},
+			Duration: 26 * time.Millisecond,
+		}},
+		{"id3v1_fills_id3v2", concat(
+			id3v2(3, frame("TIT2", latin1Text("New title"))),
+			cbrFrames(10),
+			id3v1("Old title", "Artist", 7, 13),
+		), tags.Tags{
+			Title:       "New title",
+			Artists:     []string{"Artist"},
+			TrackNumber: 7,
+			Genres:      []string{"Pop"},
+			Duration:    260 * time.Millisecond,
+		}},
+		{"no_frames", id3v2(3, frame("TIT2", latin1Text("Song"))), tags.Tags{
+			Title: "Song",
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func() {
+			got, err := tags.Read(bytes.NewReader(test.file), int64(len(test.file)), audiofmt.MP3)
+			t.Require().NoError(err)
+			t.Equal(test.want, *got)
+		})
+	}
+}
+
+func (t *TagsTest) TestRead_FLAC() {
+	info := make([]byte, 34)
+	binary.BigEndian.PutUint64(info[10:], 44100<<44|1<<41|15<<36|441000)
+
+	file := concat(
+		[]byte("fLaC"),
+		flacBlock(0, false, info),
+		flacBlock(1, false, make([]byte, 16)),
+		flacBlock(4, true, vorbisComment("TITLE=Song", "ARTIST=A", "ARTIST=B", "TRACKNUMBER=2/9", "DATE=2001-01-01", "GENRE=Rock", "GENRE=rock", "bpm=98.5")),
+	)
+
+	got, err := tags.Read(bytes.NewReader(file), int64(len(file)), audiofmt.FLAC)
+	t.Require().NoError(err)
+	t.Equal(tags.Tags{
+		Title:       "Song",
+		Artists:     []string{"A", "B"},
+		TrackNumber: 2,
+		Year:        2001,
+		Genres:      []string{"Rock"},
+		BPM:         98.5,
+		Duration:    10 * time.Second,
+	}, *got)
+}
+
+func (t *TagsTest) TestRead_Ogg() {
+	t.Run("vorbis", func() {
+		ident := make([]byte, 30)
+		copy(ident, "\x01vorbis")
+		binary.LittleEndian.PutUint32(ident[12:], 44100)
+
+		file := concat(
+			oggPage(7, 0, ident),
+			oggPage(7, 0, concat([]byte("\x03vorbis"), vorbisComment("TITLE=Song", "ALBUM=Album"), []byte{1})),
+			oggPage(7, 88200, make([]byte, 600)),
+		)
+
+		got, err := tags.Read(bytes.NewReader(file), int64(len(file)), audiofmt.Ogg)
+		t.Require().NoError(err)
+		t.Equal(tags.Tags{Title: "Song", Album: "Album", Duration: 2 * time.Second}, *got)
+	})
+
+	t.Run("opus", func() {
+		ident := make([]byte, 19)
+		copy(ident, "OpusHead")
+		binary.LittleEndian.PutUint16(ident[10:], 312)
+
+		file := concat(
+			oggPage(3, 0, ident),
+			oggPage(3, 0, concat([]byte("OpusTags"), vorbisComment("TITLE=Song"))),
+			oggPage(3, 48312, make([]byte, 10)),
+		)
+
+		got, err := tags.Read(bytes.NewReader(file), int64(len(file)), audiofmt.Ogg)
+		t.Require().NoError(err)
+		t.Equal(tags.Tags{Title: "Song", Duration: time.Second}, *got)
+	})
+}
+
+func (t *TagsTest) TestRead_WAV() {
+	format := make([]byte, 16)
+	binary.LittleEndian.PutUint16(format[0:], 1)
+	binary.LittleEndian.PutUint16(format[2:], 2)
+	binary.LittleEndian.PutUint32(format[4:], 44100)
+	binary.LittleEndian.PutUint32(format[8:], 176400)
+
+	info := concat([]byte("INFO"),
+		riffChunk("INAM", []byte("Song\x00")),
+		riffChunk("IART", []byte("Art\xeest\x00")),
+		riffChunk("ICRD", []byte("2019-01-01\x00")),
+		riffChunk("IGNR", []byte("Ambient\x00")),
+	)
+
+	body := concat([]byte("WAVE"),
+		riffChunk("fmt ", format),
+		riffChunk("LIST", info),
+		riffChunk("data", make([]byte, 17640)),
+		riffChunk("id3 ", id3v2(3, frame("TIT2", latin1Text("Other")), frame("TALB", latin1Text("Album")))),
+	)
+	file := riffChunk("RIFF", body)
+
+	got, err := tags.Read(bytes.NewReader(file), int64(len(file)), audiofmt.WAV)
+	t.Require().NoError(err)
+	t.Equal(tags.Tags{
+		Title:    "Song",
+		Artists:  []string{"Artîst"},
+		Album:    "Album",
+		Year:     2019,
+		Genres:   []string{"Ambient"},
+		Duration: 100 * time.Millisecond,
+	}, *got)
+}
+
+func (t *TagsTest) TestRead_Malformed() {
+	tests := []struct {
+		name   string
+		format *audiofmt.Format
+		file   []byte
+	}{
+		{"mp3_garbage", audiofmt.MP3, []byte("ID3\x03\x00\x00\x00\x00\x7F\x7Fshort")},
+		{"flac_truncated", audiofmt.FLAC, []byte("fLaC\x00\x00\x00\x22\x10\x00")},
+		{"ogg_truncated", audiofmt.Ogg, []byte("OggS\x00\x02")},
+		{"wav_truncated", audiofmt.WAV, []byte("RIFF\x24\x00\x00\x00WAVEfmt \x10\x00\x00\x00")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func() {
+			got, err := tags.Read(bytes.NewReader(test.file), int64(len(test.file)), test.format)
+			t.Require().NoError(err)
+			t.Zero(got.Duration)
+		})
+	}
+}
+
+func (t *TagsTest) TestAttrs() {
+	tg := tags.Tags{
+		Title:       "Song",
+		TrackNumber: 3,
+		Year:        20111,
+		Genres:      []string{"Rock"},
+		ISRC:        "us-rc1-76-07839",
+		BPM:         120,
+		Duration:    1500 * time.Millisecond,
+	}
+
+	t.Equal(model.TrackAttrs{
+		Title:       "Song",
+		Duration:    ptr(1500),
+		TrackNumber: ptr(3),
+		Genres:      []string{"Rock"},
+		ISRC:        "USRC17607839",
+		BPM:         ptr(120.0),
+	}, tg.Attrs())
+
+	t.Equal(model.TrackAttrs{Year: ptr(2000)}, (&tags.Tags{Year: 2000}).Attrs())
+}
+
+func (t *TagsTest) TestApply() {
+	attrs := model.TrackAttrs{
+		Title:    "song",
+		Duration: ptr(200_400),
+		Year:     ptr(2010),
+		Genres:   []string{"rock"},
+	}
+
+	changed, conflicts := tags.Apply(&attrs, &model.TrackAttrs{
+		Title:       "Song",
+		Duration:    ptr(200_000),
+		TrackNumber: ptr(4),
+		Year:        ptr(2011),
+		Genres:      []string{"Rock", "Pop"},
+		Comment:     "Comment",
+	})
+
+	t.True(changed)
+	t.Equal([]tags.Conflict{
+		{Field: "year", Value: 2011},
+		{Field: "genres", Value: []string{"Rock", "Pop"}},
+	}, conflicts)
+	t.Equal(model.TrackAttrs{
+		Title:       "song",
+		Duration:    ptr(200_400),
+		TrackNumber: ptr(4),
+		Year:        ptr(2010),
+		Genres:      []string{"rock"},
+		Comment:     "Comment",
+	}, attrs)
+
+	changed, conflicts = tags.Apply(&attrs, &model.TrackAttrs{Title: "Song"})
+	t.False(changed)
+	t.Empty(conflicts)
+}
+
+func id3v2(version byte, frames ...[]byte) []byte {
+	body := concat(frames...)
+	size := len(body)
+	return concat(
+		[]byte{'I', 'D', '3', version, 0, 0, byte(size >> 21 & 0x7F), byte(size >> 14 & 0x7F), byte(size >> 7 & 0x7F), byte(size & 0x7F)},
+		body,
+	)
+}
+
+// frame makes an ID3v2.3 frame, whose size is not syncsafe, which makes no difference for small frames of ID3v2.4.
+func frame(id string, data []byte) []byte {
+	header := make([]byte, 10)
+	copy(header, id)
+	binary.BigEndian.PutUint32(header[4:], uint32(len(data)))
+	return concat(header, data)
+}
+
+func id3v22Frame(id string, data []byte) []byte {
+	n := len(data)
+	return concat([]byte(id), []byte{byte(n >> 16), byte(n >> 8), byte(n)}, data)
+}
+
+func latin1Text(s string) []byte {
+	b := []byte{0}
+	for _, r := range s {
+		b = append(b, byte(r))
+	}
+	return b
+}
+
+func utf8Text(s string) []byte {
+	return append([]byte{3}, s...)
+}
+
+func utf16Text(s string) []byte {
+	b := []byte{1, 0xFF, 0xFE}
+	for _, u := range utf16.Encode([]rune(s)) {
+		b = binary.LittleEndian.AppendUint16(b, u)
+	}
+	return b
+}
+
+func id3v1(title, artist string, track, genre byte) []byte {
+	tag := make([]byte, 128)
+	copy(tag, "TAG")
+	copy(tag[3:], title)
+	copy(tag[33:], artist)
+	tag[126] = track
+	tag[127] = genre
+	return tag
+}
+
+// cbrFrames makes MPEG-1 Layer III frames of 128 kbit/s at 44.1 kHz, lasting 26 ms each.
+func cbrFrames(n int) []byte {
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+	return bytes.Repeat(frame, n)
+}
+
+// xingFrame makes a frame with a Xing header stating the number of frames in the stream.
+func xingFrame(frames uint32) []byte {
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+	copy(frame[36:], "Xing")
+	binary.BigEndian.PutUint32(frame[40:], 1)
+	binary.BigEndian.PutUint32(frame[44:], frames)
+	return frame
+}
+
+func flacBlock(blockType byte, last bool, data []byte) []byte {
+	if last {
+		blockType |= 0x80
+	}
+	n := len(data)
+	return concat([]byte{blockType, byte(n >> 16), byte(n >> 8), byte(n)}, data)
+}
+
+func vorbisComment(fields ...string) []byte {
+	b := binary.LittleEndian.AppendUint32(nil, 4)
+	b = append(b, "test"...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(fields)))
+	for _, f := range fields {
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(f)))
+		b = append(b, f...)
+	}
+	return b
+}
+
+// oggPage makes a page holding a single packet, without a valid checksum.
+func oggPage(serial uint32, granule uint64, packet []byte) []byte {
+	var lacing []byte
+	n := len(packet)
+	for ; n >= 255; n -= 255 {
+		lacing = append(lacing, 255)
+	}
+	lacing = append(lacing, byte(n))
+
+	header := make([]byte, 27)
+	copy(header, "OggS")
+	binary.LittleEndian.PutUint64(header[6:], granule)
+	binary.LittleEndian.PutUint32(header[14:], serial)
+	header[26] = byte(len(lacing))
+	return concat(header, lacing, packet)
+}
+
+func riffChunk(id string, data []byte) []byte {
+	b := concat([]byte(id), binary.LittleEndian.AppendUint32(nil, uint32(len(data))), data)
+	if len(data)%2 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,68 @@
+package tags
+
+import (
+	"encoding/binary"
+	"strings"
+)
+
+// parseVorbisComment parses a Vorbis comment block, as used by FLAC and Ogg, made of a vendor string followed by KEY=value fields.
+func parseVorbisComment(b []byte, t *Tags) {
+	next := func() (string, bool) {
+		if len(b) < 4 {
+			return "", false
+		}
+
+		n := binary.LittleEndian.Uint32(b)
+		if uint64(n) > uint64(len(b)-4) {
+			return "", false
+		}
+
+		s := string(b[4 : 4+n])
+		b = b[4+n:]
+		return s, true
+	}
+
+	// Skip the vendor string
+	if _, ok := next(); !ok || len(b) < 4 {
+		return
+	}
+
+	count := binary.LittleEndian.Uint32(b)
+	b = b[4:]
+
+	for range count {
+		field, ok := next()
+		if !ok {
+			return
+		}
+
+		key, value, ok := strings.Cut(field, "=")
+		value = strings.TrimSpace(value)
+		if !ok || value == "" {
+			continue
+		}
+
+		switch strings.ToUpper(key) {
+		case "TITLE":
+			fillZero(&t.Title, value)
+		case "ARTIST":
+			t.Artists = append(t.Artists, value)
+		case "ALBUM":
+			fillZero(&t.Album, value)
+		case "TRACKNUMBER":
+			fillZero(&t.TrackNumber, parseNumber(value))
+		case "DISCNUMBER":
+			fillZero(&t.DiscNumber, parseNumber(value))
+		case "DATE", "YEAR":
+			fillZero(&t.Year, parseYear(value))
+		case "GENRE":
+			t.Genres = addGenre(t.Genres, value)
+		case "ISRC":
+			fillZero(&t.ISRC, value)
+		case "BPM":
+			fillZero(&t.BPM, parseBPM(value))
+		case "COMMENT", "DESCRIPTION":
+			fillZero(&t.Comment, value)
+		}
+	}
+}
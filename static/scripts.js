@@ -6,10 +6,11 @@ function createTrack() {
     fetch("/api/tracks/", {
         method: "POST",
         headers: {
-            "Content-Type": "application/json",
+            "Content-Type": "application/vnd.api+json",
         },
         body: JSON.stringify({
             data: {
+                type: "tracks",
                 attributes: {
                     title: titleInput.value,
                 },
@@ -29,16 +30,27 @@ function deleteTrack(element) {
     }).then(updateTracksTable);
 }
 
-function updateTracksTable() {
-    fetch("/api/tracks/")
+function fetchAllTracks(url, tracks = []) {
+    return fetch(url)
         .then((response) => response.json())
+        .then((page) => {
+            tracks.push(...page["data"]);
+            if (page["links"] && page["links"]["next"]) {
+                return fetchAllTracks(page["links"]["next"], tracks);
+            }
+            return tracks;
+        });
+}
+
+function updateTracksTable() {
+    fetchAllTracks("/api/tracks/?page[size]=100")
         .then((tracks) => {
             const tracksTable = document
                 .getElementById("tracksTable")
                 .getElementsByTagName("tbody")[0];
             tracksTable.innerHTML = "";
 
-            tracks["data"].forEach((track) => {
+            tracks.forEach((track) => {
                 const newRow = tracksTable.insertRow();
                 const id = newRow.insertCell();
                 id.innerHTML = track["id"];